@@ -0,0 +1,88 @@
+// Command airtable-provision creates the Airtable tables and fields the API
+// depends on, using the same configuration as the server. By default it only
+// prints the changes it would make; pass -apply to make them.
+//
+//	go run ./cmd/airtable-provision           # print the plan
+//	go run ./cmd/airtable-provision -apply    # create missing tables and fields
+//
+// Existing fields are never changed or deleted. The API token needs the
+// schema.bases:read and schema.bases:write scopes.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/config"
+	"lam-phuong-api/internal/location"
+	"lam-phuong-api/internal/user"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "create the missing tables and fields instead of only printing the plan")
+	tenantName := flag.String("tenant", "", "provision only this tenant (default: every configured tenant)")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	tenants, err := cfg.AirtableTenants()
+	if err != nil {
+		log.Fatalf("Invalid tenant configuration: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	provisioned, problems := 0, 0
+	for _, t := range tenants {
+		if *tenantName != "" && t.Name != *tenantName {
+			continue
+		}
+		provisioned++
+
+		client, err := t.Airtable.NewClient()
+		if err != nil {
+			log.Fatalf("Failed to create Airtable client for tenant %s: %v", t.Name, err)
+		}
+		provisioner := airtable.NewProvisioner(client,
+			location.AirtableSchema(t.Airtable.LocationsTableName),
+			user.AirtableSchema(t.Airtable.UsersTableName, t.Airtable.LocationsTableName),
+			location.SlugHistorySchema(t.Airtable.SlugHistoryTableName),
+		)
+
+		plan, err := provisioner.Plan(ctx)
+		if err != nil {
+			log.Fatalf("Failed to read the schema of tenant %s: %v", t.Name, err)
+		}
+		fmt.Printf("Tenant %s (base %s):\n%s", t.Name, t.Airtable.BaseID, plan)
+		problems += len(plan.Problems)
+
+		switch {
+		case plan.Empty():
+			fmt.Println("Nothing to create.")
+		case !*apply:
+			fmt.Println("Dry run: run again with -apply to make these changes.")
+		default:
+			if err := provisioner.Apply(ctx, plan); err != nil {
+				log.Fatalf("Failed to provision tenant %s: %v", t.Name, err)
+			}
+			fmt.Println("Done.")
+		}
+		fmt.Println()
+	}
+
+	if provisioned == 0 {
+		log.Fatalf("Unknown tenant %q", *tenantName)
+	}
+	if problems > 0 {
+		fmt.Printf("%d problems need to be fixed in Airtable by hand.\n", problems)
+		os.Exit(1)
+	}
+}
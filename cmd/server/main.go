@@ -1,15 +1,20 @@
 package main
 
 import (
+	"context"
 	"log"
+	"log/slog"
 	"os"
 	"strings"
 	"time"
 
 	docs "lam-phuong-api/docs" // Import docs for Swagger
+	"lam-phuong-api/internal/airtable"
 	"lam-phuong-api/internal/config"
 	"lam-phuong-api/internal/location"
+	"lam-phuong-api/internal/publicid"
 	"lam-phuong-api/internal/server"
+	"lam-phuong-api/internal/tenant"
 	"lam-phuong-api/internal/user"
 )
 
@@ -88,37 +93,127 @@ func main() {
 
 	// Initialize seed data
 	locationSeed := []location.Location{
-		{ID: "1", Name: "Main Library", Slug: "main-library"},
-		{ID: "2", Name: "West Branch", Slug: "west-branch"},
+		{ID: "01M51TJPM7V7CVVMFFFQTBB5DB", Name: "Main Library", Slug: "main-library"},
+		{ID: "01M51TJPM79YVCH0PTHD1S6CF3", Name: "West Branch", Slug: "west-branch"},
 	}
 
-	// Create in-memory repository
-	baseRepo := location.NewInMemoryRepository(locationSeed)
+	// Initialize user seed data
+	userSeed := []user.User{}
 
-	// Wrap with Airtable repository for persistence
-	airtableClient, err := cfg.NewAirtableClient()
+	// Count Airtable requests per table, operation and route, and optionally log each one
+	airtableMetrics := airtable.NewMetrics()
+	airtableHooks := []airtable.Hook{airtableMetrics}
+	if cfg.Airtable.LogRequests {
+		airtableHooks = append(airtableHooks, airtable.NewLogHook(slog.Default()))
+	}
+
+	tenants, err := cfg.AirtableTenants()
 	if err != nil {
-		log.Fatalf("Failed to create Airtable client: %v", err)
+		log.Fatalf("Invalid tenant configuration: %v", err)
 	}
-	locationRepo := location.NewAirtableRepository(baseRepo, airtableClient, cfg.Airtable.LocationsTableName)
 
-	locationHandler := location.NewHandler(locationRepo)
+	// Each tenant gets its own Airtable client, repositories and caches;
+	// requests are routed to them by the tenant of their host or token
+	var (
+		locationRepos    = make(map[string]location.Repository, len(tenants))
+		userRepos        = make(map[string]user.Repository, len(tenants))
+		airtableClients  = make(map[string]*airtable.Client, len(tenants))
+		writeQueues      = make(map[string]*airtable.WriteQueue)
+		schemaCheckers   = make(map[string]*airtable.SchemaChecker)
+		syncers          = make(map[string]*airtable.Syncer)
+		webhookListeners = make(map[string]*airtable.WebhookListener)
+		tenantHosts      = make(map[string][]string, len(tenants))
+	)
+	for _, t := range tenants {
+		airtableClient, err := t.Airtable.NewClient(
+			airtable.WithLinks(user.AirtableLinks(t.Airtable.UsersTableName, t.Airtable.LocationsTableName)...),
+			airtable.WithHooks(airtableHooks...),
+		)
+		if err != nil {
+			log.Fatalf("Failed to create Airtable client for tenant %s: %v", t.Name, err)
+		}
+		airtableClients[t.Name] = airtableClient
+		tenantHosts[t.Name] = t.Hosts
+
+		// Create in-memory repositories, seeded for the default tenant only
+		var seed []location.Location
+		if t.Name == tenant.Default {
+			seed = locationSeed
+		}
+		baseRepo := location.NewInMemoryRepository(seed)
+		baseUserRepo := user.NewInMemoryRepository(userSeed)
 
-	// Initialize user seed data
-	userSeed := []user.User{}
+		// Wrap with Airtable repositories for persistence
+		locationRepo := location.NewAirtableRepository(baseRepo, airtableClient, t.Airtable.LocationsTableName)
+		locationRepo.SetSlugHistoryTable(t.Airtable.SlugHistoryTableName)
+		userRepo := user.NewAirtableRepository(baseUserRepo, airtableClient, t.Airtable.UsersTableName)
+		locationRepos[t.Name] = locationRepo
+		userRepos[t.Name] = userRepo
+
+		// While the circuit breaker is open, either reject writes or queue them for replay
+		if cfg.Airtable.BreakerWriteMode == config.BreakerWriteQueue {
+			writeQueue := airtable.NewWriteQueue(airtableClient, cfg.Airtable.BreakerQueueSize)
+			locationRepo.SetWriteQueue(writeQueue)
+			userRepo.SetWriteQueue(writeQueue)
+			writeQueues[t.Name] = writeQueue
+		}
+
+		// Verify the Airtable tables and fields the repositories depend on
+		if cfg.Airtable.SchemaCheck != config.SchemaCheckOff {
+			schemaChecker := airtable.NewSchemaChecker(airtableClient,
+				location.AirtableSchema(t.Airtable.LocationsTableName),
+				user.AirtableSchema(t.Airtable.UsersTableName, t.Airtable.LocationsTableName),
+				location.SlugHistorySchema(t.Airtable.SlugHistoryTableName),
+			)
+			checkAirtableSchema(schemaChecker, cfg.Airtable.SchemaCheck, t.Name)
+			schemaCheckers[t.Name] = schemaChecker
+		}
+
+		// Give records created in the Airtable UI the public IDs the API exposes,
+		// then load the locations into the cache that searches and nearby lookups
+		// are answered from
+		go func() {
+			backfillPublicIDs(airtableClient, t.Name, map[string]string{
+				t.Airtable.LocationsTableName: location.FieldPublicID,
+				t.Airtable.UsersTableName:     user.FieldPublicID,
+			})
+			loadLocationCache(locationRepo, t.Name)
+		}()
+
+		// Keep the in-memory caches in sync with edits made directly in Airtable
+		if cfg.Airtable.WebhookURL != "" {
+			webhookListener := airtable.NewWebhookListener(airtableClient, server.WebhookURL(cfg.Airtable.WebhookURL, t.Name))
+			webhookListener.SetInstance(cfg.Airtable.WebhookInstance)
+			webhookListener.Handle(t.Airtable.LocationsTableName, baseRepo.ApplyAirtableChanges)
+			webhookListener.Handle(t.Airtable.UsersTableName, baseUserRepo.ApplyAirtableChanges)
+			registerAirtableWebhooks(webhookListener, t.Name)
+			webhookListeners[t.Name] = webhookListener
+		}
 
-	// Create in-memory user repository
-	baseUserRepo := user.NewInMemoryRepository(userSeed)
+		// Poll for records changed in Airtable, for deployments webhooks cannot reach
+		if cfg.Airtable.SyncIntervalMs > 0 {
+			syncer := airtable.NewSyncer(airtableClient, t.Airtable.SyncPolicy())
+			syncer.Handle(t.Airtable.LocationsTableName, location.FieldSlug, baseRepo.ApplyAirtableChanges)
+			syncer.Handle(t.Airtable.UsersTableName, user.FieldEmail, baseUserRepo.ApplyAirtableChanges)
+			go syncer.Run(context.Background())
+			syncers[t.Name] = syncer
+		}
+	}
 
-	// Wrap with Airtable repository for persistence
-	userRepo := user.NewAirtableRepository(baseUserRepo, airtableClient, cfg.Airtable.UsersTableName)
+	diagnosticsHandler := server.NewDiagnosticsHandler(schemaCheckers, syncers, airtableMetrics)
+	webhookHandler := server.NewWebhookHandler(webhookListeners)
+	healthHandler := server.NewHealthHandler(airtableClients, writeQueues)
+	tenantResolver := tenant.NewResolver(tenantHosts)
+
+	locationHandler := location.NewHandler(location.NewTenantRepository(locationRepos))
+	userRepo := user.NewTenantRepository(userRepos)
 
 	// Create user handler with JWT configuration
 	tokenExpiry := time.Duration(cfg.Auth.TokenExpiry) * time.Hour
 	userHandler := user.NewHandler(userRepo, cfg.Auth.JWTSecret, tokenExpiry)
 
 	// ✅ THÊM VERSION INFO VÀO ROUTER
-	router := server.NewRouter(locationHandler, userHandler, cfg.Auth.JWTSecret, Version, CommitHash, BuildTime)
+	router := server.NewRouter(locationHandler, userHandler, diagnosticsHandler, webhookHandler, healthHandler, airtableMetrics, tenantResolver, cfg.Auth.JWTSecret, Version, CommitHash, BuildTime)
 
 	// Use server address from config
 	serverAddr := cfg.ServerAddress()
@@ -127,3 +222,63 @@ func main() {
 		log.Fatalf("failed to run server: %v", err)
 	}
 }
+
+// checkAirtableSchema runs the startup schema check of a tenant. In "fail"
+// mode any problem stops the server; otherwise problems are logged as warnings.
+func checkAirtableSchema(checker *airtable.SchemaChecker, mode, tenantName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, _ := checker.Check(ctx)
+	if report.OK {
+		log.Printf("Airtable schema check passed for tenant %s", tenantName)
+		return
+	}
+
+	for _, problem := range report.Problems() {
+		log.Printf("Airtable schema problem for tenant %s: %s", tenantName, problem)
+	}
+	if mode == config.SchemaCheckFail {
+		log.Fatalf("Airtable schema check failed for tenant %s (set AIRTABLE_SCHEMA_CHECK=warn to start anyway)", tenantName)
+	}
+	log.Printf("WARNING: Airtable schema check failed for tenant %s; affected fields will read as empty values", tenantName)
+}
+
+// backfillPublicIDs assigns a public ID to the records of a tenant's tables
+// that lack one, given as a map of table to field. Failures are logged; the
+// repositories also assign missing IDs when they list records.
+func backfillPublicIDs(client *airtable.Client, tenantName string, fields map[string]string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	for table, field := range fields {
+		if _, err := publicid.Backfill(ctx, client, table, field); err != nil {
+			log.Printf("WARNING: Failed to backfill public IDs of %s for tenant %s: %v", table, tenantName, err)
+		}
+	}
+}
+
+// loadLocationCache loads a tenant's locations from Airtable into the
+// in-memory cache. Failures are logged; searches and nearby lookups then only
+// find locations created or synced since startup.
+func loadLocationCache(repo *location.AirtableRepository, tenantName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := repo.LoadCache(ctx); err != nil {
+		log.Printf("WARNING: Failed to load locations of tenant %s into the cache: %v", tenantName, err)
+	}
+}
+
+// registerAirtableWebhooks registers a tenant's webhooks and keeps them
+// refreshed. Failures are logged; the API keeps working without push updates.
+func registerAirtableWebhooks(listener *airtable.WebhookListener, tenantName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := listener.Register(ctx); err != nil {
+		log.Printf("WARNING: Failed to register Airtable webhooks for tenant %s: %v", tenantName, err)
+		return
+	}
+	go listener.Run(context.Background())
+}
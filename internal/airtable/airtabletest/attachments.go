@@ -0,0 +1,148 @@
+package airtabletest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+// storedFile is the content of an uploaded attachment, served for downloads.
+type storedFile struct {
+	contentType string
+	content     []byte
+}
+
+// handleAttachmentDownload serves GET /attachments/{id}/{filename}. Like
+// Airtable's pre-signed URLs, it needs no API key.
+func (s *Server) handleAttachmentDownload(w http.ResponseWriter, segments []string) {
+	s.mu.Lock()
+	file, ok := s.files[segments[1]]
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Header().Set("Content-Type", file.contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(file.content)
+}
+
+// uploadAttachment serves POST /{baseId}/{recordId}/{field}/uploadAttachment,
+// Airtable's content upload endpoint. The caller must hold s.mu.
+func (s *Server) uploadAttachment(w http.ResponseWriter, r *http.Request, recordID, field string) {
+	var req struct {
+		ContentType string `json:"contentType"`
+		Filename    string `json:"filename"`
+		File        string `json:"file"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ContentType == "" {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid request: contentType and file are required")
+		return
+	}
+	content, err := base64.StdEncoding.DecodeString(req.File)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid request: file must be base64 encoded")
+		return
+	}
+	if len(content) > airtable.MaxUploadSize {
+		writeError(w, http.StatusRequestEntityTooLarge, "INVALID_REQUEST_TOO_LARGE", "File is larger than 5 MB")
+		return
+	}
+
+	var (
+		t   *table
+		rec *record
+	)
+	for _, candidate := range s.tables {
+		if _, found := candidate.find(recordID); found != nil {
+			t, rec = candidate, found
+			break
+		}
+	}
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+
+	// Accept the field by ID or name; store by name and respond by ID like Airtable
+	fieldName, fieldKey := field, field
+	for _, f := range t.schema {
+		if f.ID == field || f.Name == field {
+			fieldName, fieldKey = f.Name, f.ID
+		}
+	}
+
+	id := newID("att")
+	s.files[id] = storedFile{contentType: req.ContentType, content: content}
+	attachment := map[string]interface{}{
+		"id":       id,
+		"url":      s.URL + "/attachments/" + id + "/" + url.PathEscape(req.Filename),
+		"filename": req.Filename,
+		"size":     len(content),
+		"type":     req.ContentType,
+	}
+
+	attachments, _ := rec.Fields[fieldName].([]interface{})
+	rec.setFields(map[string]interface{}{fieldName: append(attachments, attachment)}, false)
+	s.recordChanges(t, nil, []*record{rec}, nil)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":          rec.ID,
+		"createdTime": rec.CreatedTime,
+		"fields":      map[string]interface{}{fieldKey: rec.Fields[fieldName]},
+	})
+}
+
+// resolveAttachments turns attachment write values into stored attachments:
+// {"id": "att..."} keeps an existing attachment and {"url": ...} adds a new
+// one. Values that do not look like attachments are returned unchanged.
+func resolveAttachments(current, value interface{}) interface{} {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return value
+	}
+
+	existing := make(map[string]map[string]interface{})
+	if currentItems, ok := current.([]interface{}); ok {
+		for _, item := range currentItems {
+			if m, ok := item.(map[string]interface{}); ok {
+				if id, ok := m["id"].(string); ok {
+					existing[id] = m
+				}
+			}
+		}
+	}
+
+	resolved := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		id, _ := m["id"].(string)
+		rawURL, _ := m["url"].(string)
+
+		switch {
+		case strings.HasPrefix(id, "att") && existing[id] != nil:
+			resolved = append(resolved, existing[id])
+		case rawURL != "" && id == "":
+			filename, _ := m["filename"].(string)
+			if filename == "" {
+				filename = rawURL[strings.LastIndex(rawURL, "/")+1:]
+			}
+			resolved = append(resolved, map[string]interface{}{
+				"id":       newID("att"),
+				"url":      rawURL,
+				"filename": filename,
+			})
+		default:
+			return value
+		}
+	}
+	return resolved
+}
@@ -0,0 +1,720 @@
+package airtabletest
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// formula is a parsed filterByFormula expression.
+type formula struct {
+	root node
+}
+
+// evalContext is the record a formula is evaluated against.
+type evalContext struct {
+	record *record
+}
+
+type node interface {
+	eval(ctx evalContext) (interface{}, error)
+}
+
+// parseFormula parses the subset of the Airtable formula language supported
+// by the fake: field references, string/number literals, comparison and
+// arithmetic operators, "&" concatenation and the functions in formulaFuncs.
+func parseFormula(input string) (*formula, error) {
+	p := &formulaParser{input: input}
+	p.next()
+
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tok.text, p.tok.pos)
+	}
+	return &formula{root: root}, nil
+}
+
+// match reports whether the formula is truthy for the record.
+func (f *formula) match(r *record) (bool, error) {
+	v, err := f.root.eval(evalContext{record: r})
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+// Tokenizer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokString
+	tokNumber
+	tokField
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type formulaParser struct {
+	input string
+	pos   int
+	tok   token
+	err   error
+}
+
+func (p *formulaParser) next() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	start := p.pos
+	if p.pos >= len(p.input) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return
+	}
+
+	ch := p.input[p.pos]
+	switch {
+	case ch == '\'' || ch == '"':
+		p.pos++
+		var sb strings.Builder
+		for p.pos < len(p.input) && p.input[p.pos] != ch {
+			if p.input[p.pos] == '\\' && p.pos+1 < len(p.input) {
+				p.pos++
+				switch p.input[p.pos] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				case 'r':
+					sb.WriteByte('\r')
+				default:
+					sb.WriteByte(p.input[p.pos])
+				}
+				p.pos++
+				continue
+			}
+			sb.WriteByte(p.input[p.pos])
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			p.err = fmt.Errorf("unterminated string at position %d", start)
+			p.tok = token{kind: tokEOF, pos: start}
+			return
+		}
+		p.pos++
+		p.tok = token{kind: tokString, text: sb.String(), pos: start}
+	case ch == '{':
+		// Closing braces and backslashes in field names are backslash-escaped
+		var sb strings.Builder
+		p.pos++
+		for p.pos < len(p.input) && p.input[p.pos] != '}' {
+			if p.input[p.pos] == '\\' && p.pos+1 < len(p.input) {
+				p.pos++
+			}
+			sb.WriteByte(p.input[p.pos])
+			p.pos++
+		}
+		if p.pos >= len(p.input) {
+			p.err = fmt.Errorf("unterminated field reference at position %d", start)
+			p.tok = token{kind: tokEOF, pos: start}
+			return
+		}
+		p.pos++
+		p.tok = token{kind: tokField, text: sb.String(), pos: start}
+	case ch >= '0' && ch <= '9' || ch == '.':
+		for p.pos < len(p.input) && (p.input[p.pos] >= '0' && p.input[p.pos] <= '9' || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		p.tok = token{kind: tokNumber, text: p.input[start:p.pos], pos: start}
+	case ch == '_' || unicode.IsLetter(rune(ch)):
+		for p.pos < len(p.input) && (p.input[p.pos] == '_' || unicode.IsLetter(rune(p.input[p.pos])) || unicode.IsDigit(rune(p.input[p.pos]))) {
+			p.pos++
+		}
+		p.tok = token{kind: tokIdent, text: strings.ToUpper(p.input[start:p.pos]), pos: start}
+	case ch == '(':
+		p.pos++
+		p.tok = token{kind: tokLParen, text: "(", pos: start}
+	case ch == ')':
+		p.pos++
+		p.tok = token{kind: tokRParen, text: ")", pos: start}
+	case ch == ',':
+		p.pos++
+		p.tok = token{kind: tokComma, text: ",", pos: start}
+	default:
+		for _, op := range []string{"!=", "<=", ">=", "=", "<", ">", "&", "+", "-", "*", "/"} {
+			if strings.HasPrefix(p.input[p.pos:], op) {
+				p.pos += len(op)
+				p.tok = token{kind: tokOp, text: op, pos: start}
+				return
+			}
+		}
+		p.err = fmt.Errorf("unexpected character %q at position %d", ch, start)
+		p.tok = token{kind: tokEOF, pos: start}
+	}
+}
+
+// Parser (lowest to highest precedence): comparison, concatenation, additive, multiplicative, unary.
+
+func (p *formulaParser) parseExpr() (node, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && isComparison(p.tok.text) {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, p.err
+}
+
+func (p *formulaParser) parseConcat() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && p.tok.text == "&" {
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: "&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseAdditive() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && (p.tok.text == "+" || p.tok.text == "-") {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOp && (p.tok.text == "*" || p.tok.text == "/") {
+		op := p.tok.text
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseUnary() (node, error) {
+	if p.tok.kind == tokOp && p.tok.text == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: "-", left: literalNode{value: 0.0}, right: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *formulaParser) parsePrimary() (node, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	tok := p.tok
+	switch tok.kind {
+	case tokString:
+		p.next()
+		return literalNode{value: tok.text}, nil
+	case tokNumber:
+		p.next()
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return literalNode{value: n}, nil
+	case tokField:
+		p.next()
+		return fieldNode{name: tok.text}, nil
+	case tokLParen:
+		p.next()
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) at position %d", p.tok.pos)
+		}
+		p.next()
+		return inner, nil
+	case tokIdent:
+		p.next()
+		fn, ok := formulaFuncs[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unsupported function %s", tok.text)
+		}
+		if p.tok.kind != tokLParen {
+			return nil, fmt.Errorf("expected ( after %s", tok.text)
+		}
+		p.next()
+
+		var args []node
+		for p.tok.kind != tokRParen {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.tok.kind == tokComma {
+				p.next()
+				continue
+			}
+			if p.tok.kind != tokRParen {
+				return nil, fmt.Errorf("expected , or ) at position %d", p.tok.pos)
+			}
+		}
+		p.next()
+		return callNode{name: tok.text, fn: fn, args: args}, nil
+	}
+
+	if p.err != nil {
+		return nil, p.err
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "=", "!=", "<", ">", "<=", ">=":
+		return true
+	}
+	return false
+}
+
+// Nodes
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(evalContext) (interface{}, error) {
+	return n.value, nil
+}
+
+type fieldNode struct {
+	name string
+}
+
+func (n fieldNode) eval(ctx evalContext) (interface{}, error) {
+	return ctx.record.Fields[n.name], nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(ctx evalContext) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&":
+		return toString(left) + toString(right), nil
+	case "+", "-", "*", "/":
+		l, r := toNumber(left), toNumber(right)
+		switch n.op {
+		case "+":
+			return l + r, nil
+		case "-":
+			return l - r, nil
+		case "*":
+			return l * r, nil
+		default:
+			if r == 0 {
+				return math.NaN(), nil
+			}
+			return l / r, nil
+		}
+	}
+
+	cmp := compareValues(left, right)
+	switch n.op {
+	case "=":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+type callNode struct {
+	name string
+	fn   formulaFunc
+	args []node
+}
+
+func (n callNode) eval(ctx evalContext) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return n.fn(ctx, args)
+}
+
+// Functions
+
+type formulaFunc func(ctx evalContext, args []interface{}) (interface{}, error)
+
+var formulaFuncs map[string]formulaFunc
+
+func init() {
+	formulaFuncs = map[string]formulaFunc{
+		"AND": func(_ evalContext, args []interface{}) (interface{}, error) {
+			for _, a := range args {
+				if !truthy(a) {
+					return false, nil
+				}
+			}
+			return true, nil
+		},
+		"OR": func(_ evalContext, args []interface{}) (interface{}, error) {
+			for _, a := range args {
+				if truthy(a) {
+					return true, nil
+				}
+			}
+			return false, nil
+		},
+		"NOT": func(_ evalContext, args []interface{}) (interface{}, error) {
+			if err := wantArgs("NOT", args, 1, 1); err != nil {
+				return nil, err
+			}
+			return !truthy(args[0]), nil
+		},
+		"IF": func(_ evalContext, args []interface{}) (interface{}, error) {
+			if err := wantArgs("IF", args, 2, 3); err != nil {
+				return nil, err
+			}
+			if truthy(args[0]) {
+				return args[1], nil
+			}
+			if len(args) == 3 {
+				return args[2], nil
+			}
+			return nil, nil
+		},
+		"TRUE":  func(evalContext, []interface{}) (interface{}, error) { return true, nil },
+		"FALSE": func(evalContext, []interface{}) (interface{}, error) { return false, nil },
+		"BLANK": func(evalContext, []interface{}) (interface{}, error) { return nil, nil },
+		"LOWER": stringFunc("LOWER", strings.ToLower),
+		"UPPER": stringFunc("UPPER", strings.ToUpper),
+		"TRIM":  stringFunc("TRIM", strings.TrimSpace),
+		"LEN": func(_ evalContext, args []interface{}) (interface{}, error) {
+			if err := wantArgs("LEN", args, 1, 1); err != nil {
+				return nil, err
+			}
+			return float64(len([]rune(toString(args[0])))), nil
+		},
+		"CONCATENATE": func(_ evalContext, args []interface{}) (interface{}, error) {
+			var sb strings.Builder
+			for _, a := range args {
+				sb.WriteString(toString(a))
+			}
+			return sb.String(), nil
+		},
+		"ARRAYJOIN": func(_ evalContext, args []interface{}) (interface{}, error) {
+			if err := wantArgs("ARRAYJOIN", args, 1, 2); err != nil {
+				return nil, err
+			}
+			sep := ", "
+			if len(args) == 2 {
+				sep = toString(args[1])
+			}
+			items, ok := args[0].([]interface{})
+			if !ok {
+				return toString(args[0]), nil
+			}
+			parts := make([]string, len(items))
+			for i, item := range items {
+				parts[i] = toString(item)
+			}
+			return strings.Join(parts, sep), nil
+		},
+		// SEARCH is case-insensitive, FIND is case-sensitive. Both return the
+		// 1-based position of the first match, or 0 when there is none.
+		"SEARCH": findFunc("SEARCH", true),
+		"FIND":   findFunc("FIND", false),
+		"RECORD_ID": func(ctx evalContext, _ []interface{}) (interface{}, error) {
+			return ctx.record.ID, nil
+		},
+		"CREATED_TIME": func(ctx evalContext, _ []interface{}) (interface{}, error) {
+			return ctx.record.CreatedTime, nil
+		},
+		"LAST_MODIFIED_TIME": func(ctx evalContext, _ []interface{}) (interface{}, error) {
+			return ctx.record.ModifiedTime, nil
+		},
+		"NOW": func(evalContext, []interface{}) (interface{}, error) {
+			return time.Now().UTC(), nil
+		},
+		"DATETIME_PARSE": func(_ evalContext, args []interface{}) (interface{}, error) {
+			if err := wantArgs("DATETIME_PARSE", args, 1, 2); err != nil {
+				return nil, err
+			}
+			t, ok := toTime(args[0])
+			if !ok {
+				return nil, nil
+			}
+			return t, nil
+		},
+		"IS_AFTER":  timeCompareFunc("IS_AFTER", func(a, b time.Time) bool { return a.After(b) }),
+		"IS_BEFORE": timeCompareFunc("IS_BEFORE", func(a, b time.Time) bool { return a.Before(b) }),
+		"IS_SAME":   timeCompareFunc("IS_SAME", func(a, b time.Time) bool { return a.Equal(b) }),
+	}
+}
+
+func wantArgs(name string, args []interface{}, min, max int) error {
+	if len(args) < min || len(args) > max {
+		return fmt.Errorf("%s expects %d to %d arguments, got %d", name, min, max, len(args))
+	}
+	return nil
+}
+
+func stringFunc(name string, fn func(string) string) formulaFunc {
+	return func(_ evalContext, args []interface{}) (interface{}, error) {
+		if err := wantArgs(name, args, 1, 1); err != nil {
+			return nil, err
+		}
+		return fn(toString(args[0])), nil
+	}
+}
+
+func findFunc(name string, foldCase bool) formulaFunc {
+	return func(_ evalContext, args []interface{}) (interface{}, error) {
+		if err := wantArgs(name, args, 2, 3); err != nil {
+			return nil, err
+		}
+		needle, haystack := toString(args[0]), toString(args[1])
+		if foldCase {
+			needle, haystack = strings.ToLower(needle), strings.ToLower(haystack)
+		}
+		start := 0
+		if len(args) == 3 {
+			start = int(toNumber(args[2])) - 1
+			if start < 0 {
+				start = 0
+			}
+		}
+		runes := []rune(haystack)
+		if start > len(runes) {
+			return 0.0, nil
+		}
+		rest := string(runes[start:])
+		idx := strings.Index(rest, needle)
+		if idx < 0 {
+			return 0.0, nil
+		}
+		return float64(start + utf8.RuneCountInString(rest[:idx]) + 1), nil
+	}
+}
+
+func timeCompareFunc(name string, cmp func(a, b time.Time) bool) formulaFunc {
+	return func(_ evalContext, args []interface{}) (interface{}, error) {
+		if err := wantArgs(name, args, 2, 3); err != nil {
+			return nil, err
+		}
+		a, okA := toTime(args[0])
+		b, okB := toTime(args[1])
+		if !okA || !okB {
+			return false, nil
+		}
+		return cmp(a, b), nil
+	}
+}
+
+// Value helpers
+
+func truthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case float64:
+		return val != 0 && !math.IsNaN(val)
+	case string:
+		return val != ""
+	case []interface{}:
+		return len(val) > 0
+	default:
+		return true
+	}
+}
+
+func toString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		if val {
+			return "1"
+		}
+		return "0"
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		return val.UTC().Format(time.RFC3339)
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = toString(item)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		if name, ok := val["name"].(string); ok {
+			return name
+		}
+		if id, ok := val["id"].(string); ok {
+			return id
+		}
+		return fmt.Sprint(val)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func toNumber(v interface{}) float64 {
+	switch val := v.(type) {
+	case float64:
+		return val
+	case bool:
+		if val {
+			return 1
+		}
+		return 0
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil {
+			return 0
+		}
+		return n
+	default:
+		return 0
+	}
+}
+
+func toTime(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, val); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// compareValues orders two formula values: numerically when both are
+// numbers, chronologically when both are dates, and as strings otherwise.
+func compareValues(a, b interface{}) int {
+	_, aNum := a.(float64)
+	_, bNum := b.(float64)
+	if aNum || bNum {
+		if aNum && bNum || isNumeric(a) && isNumeric(b) {
+			return compareFloat(toNumber(a), toNumber(b))
+		}
+	}
+
+	_, aTime := a.(time.Time)
+	_, bTime := b.(time.Time)
+	if aTime || bTime {
+		ta, okA := toTime(a)
+		tb, okB := toTime(b)
+		if okA && okB {
+			return ta.Compare(tb)
+		}
+	}
+
+	return strings.Compare(toString(a), toString(b))
+}
+
+func isNumeric(v interface{}) bool {
+	switch val := v.(type) {
+	case float64, bool:
+		return true
+	case nil:
+		return true
+	case string:
+		_, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return err == nil
+	}
+	return false
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
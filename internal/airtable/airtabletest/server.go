@@ -0,0 +1,898 @@
+// Package airtabletest provides an in-process fake of the Airtable REST API
+// so code built on airtable.Client can be exercised without a real base.
+// Records, the Metadata API schema endpoint and webhooks (with signed
+// notifications and payload cursors) are supported. Tables and fields can be
+// read and created through the Metadata API.
+//
+//	srv := airtabletest.NewServer()
+//	defer srv.Close()
+//	srv.AddRecords("Locations", map[string]interface{}{"Name": "Main Library"})
+//	client, _ := srv.NewClient("appTest")
+package airtabletest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+const (
+	maxPageSize  = 100
+	maxBatchSize = 10
+)
+
+// Server is a fake Airtable API backed by httptest.Server. Tables are created
+// on first use and are shared by every base ID. It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	tables       map[string]*table
+	faults       []*Fault
+	requests     []Request
+	webhooks     map[string]*webhook
+	transactions int
+	files        map[string]storedFile // Uploaded attachment content, keyed by attachment ID
+}
+
+// Request is a request received by the fake, recorded for assertions.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+}
+
+// Fault makes matching requests fail or slow down. Empty Method and Table
+// match every request.
+type Fault struct {
+	Method     string        // HTTP method to match, e.g. "GET"
+	Table      string        // Table name to match
+	StatusCode int           // Status to respond with (e.g. 429 or 500); 0 only adds latency
+	RetryAfter time.Duration // Sent as a Retry-After header when non-zero
+	Latency    time.Duration // Delay before the request is handled
+	Times      int           // Number of requests to affect; 0 means until ClearFaults
+}
+
+type table struct {
+	id      string
+	schema  []airtable.FieldSchema // Set by DefineTable; nil for tables created on use
+	records []*record
+}
+
+type record struct {
+	ID           string
+	Fields       map[string]interface{}
+	CreatedTime  string
+	ModifiedTime string
+}
+
+// NewServer starts a fake Airtable server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		tables:   make(map[string]*table),
+		webhooks: make(map[string]*webhook),
+		files:    make(map[string]storedFile),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// NewClient returns an airtable.Client pointed at the fake server, including
+// the content upload endpoint. Retries
+// are fast and rate limiting is effectively disabled unless overridden by opts.
+func (s *Server) NewClient(baseID string, opts ...airtable.Option) (*airtable.Client, error) {
+	defaults := []airtable.Option{
+		airtable.WithBaseURL(s.URL),
+		airtable.WithContentURL(s.URL),
+		airtable.WithRateLimit(1000),
+		airtable.WithRetryPolicy(airtable.RetryPolicy{
+			MaxRetries:     3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+		}),
+	}
+	return airtable.NewClient("test-api-key", baseID, append(defaults, opts...)...)
+}
+
+// DefineTable declares a table and its fields so it is reported by the
+// Metadata API endpoint. Fields without an ID are given one.
+func (s *Server) DefineTable(tableName string, fields ...airtable.FieldSchema) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(tableName)
+	t.schema = make([]airtable.FieldSchema, len(fields))
+	for i, f := range fields {
+		if f.ID == "" {
+			f.ID = newID("fld")
+		}
+		t.schema[i] = f
+	}
+}
+
+// AddRecords seeds a table with records and returns them with their new IDs.
+// Webhooks are notified as if the records were created in the Airtable UI.
+func (s *Server) AddRecords(tableName string, fields ...map[string]interface{}) []airtable.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(tableName)
+	result := make([]airtable.Record, 0, len(fields))
+	created := make([]*record, 0, len(fields))
+	for _, f := range fields {
+		r := newRecord(f)
+		t.records = append(t.records, r)
+		created = append(created, r)
+		result = append(result, r.toAPI())
+	}
+	s.recordChanges(t, created, nil, nil)
+	return result
+}
+
+// Records returns a snapshot of every record in a table.
+func (s *Server) Records(tableName string) []airtable.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(tableName)
+	result := make([]airtable.Record, 0, len(t.records))
+	for _, r := range t.records {
+		result = append(result, r.toAPI())
+	}
+	return result
+}
+
+// InjectFault registers a fault applied to matching requests.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fault := f
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset removes all tables, webhooks, faults and recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tables = make(map[string]*table)
+	s.webhooks = make(map[string]*webhook)
+	s.files = make(map[string]storedFile)
+	s.faults = nil
+	s.requests = nil
+}
+
+func (s *Server) table(name string) *table {
+	t, ok := s.tables[name]
+	if !ok {
+		t = &table{id: newID("tbl")}
+		s.tables[name] = t
+	}
+	return t
+}
+
+func (t *table) find(id string) (int, *record) {
+	for i, r := range t.records {
+		if r.ID == id {
+			return i, r
+		}
+	}
+	return -1, nil
+}
+
+func newRecord(fields map[string]interface{}) *record {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	r := &record{
+		ID:           newID("rec"),
+		Fields:       make(map[string]interface{}, len(fields)),
+		CreatedTime:  now,
+		ModifiedTime: now,
+	}
+	r.setFields(fields, false)
+	return r
+}
+
+// setFields merges (or, with replace, replaces) the record's fields. Nil and
+// empty values are removed, as Airtable does not store empty cells.
+func (r *record) setFields(fields map[string]interface{}, replace bool) {
+	previous := r.Fields
+	if replace {
+		r.Fields = make(map[string]interface{}, len(fields))
+	}
+	for k, v := range normalizeFields(fields) {
+		if v == nil || v == "" {
+			delete(r.Fields, k)
+			continue
+		}
+		r.Fields[k] = resolveAttachments(previous[k], v)
+	}
+	r.ModifiedTime = time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+func (r *record) toAPI() airtable.Record {
+	fields := make(map[string]interface{}, len(r.Fields))
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+	return airtable.Record{ID: r.ID, Fields: fields, CreatedTime: r.CreatedTime}
+}
+
+func (r *record) toJSON() map[string]interface{} {
+	fields := make(map[string]interface{}, len(r.Fields))
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+	return map[string]interface{}{"id": r.ID, "fields": fields, "createdTime": r.CreatedTime}
+}
+
+// render returns the record as the API does, with fields keyed by field ID
+// when the request asked for returnFieldsByFieldId.
+func (t *table) render(rec *record, byFieldID bool) map[string]interface{} {
+	if !byFieldID {
+		return rec.toJSON()
+	}
+	return map[string]interface{}{"id": rec.ID, "fields": t.cellValuesByFieldID(rec), "createdTime": rec.CreatedTime}
+}
+
+// fieldName returns the name of the field with the given ID, or key unchanged
+// if it is already a name. Airtable accepts both when writing.
+func (t *table) fieldName(key string) string {
+	for _, f := range t.schema {
+		if f.ID != "" && f.ID == key {
+			return f.Name
+		}
+	}
+	return key
+}
+
+// byName keys fields by field name.
+func (t *table) byName(fields map[string]interface{}) map[string]interface{} {
+	if fields == nil {
+		return nil
+	}
+	named := make(map[string]interface{}, len(fields))
+	for key, v := range fields {
+		named[t.fieldName(key)] = v
+	}
+	return named
+}
+
+// normalizeFields round-trips values through JSON so stored values have the
+// same types (float64, []interface{}, ...) whether seeded or sent over HTTP.
+func normalizeFields(fields map[string]interface{}) map[string]interface{} {
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return fields
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(raw, &normalized); err != nil {
+		return fields
+	}
+	return normalized
+}
+
+func newID(prefix string) string {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, 14)
+	_, _ = rand.Read(b)
+	for i := range b {
+		b[i] = alphabet[int(b[i])%len(alphabet)]
+	}
+	return prefix + string(b)
+}
+
+// HTTP handling
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	for i, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			segments[i] = unescaped
+		}
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query()})
+	s.mu.Unlock()
+
+	if len(segments) == 3 && segments[0] == "attachments" && r.Method == http.MethodGet {
+		s.handleAttachmentDownload(w, segments)
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "AUTHENTICATION_REQUIRED", "Authentication required")
+		return
+	}
+
+	if len(segments) < 2 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+
+	if segments[0] == "meta" {
+		if s.applyFault(w, r.Method, "") {
+			return
+		}
+		s.handleMeta(w, r, segments)
+		return
+	}
+
+	if segments[0] == "bases" {
+		if s.applyFault(w, r.Method, "") {
+			return
+		}
+		s.handleWebhooks(w, r, segments)
+		return
+	}
+
+	if len(segments) == 4 && segments[3] == "uploadAttachment" && r.Method == http.MethodPost {
+		if s.applyFault(w, r.Method, "") {
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.uploadAttachment(w, r, segments[1], segments[2])
+		return
+	}
+
+	tableName := segments[1]
+	if s.applyFault(w, r.Method, tableName) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(tableName)
+	switch {
+	case len(segments) == 2 && r.Method == http.MethodGet:
+		s.listRecords(w, r, t)
+	case len(segments) == 2 && r.Method == http.MethodPost:
+		s.createRecords(w, r, t)
+	case len(segments) == 2 && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		s.updateRecords(w, r, t, r.Method == http.MethodPut)
+	case len(segments) == 2 && r.Method == http.MethodDelete:
+		s.deleteRecords(w, r, t)
+	case len(segments) == 3 && r.Method == http.MethodGet:
+		s.getRecord(w, r, t, segments[2])
+	case len(segments) == 3 && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		s.updateRecord(w, r, t, segments[2], r.Method == http.MethodPut)
+	case len(segments) == 3 && r.Method == http.MethodDelete:
+		s.deleteRecord(w, t, segments[2])
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+	}
+}
+
+// applyFault sleeps and/or writes an error response for the first matching
+// fault. It reports whether a response was written.
+func (s *Server) applyFault(w http.ResponseWriter, method, tableName string) bool {
+	s.mu.Lock()
+	var fault *Fault
+	for i, f := range s.faults {
+		if (f.Method == "" || f.Method == method) && (f.Table == "" || f.Table == tableName) {
+			copied := *f
+			fault = &copied
+			if f.Times > 0 {
+				f.Times--
+				if f.Times == 0 {
+					s.faults = append(s.faults[:i], s.faults[i+1:]...)
+				}
+			}
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if fault == nil {
+		return false
+	}
+	if fault.Latency > 0 {
+		time.Sleep(fault.Latency)
+	}
+	if fault.StatusCode == 0 {
+		return false
+	}
+
+	if fault.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Round(time.Second)/time.Second)))
+	}
+	if fault.StatusCode == http.StatusTooManyRequests {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(fault.StatusCode)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"errors": []map[string]string{{
+				"error":   "RATE_LIMIT_REACHED",
+				"message": "Rate limit exceeded. Please try again later",
+			}},
+		})
+		return true
+	}
+	writeError(w, fault.StatusCode, "SERVER_ERROR", http.StatusText(fault.StatusCode))
+	return true
+}
+
+// handleMeta serves the Metadata API: GET and POST /meta/bases/{baseId}/tables
+// and POST /meta/bases/{baseId}/tables/{tableId}/fields. Only tables declared
+// with DefineTable or created through the API are reported.
+func (s *Server) handleMeta(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) < 4 || segments[1] != "bases" || segments[3] != "tables" {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(segments) == 4 && r.Method == http.MethodGet:
+		s.listTables(w)
+	case len(segments) == 4 && r.Method == http.MethodPost:
+		s.createTable(w, r)
+	case len(segments) == 6 && segments[5] == "fields" && r.Method == http.MethodPost:
+		s.createField(w, r, segments[4])
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+	}
+}
+
+func (s *Server) listTables(w http.ResponseWriter) {
+	names := make([]string, 0, len(s.tables))
+	for name, t := range s.tables {
+		if t.schema != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	tables := make([]airtable.TableSchema, 0, len(names))
+	for _, name := range names {
+		tables = append(tables, s.tables[name].describe(name))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"tables": tables})
+}
+
+func (t *table) describe(name string) airtable.TableSchema {
+	schema := airtable.TableSchema{ID: t.id, Name: name, Fields: t.schema}
+	if len(t.schema) > 0 {
+		schema.PrimaryFieldID = t.schema[0].ID
+	}
+	return schema
+}
+
+func (s *Server) createTable(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string                 `json:"name"`
+		Fields []airtable.FieldSchema `json:"fields"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" || len(req.Fields) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid request: a name and at least one field are required")
+		return
+	}
+	if t, ok := s.tables[req.Name]; ok && t.schema != nil {
+		writeError(w, http.StatusUnprocessableEntity, "DUPLICATE_TABLE_NAME", fmt.Sprintf("Table %q already exists", req.Name))
+		return
+	}
+
+	t := s.table(req.Name)
+	t.schema = []airtable.FieldSchema{}
+	for _, f := range req.Fields {
+		if !s.addField(w, t, f) {
+			t.schema = nil
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, t.describe(req.Name))
+}
+
+func (s *Server) createField(w http.ResponseWriter, r *http.Request, tableID string) {
+	var field airtable.FieldSchema
+	if err := json.NewDecoder(r.Body).Decode(&field); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid request: could not parse JSON body")
+		return
+	}
+
+	for _, t := range s.tables {
+		if t.id == tableID && t.schema != nil {
+			if s.addField(w, t, field) {
+				writeJSON(w, http.StatusOK, t.schema[len(t.schema)-1])
+			}
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+}
+
+// addField appends a field to the schema of t, writing an error response if
+// it is invalid. Link fields must name an existing table.
+func (s *Server) addField(w http.ResponseWriter, t *table, f airtable.FieldSchema) bool {
+	if f.Name == "" || f.Type == "" {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_FIELD_TYPE", "Fields need a name and a type")
+		return false
+	}
+	if _, exists := (airtable.TableSchema{Fields: t.schema}).Field(f.Name); exists {
+		writeError(w, http.StatusUnprocessableEntity, "DUPLICATE_OR_EMPTY_FIELD_NAME", fmt.Sprintf("Field %q already exists", f.Name))
+		return false
+	}
+	if f.Type == airtable.FieldTypeRecordLinks {
+		linked, _ := f.Options["linkedTableId"].(string)
+		found := false
+		for _, other := range s.tables {
+			found = found || other.id == linked && other.schema != nil
+		}
+		if !found {
+			writeError(w, http.StatusUnprocessableEntity, "INVALID_FIELD_TYPE_OPTIONS", "linkedTableId must name a table of the base")
+			return false
+		}
+	}
+
+	f.ID = newID("fld")
+	t.schema = append(t.schema, f)
+	return true
+}
+
+func (s *Server) listRecords(w http.ResponseWriter, r *http.Request, t *table) {
+	query := r.URL.Query()
+
+	matched := make([]*record, 0, len(t.records))
+	if expr := query.Get("filterByFormula"); expr != "" {
+		f, err := parseFormula(expr)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "INVALID_FILTER_BY_FORMULA", err.Error())
+			return
+		}
+		for _, rec := range t.records {
+			ok, err := f.match(rec)
+			if err != nil {
+				writeError(w, http.StatusUnprocessableEntity, "INVALID_FILTER_BY_FORMULA", err.Error())
+				return
+			}
+			if ok {
+				matched = append(matched, rec)
+			}
+		}
+	} else {
+		matched = append(matched, t.records...)
+	}
+
+	sortRecords(matched, parseSort(query))
+
+	if maxRecords, err := strconv.Atoi(query.Get("maxRecords")); err == nil && maxRecords > 0 && maxRecords < len(matched) {
+		matched = matched[:maxRecords]
+	}
+
+	pageSize := maxPageSize
+	if n, err := strconv.Atoi(query.Get("pageSize")); err == nil && n > 0 && n < maxPageSize {
+		pageSize = n
+	}
+
+	start := 0
+	if offset := query.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(offset, "itr"))
+		if err != nil || n < 0 || n > len(matched) {
+			writeError(w, http.StatusUnprocessableEntity, "LIST_RECORDS_ITERATOR_NOT_AVAILABLE", "Invalid offset")
+			return
+		}
+		start = n
+	}
+	end := min(start+pageSize, len(matched))
+
+	byFieldID := query.Get("returnFieldsByFieldId") == "true"
+	records := make([]map[string]interface{}, 0, end-start)
+	for _, rec := range matched[start:end] {
+		records = append(records, filterFields(t.render(rec, byFieldID), query["fields[]"]))
+	}
+
+	resp := map[string]interface{}{"records": records}
+	if end < len(matched) {
+		resp["offset"] = "itr" + strconv.Itoa(end)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type sortSpec struct {
+	field string
+	desc  bool
+}
+
+func parseSort(query url.Values) []sortSpec {
+	var specs []sortSpec
+	for i := 0; ; i++ {
+		field := query.Get(fmt.Sprintf("sort[%d][field]", i))
+		if field == "" {
+			return specs
+		}
+		specs = append(specs, sortSpec{
+			field: field,
+			desc:  query.Get(fmt.Sprintf("sort[%d][direction]", i)) == "desc",
+		})
+	}
+}
+
+func sortRecords(records []*record, specs []sortSpec) {
+	if len(specs) == 0 {
+		return
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, spec := range specs {
+			cmp := compareValues(records[i].Fields[spec.field], records[j].Fields[spec.field])
+			if cmp == 0 {
+				continue
+			}
+			if spec.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+}
+
+func filterFields(rec map[string]interface{}, names []string) map[string]interface{} {
+	if len(names) == 0 {
+		return rec
+	}
+	fields := rec["fields"].(map[string]interface{})
+	filtered := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		if v, ok := fields[name]; ok {
+			filtered[name] = v
+		}
+	}
+	rec["fields"] = filtered
+	return rec
+}
+
+func (s *Server) getRecord(w http.ResponseWriter, r *http.Request, t *table, id string) {
+	_, rec := t.find(id)
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+	writeJSON(w, http.StatusOK, t.render(rec, r.URL.Query().Get("returnFieldsByFieldId") == "true"))
+}
+
+type writeRequest struct {
+	Fields        map[string]interface{} `json:"fields"`
+	Records       []writeRecord          `json:"records"`
+	PerformUpsert *struct {
+		FieldsToMergeOn []string `json:"fieldsToMergeOn"`
+	} `json:"performUpsert"`
+	ReturnFieldsByFieldID bool `json:"returnFieldsByFieldId"`
+}
+
+type writeRecord struct {
+	ID     string                 `json:"id"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// decodeWrite parses a write request, translating field IDs to names.
+func decodeWrite(w http.ResponseWriter, r *http.Request, t *table) (writeRequest, bool) {
+	var req writeRequest
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid request: could not parse JSON body")
+		return req, false
+	}
+	if len(req.Records) > maxBatchSize {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_RECORDS", "You can only write up to 10 records at a time")
+		return req, false
+	}
+
+	req.Fields = t.byName(req.Fields)
+	for i := range req.Records {
+		req.Records[i].Fields = t.byName(req.Records[i].Fields)
+	}
+	if req.PerformUpsert != nil {
+		for i, field := range req.PerformUpsert.FieldsToMergeOn {
+			req.PerformUpsert.FieldsToMergeOn[i] = t.fieldName(field)
+		}
+	}
+	return req, true
+}
+
+func (s *Server) createRecords(w http.ResponseWriter, r *http.Request, t *table) {
+	req, ok := decodeWrite(w, r, t)
+	if !ok {
+		return
+	}
+
+	if req.Records == nil {
+		rec := newRecord(req.Fields)
+		t.records = append(t.records, rec)
+		s.recordChanges(t, []*record{rec}, nil, nil)
+		writeJSON(w, http.StatusOK, t.render(rec, req.ReturnFieldsByFieldID))
+		return
+	}
+
+	created := make([]map[string]interface{}, 0, len(req.Records))
+	createdRecords := make([]*record, 0, len(req.Records))
+	for _, wr := range req.Records {
+		rec := newRecord(wr.Fields)
+		t.records = append(t.records, rec)
+		createdRecords = append(createdRecords, rec)
+		created = append(created, t.render(rec, req.ReturnFieldsByFieldID))
+	}
+	s.recordChanges(t, createdRecords, nil, nil)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"records": created})
+}
+
+func (s *Server) updateRecords(w http.ResponseWriter, r *http.Request, t *table, replace bool) {
+	req, ok := decodeWrite(w, r, t)
+	if !ok {
+		return
+	}
+
+	if req.PerformUpsert != nil {
+		s.upsertRecords(w, t, req, replace)
+		return
+	}
+
+	// Validate every ID first so a failed batch leaves the table unchanged
+	for _, wr := range req.Records {
+		if _, rec := t.find(wr.ID); rec == nil {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "Record "+wr.ID+" not found")
+			return
+		}
+	}
+
+	updated := make([]map[string]interface{}, 0, len(req.Records))
+	changed := make([]*record, 0, len(req.Records))
+	for _, wr := range req.Records {
+		_, rec := t.find(wr.ID)
+		rec.setFields(wr.Fields, replace)
+		changed = append(changed, rec)
+		updated = append(updated, t.render(rec, req.ReturnFieldsByFieldID))
+	}
+	s.recordChanges(t, nil, changed, nil)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"records": updated})
+}
+
+func (s *Server) upsertRecords(w http.ResponseWriter, t *table, req writeRequest, replace bool) {
+	mergeOn := req.PerformUpsert.FieldsToMergeOn
+	if len(mergeOn) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "fieldsToMergeOn is required")
+		return
+	}
+
+	var (
+		records        = make([]map[string]interface{}, 0, len(req.Records))
+		createdRecords = []string{}
+		updatedRecords = []string{}
+		created        []*record
+		changed        []*record
+	)
+	for _, wr := range req.Records {
+		fields := normalizeFields(wr.Fields)
+
+		var match *record
+		for _, rec := range t.records {
+			matches := true
+			for _, name := range mergeOn {
+				if compareValues(rec.Fields[name], fields[name]) != 0 {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				match = rec
+				break
+			}
+		}
+
+		if match == nil {
+			match = newRecord(fields)
+			t.records = append(t.records, match)
+			createdRecords = append(createdRecords, match.ID)
+			created = append(created, match)
+		} else {
+			match.setFields(fields, replace)
+			updatedRecords = append(updatedRecords, match.ID)
+			changed = append(changed, match)
+		}
+		records = append(records, t.render(match, req.ReturnFieldsByFieldID))
+	}
+	s.recordChanges(t, created, changed, nil)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"records":        records,
+		"createdRecords": createdRecords,
+		"updatedRecords": updatedRecords,
+	})
+}
+
+func (s *Server) updateRecord(w http.ResponseWriter, r *http.Request, t *table, id string, replace bool) {
+	req, ok := decodeWrite(w, r, t)
+	if !ok {
+		return
+	}
+
+	_, rec := t.find(id)
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+	rec.setFields(req.Fields, replace)
+	s.recordChanges(t, nil, []*record{rec}, nil)
+	writeJSON(w, http.StatusOK, t.render(rec, req.ReturnFieldsByFieldID))
+}
+
+func (s *Server) deleteRecords(w http.ResponseWriter, r *http.Request, t *table) {
+	ids := r.URL.Query()["records[]"]
+	if len(ids) > maxBatchSize {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_RECORDS", "You can only delete up to 10 records at a time")
+		return
+	}
+
+	// Like Airtable, reject the whole batch if any record is missing
+	for _, id := range ids {
+		if _, rec := t.find(id); rec == nil {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "Record "+id+" not found")
+			return
+		}
+	}
+
+	deleted := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		i, _ := t.find(id)
+		t.records = append(t.records[:i], t.records[i+1:]...)
+		deleted = append(deleted, map[string]interface{}{"id": id, "deleted": true})
+	}
+	s.recordChanges(t, nil, nil, ids)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"records": deleted})
+}
+
+func (s *Server) deleteRecord(w http.ResponseWriter, t *table, id string) {
+	i, rec := t.find(id)
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+	t.records = append(t.records[:i], t.records[i+1:]...)
+	s.recordChanges(t, nil, nil, []string{id})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": id, "deleted": true})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{"type": errType, "message": message},
+	})
+}
@@ -0,0 +1,274 @@
+package airtabletest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+// maxPayloadsPerPage is the number of webhook payloads Airtable returns per request.
+const maxPayloadsPerPage = 50
+
+// webhook is a webhook registered with the fake. Payloads are kept in memory
+// and pinged to the notification URL as records change.
+type webhook struct {
+	airtable.Webhook
+	baseID   string
+	payloads []airtable.WebhookPayload
+}
+
+// UpdateRecord changes a record's fields as if edited in the Airtable UI,
+// notifying webhooks. It returns false if the record does not exist.
+func (s *Server) UpdateRecord(tableName, id string, fields map[string]interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(tableName)
+	_, rec := t.find(id)
+	if rec == nil {
+		return false
+	}
+	rec.setFields(fields, false)
+	s.recordChanges(t, nil, []*record{rec}, nil)
+	return true
+}
+
+// DeleteRecords removes records as if deleted in the Airtable UI, notifying
+// webhooks. Unknown IDs are ignored.
+func (s *Server) DeleteRecords(tableName string, ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.table(tableName)
+	var destroyed []string
+	for _, id := range ids {
+		if i, rec := t.find(id); rec != nil {
+			t.records = append(t.records[:i], t.records[i+1:]...)
+			destroyed = append(destroyed, id)
+		}
+	}
+	s.recordChanges(t, nil, nil, destroyed)
+}
+
+// Webhooks returns the registered webhooks.
+func (s *Server) Webhooks() []airtable.Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]airtable.Webhook, 0, len(s.webhooks))
+	for _, h := range s.webhooks {
+		result = append(result, h.Webhook)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// recordChanges appends a payload to every webhook scoped to t and pings
+// their notification URLs. The caller must hold s.mu.
+func (s *Server) recordChanges(t *table, created, changed []*record, destroyed []string) {
+	if len(created)+len(changed)+len(destroyed) == 0 {
+		return
+	}
+
+	changes := airtable.WebhookTableChanges{
+		CreatedRecordsByID: make(map[string]airtable.WebhookRecord),
+		ChangedRecordsByID: make(map[string]airtable.WebhookRecordChange),
+		DestroyedRecordIDs: destroyed,
+	}
+	for _, rec := range created {
+		changes.CreatedRecordsByID[rec.ID] = airtable.WebhookRecord{
+			CreatedTime:         rec.CreatedTime,
+			CellValuesByFieldID: t.cellValuesByFieldID(rec),
+		}
+	}
+	for _, rec := range changed {
+		changes.ChangedRecordsByID[rec.ID] = airtable.WebhookRecordChange{
+			Current: airtable.WebhookRecord{CellValuesByFieldID: t.cellValuesByFieldID(rec)},
+		}
+	}
+
+	for _, h := range s.webhooks {
+		scope := h.Specification.Options.Filters.RecordChangeScope
+		if scope != "" && scope != t.id {
+			continue
+		}
+		s.transactions++
+		h.payloads = append(h.payloads, airtable.WebhookPayload{
+			Timestamp:             time.Now().UTC(),
+			BaseTransactionNumber: s.transactions,
+			ChangedTablesByID:     map[string]airtable.WebhookTableChanges{t.id: changes},
+		})
+		h.CursorForNextPayload = len(h.payloads) + 1
+		go s.notify(h.NotificationURL, h.baseID, h.ID, h.MACSecretBase64)
+	}
+}
+
+// cellValuesByFieldID keys the record's fields by field ID where the table
+// schema defines one, and by name otherwise.
+func (t *table) cellValuesByFieldID(rec *record) map[string]interface{} {
+	ids := make(map[string]string, len(t.schema))
+	for _, f := range t.schema {
+		ids[f.Name] = f.ID
+	}
+
+	values := make(map[string]interface{}, len(rec.Fields))
+	for name, v := range rec.Fields {
+		if id, ok := ids[name]; ok {
+			name = id
+		}
+		values[name] = v
+	}
+	return values
+}
+
+// notify posts a signed notification like Airtable does. Delivery failures
+// are ignored; the receiver catches up from the payload cursor.
+func (s *Server) notify(notificationURL, baseID, webhookID, secret string) {
+	var notification airtable.WebhookNotification
+	notification.Base.ID = baseID
+	notification.Webhook.ID = webhookID
+	notification.Timestamp = time.Now().UTC()
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return
+	}
+	mac, err := airtable.SignWebhookBody(secret, body)
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, notificationURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(airtable.WebhookMACHeader, mac)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// handleWebhooks serves /bases/{baseId}/webhooks and its sub-resources.
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request, segments []string) {
+	if len(segments) < 3 || segments[2] != "webhooks" {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+	baseID := segments[1]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(segments) == 3 && r.Method == http.MethodGet:
+		s.listWebhooks(w, baseID)
+	case len(segments) == 3 && r.Method == http.MethodPost:
+		s.createWebhook(w, r, baseID)
+	case len(segments) == 4 && r.Method == http.MethodDelete:
+		if _, ok := s.webhooks[segments[3]]; !ok {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+			return
+		}
+		delete(s.webhooks, segments[3])
+		w.WriteHeader(http.StatusNoContent)
+	case len(segments) == 5 && segments[4] == "refresh" && r.Method == http.MethodPost:
+		h, ok := s.webhooks[segments[3]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+			return
+		}
+		h.ExpirationTime = time.Now().UTC().Add(7 * 24 * time.Hour)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"expirationTime": h.ExpirationTime})
+	case len(segments) == 5 && segments[4] == "payloads" && r.Method == http.MethodGet:
+		s.listPayloads(w, r, segments[3])
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+	}
+}
+
+func (s *Server) listWebhooks(w http.ResponseWriter, baseID string) {
+	webhooks := make([]airtable.Webhook, 0, len(s.webhooks))
+	for _, h := range s.webhooks {
+		if h.baseID == baseID {
+			listed := h.Webhook
+			listed.MACSecretBase64 = ""
+			webhooks = append(webhooks, listed)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	writeJSON(w, http.StatusOK, map[string]interface{}{"webhooks": webhooks})
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request, baseID string) {
+	var req struct {
+		NotificationURL string                        `json:"notificationUrl"`
+		Specification   airtable.WebhookSpecification `json:"specification"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Specification.Options.Filters.DataTypes) == 0 {
+		writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid webhook specification")
+		return
+	}
+
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+
+	h := &webhook{
+		Webhook: airtable.Webhook{
+			ID:                      newID("ach"),
+			NotificationURL:         req.NotificationURL,
+			MACSecretBase64:         base64.StdEncoding.EncodeToString(secret),
+			ExpirationTime:          time.Now().UTC().Add(7 * 24 * time.Hour),
+			CursorForNextPayload:    1,
+			IsHookEnabled:           true,
+			AreNotificationsEnabled: req.NotificationURL != "",
+			Specification:           req.Specification,
+		},
+		baseID: baseID,
+	}
+	s.webhooks[h.ID] = h
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":              h.ID,
+		"macSecretBase64": h.MACSecretBase64,
+		"expirationTime":  h.ExpirationTime,
+	})
+}
+
+func (s *Server) listPayloads(w http.ResponseWriter, r *http.Request, webhookID string) {
+	h, ok := s.webhooks[webhookID]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "Could not find what you are looking for")
+		return
+	}
+
+	cursor := 1
+	if c := r.URL.Query().Get("cursor"); c != "" {
+		n, err := strconv.Atoi(c)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusUnprocessableEntity, "INVALID_REQUEST_UNKNOWN", "Invalid cursor")
+			return
+		}
+		cursor = n
+	}
+
+	start := min(cursor-1, len(h.payloads))
+	end := min(start+maxPayloadsPerPage, len(h.payloads))
+	writeJSON(w, http.StatusOK, airtable.WebhookPayloads{
+		Cursor:        end + 1,
+		MightHaveMore: end < len(h.payloads),
+		Payloads:      append([]airtable.WebhookPayload{}, h.payloads[start:end]...),
+	})
+}
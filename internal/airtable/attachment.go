@@ -0,0 +1,213 @@
+package airtable
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// MaxUploadSize is the largest file the content upload endpoint accepts.
+// Larger files must be attached by URL.
+const MaxUploadSize = 5 << 20
+
+// Attachment is an item of an attachment field.
+// When writing, set URL (and optionally Filename) for a new file, or ID to
+// keep an existing one; Airtable fills in the remaining metadata.
+type Attachment struct {
+	ID         string               `json:"id,omitempty"`
+	URL        string               `json:"url,omitempty"`
+	Filename   string               `json:"filename,omitempty"`
+	Size       int64                `json:"size,omitempty"`
+	Type       string               `json:"type,omitempty"` // MIME type
+	Width      int                  `json:"width,omitempty"`
+	Height     int                  `json:"height,omitempty"`
+	Thumbnails map[string]Thumbnail `json:"thumbnails,omitempty"` // Keyed by "small", "large" and "full"
+}
+
+// Thumbnail is a preview image generated by Airtable for an attachment.
+type Thumbnail struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// AttachmentSource is a file to add to an attachment field: either a public
+// URL that Airtable downloads itself, or content of at most MaxUploadSize
+// bytes sent through the content upload endpoint.
+type AttachmentSource struct {
+	URL         string
+	Filename    string
+	ContentType string // Required with Content, e.g. "image/jpeg"
+	Content     []byte
+}
+
+// NewAttachmentSource reads a file to upload, such as a multipart form file.
+// It fails with ErrInvalidRequest if the file is larger than MaxUploadSize.
+func NewAttachmentSource(filename, contentType string, r io.Reader) (AttachmentSource, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxUploadSize+1))
+	if err != nil {
+		return AttachmentSource{}, fmt.Errorf("airtable: read attachment failed: %w", err)
+	}
+	if len(content) > MaxUploadSize {
+		return AttachmentSource{}, fmt.Errorf("%w: file is larger than %d bytes", ErrInvalidRequest, MaxUploadSize)
+	}
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(content)
+	}
+
+	return AttachmentSource{
+		Filename:    filename,
+		ContentType: contentType,
+		Content:     content,
+	}, nil
+}
+
+// writeValue returns the attachment as Airtable expects it in a write request.
+func (a Attachment) writeValue() map[string]interface{} {
+	if a.ID != "" {
+		return map[string]interface{}{"id": a.ID}
+	}
+	v := map[string]interface{}{"url": a.URL}
+	if a.Filename != "" {
+		v["filename"] = a.Filename
+	}
+	return v
+}
+
+// decodeAttachments converts an attachment field value as decoded from JSON.
+func decodeAttachments(raw interface{}) ([]Attachment, error) {
+	if _, ok := raw.([]interface{}); !ok {
+		return nil, errTypeMismatch
+	}
+
+	// Round-trip through JSON to decode the attachment objects
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var attachments []Attachment
+	if err := json.Unmarshal(b, &attachments); err != nil {
+		return nil, errTypeMismatch
+	}
+	return attachments, nil
+}
+
+// Attach adds a file to an attachment field, keeping the attachments already
+// there, and returns the new attachment. Files with a URL are attached by
+// URL; files with Content go through the content upload endpoint.
+func (c *Client) Attach(ctx context.Context, table, recordID, field string, src AttachmentSource) (Attachment, error) {
+	if src.Content != nil {
+		return c.UploadAttachment(ctx, table, recordID, field, src)
+	}
+	if src.URL == "" {
+		return Attachment{}, fmt.Errorf("airtable: attach failed: %w: a URL or content is required", ErrInvalidRequest)
+	}
+
+	record, err := c.GetRecord(ctx, table, recordID)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("airtable: attach failed: %w", err)
+	}
+	existing, err := record.Attachments(field)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("airtable: attach failed: %w", err)
+	}
+
+	attachments := append(existing, Attachment{URL: src.URL, Filename: src.Filename})
+	record, err = c.SetAttachments(ctx, table, recordID, field, attachments...)
+	if err != nil {
+		return Attachment{}, err
+	}
+
+	updated, err := record.Attachments(field)
+	if err != nil || len(updated) == 0 {
+		return Attachment{}, fmt.Errorf("airtable: attach failed: field %q missing from response", field)
+	}
+	return updated[len(updated)-1], nil
+}
+
+// SetAttachments replaces the attachments of a field. Existing attachments
+// are kept by passing them (or just their IDs); new ones need a URL.
+func (c *Client) SetAttachments(ctx context.Context, table, recordID, field string, attachments ...Attachment) (Record, error) {
+	values := make([]map[string]interface{}, 0, len(attachments))
+	for _, a := range attachments {
+		values = append(values, a.writeValue())
+	}
+
+	record, err := c.UpdateRecordPartial(ctx, table, recordID, map[string]interface{}{field: values})
+	if err != nil {
+		return Record{}, fmt.Errorf("airtable: set attachments failed: %w", err)
+	}
+	return record, nil
+}
+
+// UploadAttachment uploads src.Content (at most MaxUploadSize bytes) into an
+// attachment field and returns the new attachment.
+func (c *Client) UploadAttachment(ctx context.Context, table, recordID, field string, src AttachmentSource) (Attachment, error) {
+	if len(src.Content) > MaxUploadSize {
+		return Attachment{}, fmt.Errorf("airtable: upload attachment failed: %w: file is larger than %d bytes",
+			ErrInvalidRequest, MaxUploadSize)
+	}
+	if src.ContentType == "" {
+		src.ContentType = http.DetectContentType(src.Content)
+	}
+
+	body := map[string]string{
+		"contentType": src.ContentType,
+		"filename":    src.Filename,
+		"file":        base64.StdEncoding.EncodeToString(src.Content),
+	}
+	endpoint := c.contentURL + "/" + url.PathEscape(c.baseID) + "/" + url.PathEscape(recordID) +
+		"/" + url.PathEscape(c.column(table, field)) + "/uploadAttachment"
+
+	// The response holds only the uploaded field, keyed by field ID
+	var resp apiRecord
+	if err := c.doURL(ctx, OpUpload, table, http.MethodPost, endpoint, nil, body, &resp); err != nil {
+		return Attachment{}, fmt.Errorf("airtable: upload attachment failed: %w", err)
+	}
+	for _, raw := range resp.Fields {
+		attachments, err := decodeAttachments(raw)
+		if err == nil && len(attachments) > 0 {
+			return attachments[len(attachments)-1], nil
+		}
+	}
+	return Attachment{}, fmt.Errorf("airtable: upload attachment failed: no attachment in response")
+}
+
+// DownloadAttachment writes the content of an attachment to w and returns
+// the number of bytes written. Attachment URLs expire a few hours after the
+// record was read, so download soon after fetching the record.
+func (c *Client) DownloadAttachment(ctx context.Context, attachment Attachment, w io.Writer) (int64, error) {
+	if attachment.URL == "" {
+		return 0, fmt.Errorf("airtable: download attachment failed: %w: attachment has no URL", ErrInvalidRequest)
+	}
+
+	ctx, cancel := c.timeouts.withTimeout(ctx, http.MethodGet)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, attachment.URL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("airtable: download attachment failed: %w", err)
+	}
+
+	// Attachment URLs are pre-signed, so the API key is not sent
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("airtable: download attachment failed: %w", classifyError(ctx, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("airtable: download attachment failed: %w",
+			&Error{StatusCode: resp.StatusCode, Type: "DOWNLOAD_FAILED", Message: resp.Status})
+	}
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("airtable: download attachment failed: %w", err)
+	}
+	return n, nil
+}
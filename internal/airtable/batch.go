@@ -0,0 +1,278 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+const (
+	// maxBatchSize is the number of records Airtable accepts in a single write request.
+	maxBatchSize = 10
+
+	// maxConcurrentChunks bounds how many chunk requests run at once; the
+	// rate limiter still decides when each request is actually sent.
+	maxConcurrentChunks = 4
+)
+
+// RecordInput describes a record to write in a batch operation.
+// ID is required by UpdateRecords and ignored by CreateRecords and UpsertRecords.
+type RecordInput struct {
+	ID     string
+	Fields map[string]interface{}
+}
+
+// BatchResult reports the outcome of writing a single record in a batch.
+// Results are returned in the same order as the input records.
+type BatchResult struct {
+	Record  Record
+	Created bool  // Set by UpsertRecords when no existing record matched
+	Err     error // Non-nil if the chunk containing this record failed
+}
+
+// apiUpsertRequest is the body of a PATCH request that performs an upsert.
+type apiUpsertRequest struct {
+	PerformUpsert struct {
+		FieldsToMergeOn []string `json:"fieldsToMergeOn"`
+	} `json:"performUpsert"`
+	Records []apiRecord `json:"records"`
+
+	ReturnFieldsByFieldID bool `json:"returnFieldsByFieldId,omitempty"`
+}
+
+// apiUpsertResponse lists which records were created and which were updated.
+type apiUpsertResponse struct {
+	Records        []apiRecord `json:"records"`
+	CreatedRecords []string    `json:"createdRecords"`
+	UpdatedRecords []string    `json:"updatedRecords"`
+}
+
+// CreateRecords inserts any number of records, sending them to Airtable in
+// chunks of 10. A failed chunk does not stop the remaining chunks; the error
+// is reported on each affected result and summarized in the returned error.
+func (c *Client) CreateRecords(ctx context.Context, table string, records []RecordInput) ([]BatchResult, error) {
+	results := make([]BatchResult, len(records))
+
+	for start := 0; start < len(records); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(records))
+
+		body := apiRecordList{Records: make([]apiRecord, 0, end-start)}
+		for _, r := range records[start:end] {
+			body.Records = append(body.Records, apiRecord{Fields: r.Fields})
+		}
+
+		var created apiRecordList
+		err := c.do(ctx, OpCreate, table, http.MethodPost, c.tablePath(table), nil, body, &created)
+		fillBatchResults(results[start:end], created.Records, err)
+	}
+
+	return results, batchError("create records", results)
+}
+
+// UpdateRecords partially updates any number of records (only the given
+// fields change), sending them to Airtable in chunks of 10.
+func (c *Client) UpdateRecords(ctx context.Context, table string, records []RecordInput) ([]BatchResult, error) {
+	results := make([]BatchResult, len(records))
+
+	for start := 0; start < len(records); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(records))
+
+		body := apiRecordList{Records: make([]apiRecord, 0, end-start)}
+		for _, r := range records[start:end] {
+			body.Records = append(body.Records, apiRecord{ID: r.ID, Fields: r.Fields})
+		}
+
+		var updated apiRecordList
+		err := c.do(ctx, OpUpdate, table, http.MethodPatch, c.tablePath(table), nil, body, &updated)
+		fillBatchResults(results[start:end], updated.Records, err)
+	}
+
+	return results, batchError("update records", results)
+}
+
+// UpsertRecords creates or updates records matched on fieldsToMergeOn using
+// Airtable's performUpsert, so callers can sync by a natural key (e.g. Slug
+// or Email) without looking up record IDs first.
+func (c *Client) UpsertRecords(ctx context.Context, table string, fieldsToMergeOn []string, records []RecordInput) ([]BatchResult, error) {
+	if len(fieldsToMergeOn) == 0 {
+		return nil, fmt.Errorf("airtable: upsert records failed: at least one merge field is required")
+	}
+
+	results := make([]BatchResult, len(records))
+
+	for start := 0; start < len(records); start += maxBatchSize {
+		end := min(start+maxBatchSize, len(records))
+
+		var body apiUpsertRequest
+		body.PerformUpsert.FieldsToMergeOn = fieldsToMergeOn
+		body.Records = make([]apiRecord, 0, end-start)
+		for _, r := range records[start:end] {
+			body.Records = append(body.Records, apiRecord{Fields: r.Fields})
+		}
+
+		var upserted apiUpsertResponse
+		err := c.do(ctx, OpUpsert, table, http.MethodPatch, c.tablePath(table), nil, body, &upserted)
+		fillBatchResults(results[start:end], upserted.Records, err)
+
+		if err == nil {
+			created := make(map[string]struct{}, len(upserted.CreatedRecords))
+			for _, id := range upserted.CreatedRecords {
+				created[id] = struct{}{}
+			}
+			for i := range results[start:end] {
+				_, results[start+i].Created = created[results[start+i].Record.ID]
+			}
+		}
+	}
+
+	return results, batchError("upsert records", results)
+}
+
+// BulkDeleteResult reports which records a BulkDeleteRecords call removed.
+type BulkDeleteResult struct {
+	Deleted []string         // Records that were deleted
+	Missing []string         // Records that did not exist
+	Failed  map[string]error // Records that could not be deleted, keyed by ID
+}
+
+// apiDeleteResponse is the body returned by a batch delete request.
+type apiDeleteResponse struct {
+	Records []struct {
+		ID      string `json:"id"`
+		Deleted bool   `json:"deleted"`
+	} `json:"records"`
+}
+
+// BulkDeleteRecords deletes any number of records. IDs are split into chunks
+// of 10 (the Airtable API limit) which are deleted concurrently. The returned
+// error is non-nil only when some records failed to delete; records that did
+// not exist are reported in Missing instead.
+func (c *Client) BulkDeleteRecords(ctx context.Context, table string, ids []string) (BulkDeleteResult, error) {
+	result := BulkDeleteResult{Failed: make(map[string]error)}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, maxConcurrentChunks)
+	)
+
+	for start := 0; start < len(ids); start += maxBatchSize {
+		chunk := ids[start:min(start+maxBatchSize, len(ids))]
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			deleted, missing, failed := c.deleteChunk(ctx, table, chunk)
+
+			mu.Lock()
+			defer mu.Unlock()
+			result.Deleted = append(result.Deleted, deleted...)
+			result.Missing = append(result.Missing, missing...)
+			for id, err := range failed {
+				result.Failed[id] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(result.Failed) > 0 {
+		var first error
+		for _, id := range ids {
+			if err, ok := result.Failed[id]; ok {
+				first = err
+				break
+			}
+		}
+		return result, fmt.Errorf("airtable: bulk delete records failed for %d of %d records: %w", len(result.Failed), len(ids), first)
+	}
+
+	return result, nil
+}
+
+// deleteChunk deletes up to 10 records in one request. Airtable rejects the
+// whole request if any record is missing, so on a not-found response each
+// record is deleted individually to tell missing records from deleted ones.
+func (c *Client) deleteChunk(ctx context.Context, table string, ids []string) (deleted, missing []string, failed map[string]error) {
+	failed = make(map[string]error)
+
+	query := url.Values{}
+	for _, id := range ids {
+		query.Add("records[]", id)
+	}
+
+	var resp apiDeleteResponse
+	err := c.do(ctx, OpDelete, table, http.MethodDelete, c.tablePath(table), query, nil, &resp)
+	if err == nil {
+		for _, r := range resp.Records {
+			if r.Deleted {
+				deleted = append(deleted, r.ID)
+			}
+		}
+		return deleted, missing, failed
+	}
+
+	if !errors.Is(err, ErrNotFound) || len(ids) == 1 {
+		for _, id := range ids {
+			if errors.Is(err, ErrNotFound) {
+				missing = append(missing, id)
+			} else {
+				failed[id] = err
+			}
+		}
+		return deleted, missing, failed
+	}
+
+	for _, id := range ids {
+		err := c.do(ctx, OpDelete, table, http.MethodDelete, c.recordPath(table, id), nil, nil, nil)
+		switch {
+		case err == nil:
+			deleted = append(deleted, id)
+		case errors.Is(err, ErrNotFound):
+			missing = append(missing, id)
+		default:
+			failed[id] = err
+		}
+	}
+	return deleted, missing, failed
+}
+
+// fillBatchResults records the outcome of one chunk. Airtable returns records
+// in request order, so results are matched by position.
+func fillBatchResults(results []BatchResult, records []apiRecord, err error) {
+	if err == nil && len(records) != len(results) {
+		err = fmt.Errorf("expected %d records in response, got %d", len(results), len(records))
+	}
+
+	for i := range results {
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Record = records[i].toRecord()
+	}
+}
+
+// batchError summarizes failed results, wrapping the first failure.
+func batchError(op string, results []BatchResult) error {
+	var first error
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			if first == nil {
+				first = r.Err
+			}
+			failed++
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("airtable: %s failed for %d of %d records: %w", op, failed, len(results), first)
+}
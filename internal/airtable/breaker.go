@@ -0,0 +1,215 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	BreakerClosed   = "closed"    // Requests are sent to Airtable
+	BreakerOpen     = "open"      // Requests fail immediately with ErrCircuitOpen
+	BreakerHalfOpen = "half-open" // One probe request is let through to test recovery
+)
+
+// BreakerPolicy configures the circuit breaker that stops requests to
+// Airtable while it is unreachable. After FailureThreshold consecutive
+// requests fail with a network error, a 5xx response or a request timeout,
+// the circuit opens and requests fail immediately instead of waiting for
+// their timeouts. After OpenTimeout the next request is sent as a probe:
+// success closes the circuit, failure opens it again.
+type BreakerPolicy struct {
+	FailureThreshold int // Consecutive failures that open the circuit, 0 disables the breaker
+	OpenTimeout      time.Duration
+}
+
+// DefaultBreakerPolicy returns the breaker policy used when none is configured.
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// WithCircuitBreaker sets the circuit breaker policy.
+func WithCircuitBreaker(policy BreakerPolicy) Option {
+	return func(o *clientOptions) {
+		o.breaker = policy
+	}
+}
+
+// CircuitOpenError is returned without contacting Airtable while the circuit
+// is open. It matches both ErrCircuitOpen and ErrUnavailable.
+type CircuitOpenError struct {
+	RetryAfter time.Duration // Time until the next probe is allowed
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAfter <= 0 {
+		return "Airtable is unavailable, circuit breaker open (recovery probe in progress)"
+	}
+	return fmt.Sprintf("Airtable is unavailable, circuit breaker open (next attempt in %s)",
+		time.Duration(math.Ceil(e.RetryAfter.Seconds()))*time.Second)
+}
+
+// Is matches ErrCircuitOpen and ErrUnavailable.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen || target == ErrUnavailable
+}
+
+// RetryAfter returns how long to wait before retrying a request that failed
+// because the circuit is open.
+func RetryAfter(err error) (time.Duration, bool) {
+	var open *CircuitOpenError
+	if !errors.As(err, &open) || open.RetryAfter <= 0 {
+		return 0, false
+	}
+	return open.RetryAfter, true
+}
+
+// BreakerStatus describes the state of the circuit breaker.
+type BreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	Trips               int64      `json:"trips"` // Times the circuit has opened since startup
+}
+
+// breaker is a consecutive-failure circuit breaker. A nil breaker lets
+// every request through.
+type breaker struct {
+	policy BreakerPolicy
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool // A half-open probe is in flight
+	lastErr  string
+	trips    int64
+	onClose  []func()
+}
+
+func newBreaker(policy BreakerPolicy) *breaker {
+	if policy.FailureThreshold <= 0 {
+		return nil
+	}
+	return &breaker{policy: policy, state: BreakerClosed}
+}
+
+// allow reports whether a request may be sent, turning an expired open
+// circuit into a half-open one. probe is true for the request that decides
+// whether the circuit closes again.
+func (b *breaker) allow() (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		wait := b.policy.OpenTimeout - time.Since(b.openedAt)
+		if wait > 0 {
+			return false, &CircuitOpenError{RetryAfter: wait}
+		}
+		b.state = BreakerHalfOpen
+	case BreakerHalfOpen:
+		if b.probing {
+			return false, &CircuitOpenError{}
+		}
+	default:
+		return false, nil
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record updates the breaker with the outcome of a request sent after allow.
+// Only failures that point to Airtable being unreachable count; requests
+// abandoned by their caller count neither way. While half-open only the
+// probe's outcome changes the state.
+func (b *breaker) record(callerCtx context.Context, probe bool, err error) {
+	if b == nil {
+		return
+	}
+
+	failed := errors.Is(err, ErrUnavailable) ||
+		errors.Is(err, context.DeadlineExceeded) && callerCtx.Err() == nil
+	abandoned := !failed && err != nil && callerCtx.Err() != nil
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+
+	switch {
+	case abandoned:
+		return
+	case failed:
+		b.failures++
+		b.lastErr = err.Error()
+		if probe || b.state == BreakerClosed && b.failures >= b.policy.FailureThreshold {
+			b.state = BreakerOpen
+			b.openedAt = time.Now()
+			b.trips++
+		}
+	case probe:
+		b.failures = 0
+		b.state = BreakerClosed
+		b.lastErr = ""
+		for _, fn := range b.onClose {
+			go fn()
+		}
+	case b.state == BreakerClosed:
+		b.failures = 0
+	}
+}
+
+// status returns a snapshot of the breaker's state.
+func (b *breaker) status() BreakerStatus {
+	if b == nil {
+		return BreakerStatus{State: BreakerClosed}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := BreakerStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastErr,
+		Trips:               b.trips,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// BreakerStatus returns the state of the client's circuit breaker. A client
+// without a breaker always reports it closed.
+func (c *Client) BreakerStatus() BreakerStatus {
+	return c.breaker.status()
+}
+
+// onCircuitClose registers fn to run in its own goroutine whenever a probe
+// closes the circuit.
+func (c *Client) onCircuitClose(fn func()) {
+	if c.breaker == nil {
+		return
+	}
+
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	c.breaker.onClose = append(c.breaker.onClose, fn)
+}
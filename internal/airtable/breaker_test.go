@@ -0,0 +1,44 @@
+package airtable_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+func TestBreakerProbeFetchesSchema(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t,
+		airtable.WithReturnFieldsByFieldID(true),
+		airtable.WithCircuitBreaker(airtable.BreakerPolicy{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond}),
+	)
+	srv.DefineTable("Locations", airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeSingleLineText})
+	srv.AddRecords("Locations", map[string]interface{}{"Name": "Main"})
+
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodGet, StatusCode: http.StatusServiceUnavailable})
+	if _, err := client.ListRecords(ctx, "Locations", nil); err == nil {
+		t.Fatal("ListRecords succeeded during an outage")
+	}
+	if state := client.BreakerStatus().State; state != airtable.BreakerOpen {
+		t.Fatalf("breaker = %s, want %s", state, airtable.BreakerOpen)
+	}
+
+	// The probe needs the schema to translate field IDs, which it must fetch
+	// past the half-open breaker
+	srv.ClearFaults()
+	time.Sleep(30 * time.Millisecond)
+	records, err := client.ListRecords(ctx, "Locations", nil)
+	if err != nil {
+		t.Fatalf("ListRecords after the outage: %v", err)
+	}
+	if len(records) != 1 || records[0].Fields["Name"] != "Main" {
+		t.Errorf("records = %+v, want one named Main", records)
+	}
+	if state := client.BreakerStatus().State; state != airtable.BreakerClosed {
+		t.Errorf("breaker = %s, want %s", state, airtable.BreakerClosed)
+	}
+}
@@ -1,22 +1,120 @@
 package airtable
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the Airtable REST API endpoint.
+	DefaultBaseURL = "https://api.airtable.com/v0"
+
+	// DefaultContentURL is the Airtable endpoint for uploading attachment content.
+	DefaultContentURL = "https://content.airtable.com/v0"
 
-	"github.com/mehanizm/airtable"
+	// maxPageSize is the largest page Airtable returns for a single list request.
+	maxPageSize = 100
 )
 
-// Client wraps the mehanizm/airtable client with a simplified interface.
+// Client is a small wrapper around the Airtable REST API with a simplified interface.
+// It sends requests itself instead of going through mehanizm/airtable, which
+// read a single page per call and hid the offset cursor and the HTTP status
+// behind its own types; paging, retries and typed errors all need both.
 type Client struct {
-	client *airtable.Client
-	baseID string
+	httpClient *http.Client
+	apiKey     string
+	baseID     string
+	baseURL    string
+	contentURL string
+	limiter    *rateLimiter
+	retry      RetryPolicy
+	timeouts   Timeouts
+	links      map[linkKey]string // Linked table by table and field
+	hooks      []Hook
+	breaker    *breaker // Nil when disabled
+
+	// Field mapping, see WithTableMappings
+	mappings              map[string]*tableMapping // Keyed by table
+	returnFieldsByFieldID bool
+	names                 fieldNameCache
+}
+
+// Option customizes a Client created by NewClient.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	baseURL               string
+	contentURL            string
+	httpClient            *http.Client
+	requestsPerSecond     float64
+	retry                 RetryPolicy
+	timeouts              Timeouts
+	breaker               BreakerPolicy
+	links                 []Link
+	hooks                 []Hook
+	mappings              []TableMapping
+	returnFieldsByFieldID bool
+}
+
+// WithBaseURL points the client at a different API endpoint, such as a fake
+// server in tests. The URL replaces DefaultBaseURL, e.g. "http://127.0.0.1:1234".
+func WithBaseURL(baseURL string) Option {
+	return func(o *clientOptions) {
+		if baseURL != "" {
+			o.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
 }
 
-// NewClient creates a new Airtable client using the mehanizm/airtable library.
+// WithContentURL points attachment uploads at a different endpoint, replacing
+// DefaultContentURL.
+func WithContentURL(contentURL string) Option {
+	return func(o *clientOptions) {
+		if contentURL != "" {
+			o.contentURL = strings.TrimRight(contentURL, "/")
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) {
+		if httpClient != nil {
+			o.httpClient = httpClient
+		}
+	}
+}
+
+// WithRateLimit sets the number of requests per second allowed against the base.
+// The limit is shared by every Client using the same base ID.
+func WithRateLimit(requestsPerSecond float64) Option {
+	return func(o *clientOptions) {
+		if requestsPerSecond > 0 {
+			o.requestsPerSecond = requestsPerSecond
+		}
+	}
+}
+
+// WithRetryPolicy sets how rate-limited and failed requests are retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.retry = policy
+	}
+}
+
+// NewClient creates a new Airtable client.
 // apiKey: Your Airtable API token (get from https://airtable.com/account)
 // baseID: Your Airtable base ID (found in the API documentation for your base)
-func NewClient(apiKey, baseID string) (*Client, error) {
+func NewClient(apiKey, baseID string, opts ...Option) (*Client, error) {
 	if apiKey == "" {
 		return nil, fmt.Errorf("airtable: api key is required")
 	}
@@ -24,11 +122,44 @@ func NewClient(apiKey, baseID string) (*Client, error) {
 		return nil, fmt.Errorf("airtable: base ID is required")
 	}
 
-	client := airtable.NewClient(apiKey)
+	options := clientOptions{
+		baseURL:           DefaultBaseURL,
+		contentURL:        DefaultContentURL,
+		httpClient:        NewHTTPClient(),
+		requestsPerSecond: DefaultRequestsPerSecond,
+		retry:             DefaultRetryPolicy(),
+		timeouts:          DefaultTimeouts(),
+		breaker:           DefaultBreakerPolicy(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	mappings := make(map[string]*tableMapping, len(options.mappings))
+	for _, mapping := range options.mappings {
+		mappings[mapping.Table] = newTableMapping(mapping.Fields)
+	}
+
+	links := make(map[linkKey]string, len(options.links))
+	for _, link := range options.links {
+		links[linkKey{table: link.Table, field: link.Field}] = link.LinkedTable
+	}
 
 	return &Client{
-		client: client,
-		baseID: baseID,
+		httpClient: options.httpClient,
+		apiKey:     apiKey,
+		baseID:     baseID,
+		baseURL:    options.baseURL,
+		contentURL: options.contentURL,
+		limiter:    limiterForBase(baseID, options.requestsPerSecond),
+		retry:      options.retry,
+		timeouts:   options.timeouts,
+		links:      links,
+		hooks:      options.hooks,
+		breaker:    newBreaker(options.breaker),
+
+		mappings:              mappings,
+		returnFieldsByFieldID: options.returnFieldsByFieldID,
 	}, nil
 }
 
@@ -37,14 +168,33 @@ type Record struct {
 	ID          string
 	Fields      map[string]interface{}
 	CreatedTime string
+	Expanded    map[string][]Record // Linked records by link field, filled by Expand
+}
+
+// Attachments returns the attachments stored in an attachment field, or nil
+// if the field is empty. It fails if the field holds another type of value.
+func (r Record) Attachments(field string) ([]Attachment, error) {
+	raw, ok := r.Fields[field]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+
+	attachments, err := decodeAttachments(raw)
+	if err != nil {
+		return nil, &UnmarshalTypeError{Field: field, Value: raw, Type: attachmentsType}
+	}
+	return attachments, nil
 }
 
 // ListParams configures ListRecords queries.
 type ListParams struct {
 	View            string
-	PageSize        int
+	PageSize        int // Records per request, 1-100 (Airtable default is 100)
+	MaxRecords      int // Total records to return across all pages, 0 means no limit
 	FilterByFormula string
 	Sort            []SortParam
+	Fields          []string // Fields to return, nil returns every field
+	Expand          []string // Link fields whose records are fetched into Record.Expanded, see WithLinks
 }
 
 // SortParam configures sorting for list queries.
@@ -53,226 +203,449 @@ type SortParam struct {
 	Direction string // "asc" or "desc"
 }
 
-// ListRecords retrieves records from the specified table.
+// apiRecord is the wire representation of a record.
+type apiRecord struct {
+	ID          string                 `json:"id,omitempty"`
+	Fields      map[string]interface{} `json:"fields"`
+	CreatedTime string                 `json:"createdTime,omitempty"`
+
+	ReturnFieldsByFieldID bool `json:"returnFieldsByFieldId,omitempty"` // Request option of single-record writes
+}
+
+func (r apiRecord) toRecord() Record {
+	return Record{
+		ID:          r.ID,
+		Fields:      r.Fields,
+		CreatedTime: r.CreatedTime,
+	}
+}
+
+// apiRecordList is the wire representation of a page of records.
+type apiRecordList struct {
+	Records []apiRecord `json:"records"`
+	Offset  string      `json:"offset,omitempty"`
+
+	ReturnFieldsByFieldID bool `json:"returnFieldsByFieldId,omitempty"` // Request option of batch writes
+}
+
+// ListRecords retrieves all records from the specified table, following
+// Airtable's offset cursor until every page (or params.MaxRecords) has been read.
 func (c *Client) ListRecords(ctx context.Context, table string, params *ListParams) ([]Record, error) {
-	airtableTable := c.client.GetTable(c.baseID, table)
+	it := c.Records(ctx, table, params)
+
+	var result []Record
+	for {
+		page, ok := it.NextPage()
+		if !ok {
+			break
+		}
+		result = append(result, page...)
+	}
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("airtable: list records failed: %w", err)
+	}
+
+	if result == nil {
+		result = []Record{}
+	}
+	return result, nil
+}
 
-	query := airtableTable.GetRecords()
+// RecordIterator streams records from a table one page at a time, so large
+// tables can be processed without loading every record into memory.
+//
+//	it := client.Records(ctx, table, nil)
+//	for it.Next() {
+//		record := it.Record()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RecordIterator struct {
+	ctx    context.Context
+	client *Client
+	table  string
+	params ListParams
+
+	page    []Record
+	current Record
+	offset  string
+	fetched int
+	started bool
+	done    bool
+	err     error
+}
 
+// Records returns an iterator over the records of the specified table.
+// No request is made until Next or NextPage is called.
+func (c *Client) Records(ctx context.Context, table string, params *ListParams) *RecordIterator {
+	it := &RecordIterator{
+		ctx:    ctx,
+		client: c,
+		table:  table,
+	}
 	if params != nil {
-		if params.View != "" {
-			query = query.FromView(params.View)
-		}
-		if params.FilterByFormula != "" {
-			query = query.WithFilterFormula(params.FilterByFormula)
+		it.params = *params
+	}
+	return it
+}
+
+// Next advances the iterator to the next record, fetching a new page when
+// the current one is exhausted. It returns false when there are no more
+// records or an error occurred; check Err afterwards.
+func (it *RecordIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.fetch() {
+			return false
 		}
-		if len(params.Sort) > 0 {
-			sortQueries := make([]struct {
-				FieldName string
-				Direction string
-			}, 0, len(params.Sort))
-			for _, sort := range params.Sort {
-				direction := "asc"
-				if sort.Direction == "desc" {
-					direction = "desc"
-				}
-				sortQueries = append(sortQueries, struct {
-					FieldName string
-					Direction string
-				}{
-					FieldName: sort.Field,
-					Direction: direction,
-				})
-			}
-			query = query.WithSort(sortQueries...)
+	}
+	it.current = it.page[0]
+	it.page = it.page[1:]
+	return true
+}
+
+// Record returns the record at the current iterator position.
+func (it *RecordIterator) Record() Record {
+	return it.current
+}
+
+// NextPage returns the remaining records of the current page, or fetches the
+// next page if the current one has been consumed. It returns false when there
+// are no more pages or an error occurred; check Err afterwards.
+func (it *RecordIterator) NextPage() ([]Record, bool) {
+	for len(it.page) == 0 {
+		if !it.fetch() {
+			return nil, false
 		}
 	}
+	page := it.page
+	it.page = nil
+	return page, true
+}
+
+// Err returns the first error encountered while iterating, if any.
+func (it *RecordIterator) Err() error {
+	return it.err
+}
 
-	var records *airtable.Records
-	var err error
-	if ctx != nil && ctx != context.Background() {
-		records, err = query.DoContext(ctx)
-	} else {
-		records, err = query.Do()
+// fetch loads the next page into the iterator buffer.
+func (it *RecordIterator) fetch() bool {
+	if it.done || it.err != nil {
+		return false
 	}
-	if err != nil {
-		return nil, fmt.Errorf("airtable: list records failed: %w", err)
+	if it.started && it.offset == "" {
+		it.done = true
+		return false
+	}
+	if it.params.MaxRecords > 0 && it.fetched >= it.params.MaxRecords {
+		it.done = true
+		return false
 	}
+	it.started = true
 
-	result := make([]Record, 0, len(records.Records))
-	for _, r := range records.Records {
-		result = append(result, Record{
-			ID:          r.ID,
-			Fields:      r.Fields,
-			CreatedTime: r.CreatedTime,
-		})
+	var page apiRecordList
+	query := it.params.query(it.offset)
+	if err := it.client.do(it.ctx, OpList, it.table, http.MethodGet, it.client.tablePath(it.table), query, nil, &page); err != nil {
+		it.err = err
+		return false
 	}
 
-	return result, nil
+	records := make([]Record, 0, len(page.Records))
+	for _, r := range page.Records {
+		if it.params.MaxRecords > 0 && it.fetched >= it.params.MaxRecords {
+			break
+		}
+		records = append(records, r.toRecord())
+		it.fetched++
+	}
+
+	// Linked records are fetched once per page rather than per record
+	if len(it.params.Expand) > 0 {
+		if err := it.client.Expand(it.ctx, it.table, records, it.params.Expand...); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	it.page = records
+	it.offset = page.Offset
+	return true
+}
+
+// query encodes the list parameters for a single page request.
+func (p ListParams) query(offset string) url.Values {
+	query := url.Values{}
+	if p.View != "" {
+		query.Set("view", p.View)
+	}
+	if p.PageSize > 0 {
+		pageSize := p.PageSize
+		if pageSize > maxPageSize {
+			pageSize = maxPageSize
+		}
+		query.Set("pageSize", strconv.Itoa(pageSize))
+	}
+	if p.MaxRecords > 0 {
+		query.Set("maxRecords", strconv.Itoa(p.MaxRecords))
+	}
+	if p.FilterByFormula != "" {
+		query.Set("filterByFormula", p.FilterByFormula)
+	}
+	for i, sort := range p.Sort {
+		direction := "asc"
+		if sort.Direction == "desc" {
+			direction = "desc"
+		}
+		query.Set(fmt.Sprintf("sort[%d][field]", i), sort.Field)
+		query.Set(fmt.Sprintf("sort[%d][direction]", i), direction)
+	}
+	for _, field := range p.Fields {
+		query.Add("fields[]", field)
+	}
+	if offset != "" {
+		query.Set("offset", offset)
+	}
+	return query
 }
 
 // GetRecord fetches a single record by ID.
 func (c *Client) GetRecord(ctx context.Context, table, id string) (Record, error) {
-	airtableTable := c.client.GetTable(c.baseID, table)
-
-	var record *airtable.Record
-	var err error
-	if ctx != nil && ctx != context.Background() {
-		record, err = airtableTable.GetRecordContext(ctx, id)
-	} else {
-		record, err = airtableTable.GetRecord(id)
-	}
-	if err != nil {
+	var record apiRecord
+	if err := c.do(ctx, OpGet, table, http.MethodGet, c.recordPath(table, id), nil, nil, &record); err != nil {
 		return Record{}, fmt.Errorf("airtable: get record failed: %w", err)
 	}
 
-	return Record{
-		ID:          record.ID,
-		Fields:      record.Fields,
-		CreatedTime: record.CreatedTime,
-	}, nil
+	return record.toRecord(), nil
 }
 
 // CreateRecord inserts a new record into Airtable.
 func (c *Client) CreateRecord(ctx context.Context, table string, fields map[string]interface{}) (Record, error) {
-	airtableTable := c.client.GetTable(c.baseID, table)
-
-	recordsToSend := &airtable.Records{
-		Records: []*airtable.Record{
-			{
-				Fields: fields,
-			},
-		},
+	body := apiRecordList{
+		Records: []apiRecord{{Fields: fields}},
 	}
 
-	receivedRecords, err := airtableTable.AddRecords(recordsToSend)
-	if err != nil {
+	var created apiRecordList
+	if err := c.do(ctx, OpCreate, table, http.MethodPost, c.tablePath(table), nil, body, &created); err != nil {
 		return Record{}, fmt.Errorf("airtable: create record failed: %w", err)
 	}
 
-	if len(receivedRecords.Records) == 0 {
+	if len(created.Records) == 0 {
 		return Record{}, fmt.Errorf("airtable: no record returned")
 	}
 
-	r := receivedRecords.Records[0]
-	return Record{
-		ID:          r.ID,
-		Fields:      r.Fields,
-		CreatedTime: r.CreatedTime,
-	}, nil
+	return created.Records[0].toRecord(), nil
 }
 
 // UpdateRecord replaces a record in Airtable (full update).
+// Fields not present in fields are cleared.
 func (c *Client) UpdateRecord(ctx context.Context, table, id string, fields map[string]interface{}) (Record, error) {
-	airtableTable := c.client.GetTable(c.baseID, table)
+	body := apiRecord{Fields: fields}
 
-	// First get the record to update
-	record, err := airtableTable.GetRecord(id)
-	if err != nil {
-		return Record{}, fmt.Errorf("airtable: get record for update failed: %w", err)
+	var updated apiRecord
+	if err := c.do(ctx, OpUpdate, table, http.MethodPut, c.recordPath(table, id), nil, body, &updated); err != nil {
+		return Record{}, fmt.Errorf("airtable: update record failed: %w", err)
 	}
 
-	// Update all fields
-	record.Fields = fields
+	return updated.toRecord(), nil
+}
 
-	recordsToUpdate := &airtable.Records{
-		Records: []*airtable.Record{record},
-	}
+// UpdateRecordPartial performs a partial update on a record (only specified fields).
+func (c *Client) UpdateRecordPartial(ctx context.Context, table, id string, fields map[string]interface{}) (Record, error) {
+	body := apiRecord{Fields: fields}
 
-	updatedRecords, err := airtableTable.UpdateRecords(recordsToUpdate)
-	if err != nil {
-		return Record{}, fmt.Errorf("airtable: update record failed: %w", err)
+	var updated apiRecord
+	if err := c.do(ctx, OpUpdate, table, http.MethodPatch, c.recordPath(table, id), nil, body, &updated); err != nil {
+		return Record{}, fmt.Errorf("airtable: partial update record failed: %w", err)
 	}
 
-	if len(updatedRecords.Records) == 0 {
-		return Record{}, fmt.Errorf("airtable: no record returned")
+	return updated.toRecord(), nil
+}
+
+// DeleteRecord removes a record from Airtable.
+func (c *Client) DeleteRecord(ctx context.Context, table, id string) error {
+	if err := c.do(ctx, OpDelete, table, http.MethodDelete, c.recordPath(table, id), nil, nil, nil); err != nil {
+		return fmt.Errorf("airtable: delete record failed: %w", err)
 	}
 
-	r := updatedRecords.Records[0]
-	return Record{
-		ID:          r.ID,
-		Fields:      r.Fields,
-		CreatedTime: r.CreatedTime,
-	}, nil
+	return nil
 }
 
-// UpdateRecordPartial performs a partial update on a record (only specified fields).
-func (c *Client) UpdateRecordPartial(ctx context.Context, table, id string, fields map[string]interface{}) (Record, error) {
-	airtableTable := c.client.GetTable(c.baseID, table)
+func (c *Client) tablePath(table string) string {
+	return "/" + url.PathEscape(c.baseID) + "/" + url.PathEscape(table)
+}
+
+func (c *Client) recordPath(table, id string) string {
+	return c.tablePath(table) + "/" + url.PathEscape(id)
+}
+
+// nestedRequestKey marks the context of requests made on behalf of another
+// request, such as schema lookups while mapping its fields. They bypass the
+// circuit breaker, whose decision for the outer request covers them.
+type nestedRequestKey struct{}
+
+// do sends an authenticated request to the Airtable API and decodes the JSON
+// response into out (if non-nil). Every attempt waits on the base's rate
+// limiter, and failures are retried according to the client's RetryPolicy:
+// 429s always, 5xx and network failures only for idempotent methods or, for
+// POST, connection failures that happened before the request was sent. The
+// whole exchange is bounded by the client's Timeouts as well as ctx, and
+// reported to the client's hooks as operation op on table. While the circuit
+// breaker is open, requests fail immediately with a *CircuitOpenError.
+func (c *Client) do(ctx context.Context, op, table, method, path string, query url.Values, body, out interface{}) error {
+	return c.doURL(ctx, op, table, method, c.baseURL+path, query, body, out)
+}
 
-	// First get the record to update
-	var record *airtable.Record
-	var err error
-	if ctx != nil && ctx != context.Background() {
-		record, err = airtableTable.GetRecordContext(ctx, id)
-	} else {
-		record, err = airtableTable.GetRecord(id)
+// doURL is like do but takes an absolute URL, for endpoints served from
+// another host such as the content upload API.
+func (c *Client) doURL(ctx context.Context, op, table, method, endpoint string, query url.Values, body, out interface{}) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	if err != nil {
-		return Record{}, fmt.Errorf("airtable: get record for update failed: %w", err)
+
+	// Requests made while mapping another request's fields ride on its
+	// admission, so a half-open probe can fetch the schema it needs
+	if ctx.Value(nestedRequestKey{}) == nil {
+		probe, allowErr := c.breaker.allow()
+		if allowErr != nil {
+			return allowErr
+		}
+		callerCtx := ctx
+		defer func() {
+			c.breaker.record(callerCtx, probe, err)
+		}()
 	}
 
-	// Convert fields to map[string]any for the library
-	fieldsAny := make(map[string]any, len(fields))
-	for k, v := range fields {
-		fieldsAny[k] = v
+	info := RequestInfo{Operation: op, Table: table, Method: method, Records: countRecords(body) + len(query["records[]"])}
+	for _, hook := range c.hooks {
+		hook.OnRequest(ctx, info)
 	}
+	var (
+		started = time.Now()
+		status  int
+		retries int
+	)
+	defer func() {
+		if len(c.hooks) == 0 {
+			return
+		}
+		resp := ResponseInfo{Request: info, StatusCode: status, Latency: time.Since(started), Retries: retries, Err: err}
+		if err == nil {
+			resp.Records = countRecords(out)
+		}
+		for _, hook := range c.hooks {
+			hook.OnResponse(ctx, resp)
+		}
+	}()
 
-	// Use the library's UpdateRecordPartial method
-	var updatedRecord *airtable.Record
-	if ctx != nil && ctx != context.Background() {
-		updatedRecord, err = record.UpdateRecordPartialContext(ctx, fieldsAny)
-	} else {
-		updatedRecord, err = record.UpdateRecordPartial(fieldsAny)
+	ctx, cancel := c.timeouts.withTimeout(ctx, method)
+	defer cancel()
+
+	mapFields := c.mapsFields(table) && op != OpUpload
+	mapCtx := context.WithValue(ctx, nestedRequestKey{}, struct{}{})
+	if mapFields {
+		if query, body, err = c.mapRequest(mapCtx, op, table, query, body); err != nil {
+			return err
+		}
 	}
-	if err != nil {
-		return Record{}, fmt.Errorf("airtable: partial update record failed: %w", err)
+
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
 	}
 
-	return Record{
-		ID:          updatedRecord.ID,
-		Fields:      updatedRecord.Fields,
-		CreatedTime: updatedRecord.CreatedTime,
-	}, nil
-}
+	var payload []byte
+	if body != nil {
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
 
-// DeleteRecord removes a record from Airtable.
-func (c *Client) DeleteRecord(ctx context.Context, table, id string) error {
-	airtableTable := c.client.GetTable(c.baseID, table)
+	for attempt := 0; ; attempt++ {
+		retries = attempt
+		if err := c.limiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		var (
+			respBody   []byte
+			retryAfter time.Duration
+		)
+		status, respBody, retryAfter, err = c.send(ctx, method, endpoint, payload)
+		if err == nil {
+			if out == nil || len(respBody) == 0 {
+				return nil
+			}
+			if err := json.Unmarshal(respBody, out); err != nil {
+				return fmt.Errorf("decode response: %w", err)
+			}
+			if mapFields {
+				return c.mapResponse(mapCtx, table, out)
+			}
+			return nil
+		}
+
+		if attempt >= c.retry.MaxRetries || !c.shouldRetry(ctx, method, err) {
+			return classifyError(ctx, err)
+		}
 
-	// Get the record first
-	var record *airtable.Record
-	var err error
-	if ctx != nil && ctx != context.Background() {
-		record, err = airtableTable.GetRecordContext(ctx, id)
-	} else {
-		record, err = airtableTable.GetRecord(id)
+		delay := c.retry.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			// Give the caller the Airtable failure rather than our own deadline
+			return classifyError(ctx, err)
+		}
 	}
+}
+
+// send performs a single HTTP attempt. It returns the status code (0 if no
+// response arrived) and the response body on success, and the server's
+// Retry-After delay (if any) alongside errors.
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte) (int, []byte, time.Duration, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
 	if err != nil {
-		return fmt.Errorf("airtable: get record for delete failed: %w", err)
+		return 0, nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
 
-	// Delete using the record's method
-	if ctx != nil && ctx != context.Background() {
-		_, err = record.DeleteRecordContext(ctx)
-	} else {
-		_, err = record.DeleteRecord()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, 0, err
 	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("airtable: delete record failed: %w", err)
+		return resp.StatusCode, nil, 0, fmt.Errorf("read response: %w", err)
 	}
 
-	return nil
-}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"))
+		return resp.StatusCode, nil, retryAfter, parseAPIError(resp.StatusCode, respBody)
+	}
 
-// BulkDeleteRecords deletes multiple records (up to 10 at a time per Airtable API limits).
-func (c *Client) BulkDeleteRecords(ctx context.Context, table string, ids []string) error {
-	airtableTable := c.client.GetTable(c.baseID, table)
+	return resp.StatusCode, respBody, 0, nil
+}
 
-	_, err := airtableTable.DeleteRecords(ids)
-	if err != nil {
-		return fmt.Errorf("airtable: bulk delete records failed: %w", err)
+// shouldRetry reports whether a failed attempt with the given method may be
+// retried without risking the request being applied twice.
+func (c *Client) shouldRetry(ctx context.Context, method string, err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(method, apiErr.StatusCode)
 	}
-
-	return nil
+	if !isRetryableError(ctx, err) {
+		return false
+	}
+	return isIdempotent(method) || isNotSent(err)
 }
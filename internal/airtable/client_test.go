@@ -0,0 +1,229 @@
+package airtable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+// newTestClient starts a fake Airtable server and returns a client for it.
+func newTestClient(t *testing.T, opts ...airtable.Option) (*airtabletest.Server, *airtable.Client) {
+	t.Helper()
+	srv := airtabletest.NewServer()
+	t.Cleanup(srv.Close)
+	client, err := srv.NewClient("appTest", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, client
+}
+
+// addLocations seeds n records named "Location 0" to "Location n-1" and
+// returns their IDs.
+func addLocations(srv *airtabletest.Server, n int) []string {
+	fields := make([]map[string]interface{}, n)
+	for i := range fields {
+		fields[i] = map[string]interface{}{"Name": fmt.Sprintf("Location %d", i)}
+	}
+	var ids []string
+	for _, record := range srv.AddRecords("Locations", fields...) {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
+
+func TestListRecordsFollowsOffsets(t *testing.T) {
+	srv, client := newTestClient(t)
+	addLocations(srv, 250)
+
+	records, err := client.ListRecords(context.Background(), "Locations", nil)
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	if len(records) != 250 {
+		t.Fatalf("records = %d, want 250", len(records))
+	}
+	seen := make(map[string]bool)
+	for _, record := range records {
+		seen[record.ID] = true
+	}
+	if len(seen) != 250 {
+		t.Errorf("distinct records = %d, want 250", len(seen))
+	}
+
+	var offsets []string
+	for _, req := range srv.Requests() {
+		offsets = append(offsets, req.Query.Get("offset"))
+	}
+	if len(offsets) != 3 || offsets[0] != "" || offsets[1] == "" || offsets[2] == "" {
+		t.Errorf("offsets of requests = %q, want none on the first of 3 pages only", offsets)
+	}
+}
+
+func TestListRecordsStopsAtMaxRecords(t *testing.T) {
+	srv, client := newTestClient(t)
+	addLocations(srv, 50)
+
+	records, err := client.ListRecords(context.Background(), "Locations", &airtable.ListParams{PageSize: 20, MaxRecords: 30})
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	if len(records) != 30 {
+		t.Errorf("records = %d, want 30", len(records))
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestRecordIteratorFollowsOffsets(t *testing.T) {
+	srv, client := newTestClient(t)
+	addLocations(srv, 250)
+
+	it := client.Records(context.Background(), "Locations", nil)
+	var sizes []int
+	for {
+		page, ok := it.NextPage()
+		if !ok {
+			break
+		}
+		sizes = append(sizes, len(page))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if fmt.Sprint(sizes) != "[100 100 50]" {
+		t.Errorf("page sizes = %v, want [100 100 50]", sizes)
+	}
+}
+
+func TestRecordIteratorStopsAtMaxRecordsMidPage(t *testing.T) {
+	srv, client := newTestClient(t)
+	addLocations(srv, 50)
+
+	it := client.Records(context.Background(), "Locations", &airtable.ListParams{PageSize: 20, MaxRecords: 25})
+	var names []string
+	for it.Next() {
+		names = append(names, it.Record().Fields["Name"].(string))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if len(names) != 25 || names[24] != "Location 24" {
+		t.Errorf("records = %d ending with %q, want 25 ending with Location 24", len(names), names[len(names)-1])
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestRecordIteratorErrAfterFailedPage(t *testing.T) {
+	srv, client := newTestClient(t)
+	addLocations(srv, 150)
+
+	it := client.Records(context.Background(), "Locations", nil)
+	if page, ok := it.NextPage(); !ok || len(page) != 100 {
+		t.Fatalf("first page = %d records, %v; want 100", len(page), it.Err())
+	}
+
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodGet, StatusCode: http.StatusInternalServerError})
+	if it.Next() {
+		t.Fatal("Next succeeded after the second page failed")
+	}
+	if err := it.Err(); !errors.Is(err, airtable.ErrUnavailable) {
+		t.Errorf("Err = %v, want ErrUnavailable", err)
+	}
+
+	// The iterator stays failed rather than skipping the page
+	srv.ClearFaults()
+	if _, ok := it.NextPage(); ok {
+		t.Error("NextPage succeeded after an error")
+	}
+}
+
+func TestListRecordsRetriedAfterRateLimit(t *testing.T) {
+	srv, client := newTestClient(t)
+	addLocations(srv, 3)
+
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodGet, StatusCode: http.StatusTooManyRequests, Times: 1})
+	records, err := client.ListRecords(context.Background(), "Locations", nil)
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	if len(records) != 3 {
+		t.Errorf("records = %d, want 3", len(records))
+	}
+	if n := len(srv.Requests()); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestListRecordsGivesUpAfterMaxRetries(t *testing.T) {
+	srv, client := newTestClient(t)
+
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodGet, StatusCode: http.StatusTooManyRequests})
+	_, err := client.ListRecords(context.Background(), "Locations", nil)
+	if !errors.Is(err, airtable.ErrRateLimited) {
+		t.Fatalf("ListRecords error = %v, want ErrRateLimited", err)
+	}
+	if n := len(srv.Requests()); n != 4 {
+		t.Errorf("requests = %d, want 4 (first attempt and 3 retries)", n)
+	}
+}
+
+func TestBulkDeleteRecordsReportsMissing(t *testing.T) {
+	srv, client := newTestClient(t)
+	ids := addLocations(srv, 25)
+	missing := []string{"recMissing00001", "recMissing00002"}
+
+	result, err := client.BulkDeleteRecords(context.Background(), "Locations", append(ids, missing...))
+	if err != nil {
+		t.Fatalf("BulkDeleteRecords: %v", err)
+	}
+	if len(result.Deleted) != 25 {
+		t.Errorf("deleted = %d, want 25", len(result.Deleted))
+	}
+	sort.Strings(result.Missing)
+	if fmt.Sprint(result.Missing) != fmt.Sprint(missing) {
+		t.Errorf("missing = %v, want %v", result.Missing, missing)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("failed = %v, want none", result.Failed)
+	}
+	if n := len(srv.Records("Locations")); n != 0 {
+		t.Errorf("records left = %d, want 0", n)
+	}
+
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodDelete && len(req.Query["records[]"]) > 10 {
+			t.Errorf("delete request with %d records, want at most 10", len(req.Query["records[]"]))
+		}
+	}
+}
+
+func TestBulkDeleteRecordsReportsFailedChunk(t *testing.T) {
+	srv, client := newTestClient(t)
+	ids := addLocations(srv, 12)
+
+	// Chunks of 10 and 2 records; exactly one of them is rejected
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodDelete, StatusCode: http.StatusForbidden, Times: 1})
+	result, err := client.BulkDeleteRecords(context.Background(), "Locations", ids)
+	if !errors.Is(err, airtable.ErrUnauthorized) {
+		t.Fatalf("BulkDeleteRecords error = %v, want ErrUnauthorized", err)
+	}
+	if n := len(result.Failed); n != 10 && n != 2 {
+		t.Errorf("failed = %d, want one whole chunk", n)
+	}
+	if len(result.Deleted)+len(result.Failed) != len(ids) {
+		t.Errorf("deleted %d and failed %d of %d records", len(result.Deleted), len(result.Failed), len(ids))
+	}
+	if n := len(srv.Records("Locations")); n != len(result.Failed) {
+		t.Errorf("records left = %d, want the %d that failed", n, len(result.Failed))
+	}
+}
@@ -0,0 +1,306 @@
+package airtable
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The codec maps structs to Airtable fields using `airtable` struct tags:
+//
+//	type Location struct {
+//		ID        string    `airtable:",id"`                  // Record ID
+//		Name      string    `airtable:"Name"`
+//		Tags      []string  `airtable:"Tags,omitempty"`       // Multi-select or linked records
+//		CreatedAt time.Time `airtable:"Created At,readonly"`  // Read but never written
+//		Internal  string    `airtable:"-"`                    // Ignored
+//	}
+//
+// Supported field types are strings, numbers, booleans, time.Time (RFC3339 or
+// date-only), []string, []Attachment, Attachment (the first file of an
+// attachment field) and pointers to any of these. Untagged fields are ignored.
+
+// UnmarshalTypeError reports an Airtable value that cannot be stored in a struct field.
+type UnmarshalTypeError struct {
+	Field string       // Airtable field name
+	Value interface{}  // Value returned by Airtable
+	Type  reflect.Type // Type of the struct field
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf("airtable: cannot unmarshal %T value %v of field %q into Go type %s",
+		e.Value, e.Value, e.Field, e.Type)
+}
+
+// Marshal converts a tagged struct into a fields map for CreateRecord and
+// UpdateRecord. Readonly and ID fields are skipped, as are zero values of
+// fields tagged omitempty.
+func Marshal(v interface{}) (map[string]interface{}, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("airtable: marshal expects a struct, got %T", v)
+	}
+
+	fields := make(map[string]interface{})
+	for _, f := range structFields(rv.Type()) {
+		if f.id || f.readonly {
+			continue
+		}
+		fv := rv.Field(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		value, err := marshalValue(fv)
+		if err != nil {
+			return nil, fmt.Errorf("airtable: marshal field %q: %w", f.name, err)
+		}
+		fields[f.name] = value
+	}
+	return fields, nil
+}
+
+// Unmarshal stores the record's ID and fields in the tagged struct pointed to
+// by v. Fields missing from the record are reset to their zero value; values
+// of the wrong type are reported as *UnmarshalTypeError.
+func Unmarshal(record Record, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("airtable: unmarshal expects a non-nil struct pointer, got %T", v)
+	}
+	rv = rv.Elem()
+
+	for _, f := range structFields(rv.Type()) {
+		fv := rv.Field(f.index)
+		if f.id {
+			fv.SetString(record.ID)
+			continue
+		}
+
+		raw, ok := record.Fields[f.name]
+		if !ok || raw == nil {
+			fv.SetZero()
+			continue
+		}
+		if err := unmarshalValue(raw, fv); err != nil {
+			return &UnmarshalTypeError{Field: f.name, Value: raw, Type: fv.Type()}
+		}
+	}
+	return nil
+}
+
+// fieldInfo describes a tagged struct field.
+type fieldInfo struct {
+	index     int
+	name      string
+	id        bool
+	readonly  bool
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]fieldInfo
+
+func structFields(t reflect.Type) []fieldInfo {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+
+	var fields []fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("airtable")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		info := fieldInfo{index: i, name: name}
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "id":
+				info.id = true
+			case "readonly":
+				info.readonly = true
+			case "omitempty":
+				info.omitEmpty = true
+			}
+		}
+		if info.id && sf.Type.Kind() != reflect.String {
+			panic(fmt.Sprintf("airtable: id field %s.%s must be a string", t, sf.Name))
+		}
+		fields = append(fields, info)
+	}
+
+	cached, _ := fieldCache.LoadOrStore(t, fields)
+	return cached.([]fieldInfo)
+}
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	attachmentType  = reflect.TypeOf(Attachment{})
+	attachmentsType = reflect.TypeOf([]Attachment(nil))
+)
+
+func marshalValue(v reflect.Value) (interface{}, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return nil, nil
+		}
+		return t.Format(time.RFC3339), nil
+	case v.Type() == attachmentType:
+		return []map[string]interface{}{v.Interface().(Attachment).writeValue()}, nil
+	case v.Type() == attachmentsType:
+		attachments := v.Interface().([]Attachment)
+		out := make([]map[string]interface{}, 0, len(attachments))
+		for _, a := range attachments {
+			out = append(out, a.writeValue())
+		}
+		return out, nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			out := make([]string, v.Len())
+			for i := range out {
+				out[i] = v.Index(i).String()
+			}
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// errTypeMismatch is replaced by an UnmarshalTypeError carrying the field name.
+var errTypeMismatch = errors.New("type mismatch")
+
+func unmarshalValue(raw interface{}, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.Type().Elem() == attachmentType {
+			// An empty attachment field leaves *Attachment nil
+			if items, ok := raw.([]interface{}); ok && len(items) == 0 {
+				v.SetZero()
+				return nil
+			}
+		}
+		ptr := reflect.New(v.Type().Elem())
+		if err := unmarshalValue(raw, ptr.Elem()); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	switch {
+	case v.Type() == timeType:
+		s, ok := raw.(string)
+		if !ok {
+			return errTypeMismatch
+		}
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	case v.Type() == attachmentType:
+		attachments, err := decodeAttachments(raw)
+		if err != nil {
+			return err
+		}
+		if len(attachments) > 0 {
+			v.Set(reflect.ValueOf(attachments[0]))
+		} else {
+			v.SetZero()
+		}
+		return nil
+	case v.Type() == attachmentsType:
+		attachments, err := decodeAttachments(raw)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(attachments))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return errTypeMismatch
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return errTypeMismatch
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := raw.(float64)
+		if !ok || n != math.Trunc(n) || v.OverflowInt(int64(n)) {
+			return errTypeMismatch
+		}
+		v.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := raw.(float64)
+		if !ok || n < 0 || n != math.Trunc(n) || v.OverflowUint(uint64(n)) {
+			return errTypeMismatch
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		n, ok := raw.(float64)
+		if !ok {
+			return errTypeMismatch
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok || v.Type().Elem().Kind() != reflect.String {
+			return errTypeMismatch
+		}
+		out := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return errTypeMismatch
+			}
+			out.Index(i).SetString(s)
+		}
+		v.Set(out)
+	default:
+		return errTypeMismatch
+	}
+	return nil
+}
+
+// parseTime accepts the formats Airtable uses for dateTime and date fields.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
@@ -0,0 +1,189 @@
+package airtable_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+type codecRecord struct {
+	ID        string                `airtable:",id"`
+	Name      string                `airtable:"Name"`
+	Capacity  int                   `airtable:"Capacity"`
+	Rating    float64               `airtable:"Rating"`
+	Open      bool                  `airtable:"Open"`
+	OpenedAt  time.Time             `airtable:"Opened At"`
+	Tags      []string              `airtable:"Tags,omitempty"` // Multi-select
+	Managers  []string              `airtable:"Managers"`       // Linked records
+	Photos    []airtable.Attachment `airtable:"Photos"`         // Attachments
+	Cover     *airtable.Attachment  `airtable:"Cover"`          // First attachment only
+	Floor     *int                  `airtable:"Floor"`          // Nil when empty
+	CreatedAt time.Time             `airtable:"Created At,readonly"`
+	Internal  string                `airtable:"-"`
+	Untagged  string
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+	floor := 3
+	want := codecRecord{
+		Name:     "Lâm Phương",
+		Capacity: 120,
+		Rating:   4.5,
+		Open:     true,
+		OpenedAt: time.Date(2024, 1, 31, 7, 30, 0, 0, time.FixedZone("ICT", 7*3600)),
+		Tags:     []string{"Cafe", "Library"},
+		Managers: []string{"recManager1", "recManager2"},
+		Photos: []airtable.Attachment{
+			{URL: "https://example.com/front.jpg", Filename: "front.jpg"},
+			{URL: "https://example.com/back.jpg", Filename: "back.jpg"},
+		},
+		Cover: &airtable.Attachment{URL: "https://example.com/cover.jpg", Filename: "cover.jpg"},
+		Floor: &floor,
+	}
+
+	fields, err := airtable.Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	created, err := client.CreateRecord(ctx, "Locations", fields)
+	if err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	record, err := client.GetRecord(ctx, "Locations", created.ID)
+	if err != nil {
+		t.Fatalf("GetRecord: %v", err)
+	}
+
+	var got codecRecord
+	if err := airtable.Unmarshal(record, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if got.ID != created.ID {
+		t.Errorf("ID = %q, want %q", got.ID, created.ID)
+	}
+	if !got.OpenedAt.Equal(want.OpenedAt) {
+		t.Errorf("OpenedAt = %v, want %v", got.OpenedAt, want.OpenedAt)
+	}
+	// Compare the rest apart from the IDs given by Airtable
+	if got.Cover == nil {
+		t.Fatal("Cover is nil")
+	}
+	for _, a := range append(got.Photos, *got.Cover) {
+		if a.ID == "" {
+			t.Errorf("attachment %s has no ID", a.URL)
+		}
+	}
+	for i := range got.Photos {
+		got.Photos[i].ID = ""
+	}
+	got.Cover.ID = ""
+	got.ID, got.OpenedAt = "", want.OpenedAt
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+	if len(srv.Records("Locations")) != 1 {
+		t.Errorf("records = %d, want 1", len(srv.Records("Locations")))
+	}
+}
+
+func TestMarshalSkipsReadonlyAndEmptyFields(t *testing.T) {
+	fields, err := airtable.Marshal(&codecRecord{
+		ID:        "recLocation",
+		Name:      "Main",
+		CreatedAt: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Internal:  "secret",
+		Untagged:  "secret",
+	})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"Name":      "Main",
+		"Capacity":  int64(0),
+		"Rating":    0.0,
+		"Open":      false,
+		"Opened At": nil, // Zero times clear the field
+		"Managers":  []string{},
+		"Photos":    []map[string]interface{}{},
+		"Cover":     nil,
+		"Floor":     nil,
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Marshal = %#v, want %#v", fields, want)
+	}
+}
+
+func TestMarshalAttachmentByID(t *testing.T) {
+	fields, err := airtable.Marshal(codecRecord{Cover: &airtable.Attachment{ID: "attCover", URL: "https://example.com/cover.jpg"}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	// An attachment with an ID is kept by ID, without uploading its URL again
+	want := []map[string]interface{}{{"id": "attCover"}}
+	if !reflect.DeepEqual(fields["Cover"], want) {
+		t.Errorf("Cover = %#v, want %#v", fields["Cover"], want)
+	}
+}
+
+func TestUnmarshalDateOnlyAndMissingFields(t *testing.T) {
+	got := codecRecord{Name: "stale", Floor: new(int)}
+	err := airtable.Unmarshal(airtable.Record{ID: "recLocation", Fields: map[string]interface{}{
+		"Opened At": "2024-01-31",
+		"Cover":     []interface{}{},
+	}}, &got)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if want := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC); !got.OpenedAt.Equal(want) {
+		t.Errorf("OpenedAt = %v, want %v", got.OpenedAt, want)
+	}
+	if got.Name != "" || got.Floor != nil || got.Cover != nil {
+		t.Errorf("missing fields not reset: %+v", got)
+	}
+}
+
+func TestUnmarshalTypeErrors(t *testing.T) {
+	tests := []struct {
+		field string
+		value interface{}
+	}{
+		{"Name", 42.0}, // A number in a string field
+		{"Capacity", "120"},
+		{"Capacity", 1.5},
+		{"Open", "true"},
+		{"Opened At", "yesterday"},
+		{"Tags", "Cafe"},
+		{"Managers", []interface{}{"recManager1", 2.0}},
+		{"Photos", map[string]interface{}{"url": "https://example.com/front.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			var got codecRecord
+			err := airtable.Unmarshal(airtable.Record{Fields: map[string]interface{}{tt.field: tt.value}}, &got)
+			var typeErr *airtable.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				t.Fatalf("Unmarshal error = %v, want *UnmarshalTypeError", err)
+			}
+			if typeErr.Field != tt.field {
+				t.Errorf("error field = %q, want %q", typeErr.Field, tt.field)
+			}
+		})
+	}
+}
+
+func TestCodecRejectsNonStructs(t *testing.T) {
+	if _, err := airtable.Marshal("Main"); err == nil {
+		t.Error("Marshal of a string succeeded")
+	}
+	var r codecRecord
+	if err := airtable.Unmarshal(airtable.Record{}, r); err == nil {
+		t.Error("Unmarshal into a non-pointer succeeded")
+	}
+}
@@ -0,0 +1,132 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Sentinel errors describing why an Airtable call failed. Errors returned by
+// Client methods can be matched against them with errors.Is.
+var (
+	ErrNotFound       = errors.New("airtable: not found")
+	ErrRateLimited    = errors.New("airtable: rate limited")
+	ErrInvalidRequest = errors.New("airtable: invalid request")
+	ErrUnauthorized   = errors.New("airtable: unauthorized")
+	ErrUnavailable    = errors.New("airtable: unavailable")
+	ErrCircuitOpen    = errors.New("airtable: circuit breaker open") // Also matches ErrUnavailable
+	ErrQueueFull      = errors.New("airtable: write queue full")
+)
+
+// Error is a failed Airtable API call. StatusCode is zero when no response
+// was received (e.g. a network failure), in which case Err holds the cause.
+type Error struct {
+	StatusCode int
+	Type       string // Airtable error type, e.g. "INVALID_VALUE_FOR_COLUMN"
+	Message    string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.StatusCode == 0 && e.Err != nil:
+		return e.Err.Error()
+	case e.Message != "":
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Type, e.Message)
+	default:
+		return fmt.Sprintf("%d %s", e.StatusCode, e.Type)
+	}
+}
+
+// Unwrap returns the underlying transport error, if any.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the error against the package's sentinel errors.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound ||
+			e.Type == "NOT_FOUND" || e.Type == "ROW_DOES_NOT_EXIST" || e.Type == "MODEL_ID_NOT_FOUND"
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrInvalidRequest:
+		return (e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity ||
+			e.StatusCode == http.StatusRequestEntityTooLarge) && !e.Is(ErrNotFound)
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+			e.StatusCode == http.StatusPaymentRequired
+	case ErrUnavailable:
+		return e.StatusCode == 0 || e.StatusCode >= 500
+	}
+	return false
+}
+
+// HTTPStatus maps an error returned by the Client to the status code an API
+// handler should respond with. Failures on Airtable's side (or with our
+// credentials) are reported as 503, as they are not the API caller's fault.
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrUnavailable), errors.Is(err, ErrUnauthorized),
+		errors.Is(err, ErrQueueFull):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// classifyError wraps transport failures in an *Error so callers can match
+// them with ErrUnavailable. Errors caused by the caller's context are
+// returned unchanged.
+func classifyError(ctx context.Context, err error) error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) || !isRetryableError(ctx, err) {
+		return err
+	}
+	return &Error{Type: "NETWORK_ERROR", Err: err}
+}
+
+// parseAPIError decodes Airtable's error body, which is one of
+// {"error": {"type": "...", "message": "..."}}, {"error": "TYPE"} or
+// {"errors": [{"error": "TYPE", "message": "..."}]} (used for rate limiting).
+func parseAPIError(statusCode int, body []byte) *Error {
+	apiErr := &Error{StatusCode: statusCode}
+
+	var envelope struct {
+		Error  json.RawMessage `json:"error"`
+		Errors []struct {
+			Error   string `json:"error"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && len(envelope.Errors) > 0 {
+		apiErr.Type = envelope.Errors[0].Error
+		apiErr.Message = envelope.Errors[0].Message
+	} else if err == nil && len(envelope.Error) > 0 {
+		var detailed struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(envelope.Error, &detailed); err == nil {
+			apiErr.Type = detailed.Type
+			apiErr.Message = detailed.Message
+		} else {
+			var errType string
+			if err := json.Unmarshal(envelope.Error, &errType); err == nil {
+				apiErr.Type = errType
+			}
+		}
+	}
+
+	if apiErr.Type == "" {
+		apiErr.Type = http.StatusText(statusCode)
+	}
+	return apiErr
+}
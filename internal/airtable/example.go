@@ -1,9 +1,11 @@
 package airtable
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"log"
+	"time"
 )
 
 // ExampleUsage demonstrates how to use the Airtable client.
@@ -36,7 +38,7 @@ func ExampleUsage() {
 	// Example 2: List records with filters and sorting
 	params := &ListParams{
 		View:            "Grid view",
-		FilterByFormula: `{Slug}='main-library'`,
+		FilterByFormula: Field("Slug").Eq("main-library").String(),
 		Sort: []SortParam{
 			{Field: "Created", Direction: "desc"},
 		},
@@ -48,7 +50,17 @@ func ExampleUsage() {
 		fmt.Printf("Found %d filtered records\n", len(filteredRecords))
 	}
 
-	// Example 3: Get a single record by ID
+	// Example 3: Stream a large table page by page instead of loading it all at once
+	it := client.Records(ctx, tableName, &ListParams{PageSize: 50})
+	for it.Next() {
+		record := it.Record()
+		fmt.Printf("Streamed record ID: %s\n", record.ID)
+	}
+	if err := it.Err(); err != nil {
+		log.Printf("Failed to stream records: %v", err)
+	}
+
+	// Example 4: Get a single record by ID
 	recordID := "recXXXXXXXXXXXXXX" // Replace with actual record ID
 	record, err := client.GetRecord(ctx, tableName, recordID)
 	if err != nil {
@@ -57,7 +69,7 @@ func ExampleUsage() {
 		fmt.Printf("Record: %+v\n", record)
 	}
 
-	// Example 4: Create a new record
+	// Example 5: Create a new record
 	newFields := map[string]interface{}{
 		"Title":  "The Go Programming Language",
 		"Author": "Alan A. A. Donovan",
@@ -70,7 +82,7 @@ func ExampleUsage() {
 		fmt.Printf("Created record with ID: %s\n", createdRecord.ID)
 	}
 
-	// Example 5: Update a record (full update)
+	// Example 6: Update a record (full update)
 	updateFields := map[string]interface{}{
 		"Title":  "The Go Programming Language (Updated)",
 		"Author": "Alan A. A. Donovan & Brian W. Kernighan",
@@ -83,7 +95,7 @@ func ExampleUsage() {
 		fmt.Printf("Updated record: %+v\n", updatedRecord)
 	}
 
-	// Example 6: Partial update (only update specific fields)
+	// Example 7: Partial update (only update specific fields)
 	partialFields := map[string]interface{}{
 		"Price": 39.99,
 	}
@@ -94,7 +106,7 @@ func ExampleUsage() {
 		fmt.Printf("Partially updated record: %+v\n", partiallyUpdatedRecord)
 	}
 
-	// Example 7: Delete a record
+	// Example 8: Delete a record
 	err = client.DeleteRecord(ctx, tableName, recordID)
 	if err != nil {
 		log.Printf("Failed to delete record: %v", err)
@@ -102,13 +114,108 @@ func ExampleUsage() {
 		fmt.Println("Record deleted successfully")
 	}
 
-	// Example 8: Bulk delete records (up to 10 at a time)
+	// Example 9: Bulk delete records (sent to Airtable 10 at a time)
 	idsToDelete := []string{"rec1", "rec2", "rec3"}
-	err = client.BulkDeleteRecords(ctx, tableName, idsToDelete)
+	deleteResult, err := client.BulkDeleteRecords(ctx, tableName, idsToDelete)
 	if err != nil {
 		log.Printf("Failed to bulk delete records: %v", err)
+	}
+	fmt.Printf("Deleted %d records, %d missing, %d failed\n",
+		len(deleteResult.Deleted), len(deleteResult.Missing), len(deleteResult.Failed))
+
+	// Example 10: Create or update many records in chunks of 10, matched on a natural key
+	upserts := []RecordInput{
+		{Fields: map[string]interface{}{"Slug": "main-library", "Name": "Main Library"}},
+		{Fields: map[string]interface{}{"Slug": "west-branch", "Name": "West Branch"}},
+	}
+	results, err := client.UpsertRecords(ctx, tableName, []string{"Slug"}, upserts)
+	if err != nil {
+		log.Printf("Failed to upsert some records: %v", err)
+	}
+	for _, result := range results {
+		if result.Err == nil {
+			fmt.Printf("Upserted record %s (created: %t)\n", result.Record.ID, result.Created)
+		}
+	}
+
+	// Example 11: Map records to and from tagged structs
+	type place struct {
+		ID        string    `airtable:",id"`
+		Name      string    `airtable:"Name"`
+		Tags      []string  `airtable:"Tags,omitempty"`
+		CreatedAt time.Time `airtable:"Created At,readonly"`
+	}
+	placeFields, err := Marshal(place{Name: "Main Library", Tags: []string{"library"}})
+	if err != nil {
+		log.Printf("Failed to marshal place: %v", err)
+	} else if placeRecord, err := client.CreateRecord(ctx, tableName, placeFields); err == nil {
+		var p place
+		if err := Unmarshal(placeRecord, &p); err != nil {
+			log.Printf("Failed to unmarshal place: %v", err)
+		}
+		fmt.Printf("Created place %s at %s\n", p.ID, p.CreatedAt)
+	}
+
+	// Example 12: Attach files by URL or by uploading content, then download one
+	photo, err := client.Attach(ctx, tableName, "recXXXXXXXXXXXXXX", "Photos", AttachmentSource{
+		URL:      "https://example.com/library.jpg",
+		Filename: "library.jpg",
+	})
+	if err != nil {
+		log.Printf("Failed to attach photo: %v", err)
 	} else {
-		fmt.Println("Records deleted successfully")
+		var buf bytes.Buffer
+		if _, err := client.DownloadAttachment(ctx, photo, &buf); err == nil {
+			fmt.Printf("Downloaded %s (%d bytes)\n", photo.Filename, buf.Len())
+		}
+	}
+	_, err = client.Attach(ctx, tableName, "recXXXXXXXXXXXXXX", "Photos", AttachmentSource{
+		Filename:    "notes.txt",
+		ContentType: "text/plain",
+		Content:     []byte("Opening hours: 8am-5pm"),
+	})
+	if err != nil {
+		log.Printf("Failed to upload attachment: %v", err)
 	}
-}
 
+	// Example 13: Fetch linked records together with the records linking to them.
+	// The link must be declared when creating the client:
+	//   NewClient(apiKey, baseID, WithLinks(Link{Table: "Users", Field: "Locations", LinkedTable: "Locations"}))
+	users, err := client.ListRecords(ctx, "Users", &ListParams{Expand: []string{"Locations"}})
+	if err != nil {
+		log.Printf("Failed to list users with locations: %v", err)
+	} else {
+		for _, u := range users {
+			fmt.Printf("User %s manages %d locations\n", u.ID, len(u.Expanded["Locations"]))
+		}
+	}
+
+	// Example 14: Address renamed or translated columns by their logical names.
+	// The mapping is set when creating the client:
+	//   NewClient(apiKey, baseID, WithTableMappings(TableMapping{
+	//       Table:  "Locations",
+	//       Fields: map[string]string{"Name": "Tên", "Slug": "fldAbC123dEf456GhI"},
+	//   }))
+	// Records, formulas and sorts then use "Name" and "Slug" as usual.
+	mapped, err := client.ListRecords(ctx, "Locations", &ListParams{
+		FilterByFormula: Field("Slug").Eq("main-library").String(),
+	})
+	if err != nil {
+		log.Printf("Failed to list mapped locations: %v", err)
+	} else if len(mapped) > 0 {
+		fmt.Printf("Found %v\n", mapped[0].Fields["Name"])
+	}
+
+	// Example 15: Poll for records changed in Airtable instead of listing the
+	// whole table. The first poll delivers every record; later polls only the
+	// records modified since the previous one, plus deletions found by
+	// periodic ID diffs.
+	syncer := NewSyncer(client, DefaultSyncPolicy())
+	syncer.Handle("Locations", "Slug", func(ctx context.Context, changes RecordChanges) error {
+		fmt.Printf("%d changed, %d deleted\n", len(changes.Upserted), len(changes.Deleted))
+		return nil
+	})
+	if err := syncer.SyncAll(ctx); err != nil {
+		log.Printf("Failed to sync locations: %v", err)
+	}
+}
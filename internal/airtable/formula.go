@@ -0,0 +1,233 @@
+package airtable
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formula is an Airtable formula expression, such as a filterByFormula value.
+// Build formulas with Field, Value and the combinators below instead of
+// formatting strings by hand, so values are always escaped correctly:
+//
+//	Field("Slug").Eq("main-library").String()
+//	// {Slug} = 'main-library'
+//	And(Lower(Field("Email")).Eq("a@b.com"), Field("Role").NotBlank()).String()
+//	// AND(LOWER({Email}) = 'a@b.com', {Role} != BLANK())
+type Formula struct {
+	expr string
+}
+
+// String renders the formula for use in ListParams.FilterByFormula.
+func (f Formula) String() string {
+	return f.expr
+}
+
+// IsZero reports whether the formula is empty.
+func (f Formula) IsZero() bool {
+	return f.expr == ""
+}
+
+// Raw wraps a hand-written formula. The caller is responsible for escaping.
+func Raw(expr string) Formula {
+	return Formula{expr: expr}
+}
+
+// Field references a field by name, e.g. {Created At}. Closing braces and
+// backslashes in the name are escaped with a backslash.
+func Field(name string) Formula {
+	return Formula{expr: fieldRef(name)}
+}
+
+// Value renders a Go value as a formula literal. Strings are quoted and
+// escaped, times become DATETIME_PARSE calls, and booleans become TRUE()/FALSE().
+func Value(v interface{}) Formula {
+	switch val := v.(type) {
+	case Formula:
+		return val
+	case nil:
+		return Formula{expr: "BLANK()"}
+	case string:
+		return Formula{expr: quote(val)}
+	case bool:
+		if val {
+			return Formula{expr: "TRUE()"}
+		}
+		return Formula{expr: "FALSE()"}
+	case int:
+		return Formula{expr: strconv.Itoa(val)}
+	case int64:
+		return Formula{expr: strconv.FormatInt(val, 10)}
+	case float64:
+		return Formula{expr: strconv.FormatFloat(val, 'f', -1, 64)}
+	case time.Time:
+		return Formula{expr: "DATETIME_PARSE(" + quote(val.UTC().Format(time.RFC3339)) + ")"}
+	default:
+		return Formula{expr: quote(toFormulaString(val))}
+	}
+}
+
+// Eq renders f = v.
+func (f Formula) Eq(v interface{}) Formula { return f.compare("=", v) }
+
+// NotEq renders f != v.
+func (f Formula) NotEq(v interface{}) Formula { return f.compare("!=", v) }
+
+// Lt renders f < v.
+func (f Formula) Lt(v interface{}) Formula { return f.compare("<", v) }
+
+// Lte renders f <= v.
+func (f Formula) Lte(v interface{}) Formula { return f.compare("<=", v) }
+
+// Gt renders f > v.
+func (f Formula) Gt(v interface{}) Formula { return f.compare(">", v) }
+
+// Gte renders f >= v.
+func (f Formula) Gte(v interface{}) Formula { return f.compare(">=", v) }
+
+// Blank matches records where f is empty.
+func (f Formula) Blank() Formula { return f.compare("=", nil) }
+
+// NotBlank matches records where f is not empty.
+func (f Formula) NotBlank() Formula { return f.compare("!=", nil) }
+
+func (f Formula) compare(op string, v interface{}) Formula {
+	return Formula{expr: f.expr + " " + op + " " + Value(v).expr}
+}
+
+// And matches when every condition matches. Empty conditions are skipped.
+func And(conditions ...Formula) Formula {
+	return call("AND", conditions...)
+}
+
+// Or matches when any condition matches. Empty conditions are skipped.
+func Or(conditions ...Formula) Formula {
+	return call("OR", conditions...)
+}
+
+// Not negates a condition.
+func Not(condition Formula) Formula {
+	return Formula{expr: "NOT(" + condition.expr + ")"}
+}
+
+// Lower renders LOWER(f), for case-insensitive comparisons.
+func Lower(f Formula) Formula {
+	return Formula{expr: "LOWER(" + f.expr + ")"}
+}
+
+// Search matches when needle occurs in haystack. Combine with Lower for a
+// case-insensitive search.
+func Search(needle interface{}, haystack Formula) Formula {
+	return Formula{expr: "SEARCH(" + Value(needle).expr + ", " + haystack.expr + ")"}
+}
+
+// IsAfter matches when the date in f is after t.
+func IsAfter(f Formula, t time.Time) Formula {
+	return Formula{expr: "IS_AFTER(" + f.expr + ", " + Value(t).expr + ")"}
+}
+
+// IsBefore matches when the date in f is before t.
+func IsBefore(f Formula, t time.Time) Formula {
+	return Formula{expr: "IS_BEFORE(" + f.expr + ", " + Value(t).expr + ")"}
+}
+
+// RecordID renders RECORD_ID(), the ID of the record being evaluated.
+func RecordID() Formula {
+	return Formula{expr: "RECORD_ID()"}
+}
+
+// LastModifiedTime renders LAST_MODIFIED_TIME(), when any field of the record last changed.
+func LastModifiedTime() Formula {
+	return Formula{expr: "LAST_MODIFIED_TIME()"}
+}
+
+// call renders name(arg1, arg2, ...), collapsing to the argument itself when
+// only one non-empty argument remains.
+func call(name string, args ...Formula) Formula {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		if !a.IsZero() {
+			parts = append(parts, a.expr)
+		}
+	}
+
+	switch len(parts) {
+	case 0:
+		return Formula{}
+	case 1:
+		return Formula{expr: parts[0]}
+	default:
+		return Formula{expr: name + "(" + strings.Join(parts, ", ") + ")"}
+	}
+}
+
+// quote renders s as a single-quoted formula string literal. Backslashes and
+// quotes are backslash-escaped and control characters use escape sequences.
+func quote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// fieldRef renders a {name} field reference, escaping the characters that
+// would end it early.
+func fieldRef(name string) string {
+	var b strings.Builder
+	b.Grow(len(name) + 2)
+	b.WriteByte('{')
+	for _, r := range name {
+		if r == '}' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// scanFieldRef reads the field reference that starts with the brace at
+// formula[start]. It returns the unescaped field name and the index of the
+// closing brace, or -1 if the reference is not terminated.
+func scanFieldRef(formula string, start int) (name string, end int) {
+	var b strings.Builder
+	for i := start + 1; i < len(formula); i++ {
+		switch ch := formula[i]; {
+		case ch == '}':
+			return b.String(), i
+		case ch == '\\' && i+1 < len(formula):
+			i++
+			b.WriteByte(formula[i])
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return "", -1
+}
+
+func toFormulaString(v interface{}) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprint(v)
+}
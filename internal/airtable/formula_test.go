@@ -0,0 +1,130 @@
+package airtable_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+func TestValueQuotesStrings(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"main-library", `'main-library'`},
+		{"", `''`},
+		{`C:\path`, `'C:\\path'`},
+		{"Lâm's", `'Lâm\'s'`},
+		{`say "hi"`, `'say \"hi\"'`},
+		{"line\nbreak", `'line\nbreak'`},
+		{"tab\there", `'tab\there'`},
+		{"cr\r", `'cr\r'`},
+		{`'), TRUE(), ('`, `'\'), TRUE(), (\''`},
+	}
+	for _, tt := range tests {
+		if got := airtable.Value(tt.value).String(); got != tt.want {
+			t.Errorf("Value(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValueLiterals(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, "BLANK()"},
+		{true, "TRUE()"},
+		{false, "FALSE()"},
+		{42, "42"},
+		{int64(-7), "-7"},
+		{2.5, "2.5"},
+		{time.Date(2024, 1, 31, 7, 0, 0, 0, time.FixedZone("ICT", 7*3600)), "DATETIME_PARSE('2024-01-31T00:00:00Z')"},
+		{airtable.RecordID(), "RECORD_ID()"},
+	}
+	for _, tt := range tests {
+		if got := airtable.Value(tt.value).String(); got != tt.want {
+			t.Errorf("Value(%v) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFieldEscapesName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Created At", "{Created At}"},
+		{"Địa điểm", "{Địa điểm}"},
+		{"Notes {internal}", `{Notes {internal\}}`},
+		{`Path\`, `{Path\\}`},
+	}
+	for _, tt := range tests {
+		if got := airtable.Field(tt.name).String(); got != tt.want {
+			t.Errorf("Field(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormulaCombinators(t *testing.T) {
+	created := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		formula airtable.Formula
+		want    string
+	}{
+		{"empty And", airtable.And(), ""},
+		{"And of one", airtable.And(airtable.Formula{}, airtable.Field("Slug").Eq("main")), "{Slug} = 'main'"},
+		{
+			"nested",
+			airtable.And(
+				airtable.Or(airtable.Field("Role").Eq("Admin"), airtable.Field("Role").Eq("Super Admin")),
+				airtable.Not(airtable.Field("Email").Blank()),
+			),
+			"AND(OR({Role} = 'Admin', {Role} = 'Super Admin'), NOT({Email} = BLANK()))",
+		},
+		{"Blank", airtable.Field("Public ID").Blank(), "{Public ID} = BLANK()"},
+		{"NotBlank", airtable.Field("Public ID").NotBlank(), "{Public ID} != BLANK()"},
+		{"IsAfter", airtable.IsAfter(airtable.Field("Created At"), created), "IS_AFTER({Created At}, DATETIME_PARSE('2024-01-31T00:00:00Z'))"},
+		{"IsBefore", airtable.IsBefore(airtable.LastModifiedTime(), created), "IS_BEFORE(LAST_MODIFIED_TIME(), DATETIME_PARSE('2024-01-31T00:00:00Z'))"},
+		{
+			"Lower and Search",
+			airtable.Search("lâm's", airtable.Lower(airtable.Field("Name"))),
+			`SEARCH('lâm\'s', LOWER({Name}))`,
+		},
+		{"comparisons", airtable.And(airtable.Field("N").Lt(1), airtable.Field("N").Gte(0.5)), "AND({N} < 1, {N} >= 0.5)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.formula.String(); got != tt.want {
+				t.Errorf("formula = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldWithClosingBraceFilters(t *testing.T) {
+	srv, client := newTestClient(t, airtable.WithTableMappings(airtable.TableMapping{
+		Table:  "Locations",
+		Fields: map[string]string{"Label": "Tên {cũ}"},
+	}))
+	srv.AddRecords("Locations",
+		map[string]interface{}{"Tên {cũ}": "Main"},
+		map[string]interface{}{"Tên {cũ}": "Branch"},
+	)
+
+	// Both the logical name and the mapped column contain a closing brace
+	for _, field := range []string{"Tên {cũ}", "Label"} {
+		records, err := client.ListRecords(context.Background(), "Locations", &airtable.ListParams{
+			FilterByFormula: airtable.Field(field).Eq("Main").String(),
+		})
+		if err != nil {
+			t.Fatalf("ListRecords by %s: %v", field, err)
+		}
+		if len(records) != 1 {
+			t.Errorf("records by %s = %d, want 1", field, len(records))
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package airtable
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Operations reported to hooks
+const (
+	OpList    = "list"
+	OpGet     = "get"
+	OpCreate  = "create"
+	OpUpdate  = "update"
+	OpUpsert  = "upsert"
+	OpDelete  = "delete"
+	OpUpload  = "upload"
+	OpSchema  = "schema"
+	OpWebhook = "webhook"
+)
+
+// RequestInfo describes an Airtable API request.
+type RequestInfo struct {
+	Operation string // One of the Op constants
+	Table     string // Empty for requests not scoped to a table
+	Method    string
+	Records   int // Records sent in the request
+}
+
+// ResponseInfo describes the outcome of an Airtable API request after its
+// last attempt.
+type ResponseInfo struct {
+	Request    RequestInfo
+	StatusCode int           // Status of the last attempt, 0 if no response arrived
+	Records    int           // Records returned in the response
+	Latency    time.Duration // Total time including rate limiting and retries
+	Retries    int
+	Err        error
+}
+
+// Hook observes the requests a Client makes. OnRequest is called once before
+// the first attempt and OnResponse once after the last, both with the
+// caller's context. Hooks are called synchronously and must be safe for
+// concurrent use.
+type Hook interface {
+	OnRequest(ctx context.Context, info RequestInfo)
+	OnResponse(ctx context.Context, info ResponseInfo)
+}
+
+// WithHooks adds hooks that observe every API request.
+func WithHooks(hooks ...Hook) Option {
+	return func(o *clientOptions) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// countRecords returns the number of records in a request or response body.
+func countRecords(v interface{}) int {
+	switch body := v.(type) {
+	case apiRecord:
+		return 1
+	case *apiRecord:
+		if body.ID != "" || body.Fields != nil {
+			return 1
+		}
+	case apiRecordList:
+		return len(body.Records)
+	case *apiRecordList:
+		return len(body.Records)
+	case apiUpsertRequest:
+		return len(body.Records)
+	case *apiUpsertResponse:
+		return len(body.Records)
+	case *apiDeleteResponse:
+		return len(body.Records)
+	}
+	return 0
+}
+
+// logHook logs every response as a structured log entry.
+type logHook struct {
+	logger *slog.Logger
+}
+
+// NewLogHook returns a hook that logs each request with its table,
+// operation, record counts, status, latency and retries. Failed requests
+// are logged as warnings.
+func NewLogHook(logger *slog.Logger) Hook {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return logHook{logger: logger}
+}
+
+func (h logHook) OnRequest(ctx context.Context, info RequestInfo) {}
+
+func (h logHook) OnResponse(ctx context.Context, info ResponseInfo) {
+	attrs := []slog.Attr{
+		slog.String("operation", info.Request.Operation),
+		slog.String("table", info.Request.Table),
+		slog.String("method", info.Request.Method),
+		slog.Int("records_sent", info.Request.Records),
+		slog.Int("records_received", info.Records),
+		slog.Int("status", info.StatusCode),
+		slog.Duration("latency", info.Latency),
+		slog.Int("retries", info.Retries),
+	}
+
+	level := slog.LevelInfo
+	if info.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", info.Err.Error()))
+	}
+	h.logger.LogAttrs(ctx, level, "airtable request", attrs...)
+}
+
+// Metrics is a hook that keeps in-process counters per table and operation.
+// Combined with TrackCalls it also counts the Airtable requests made while
+// serving each route, which exposes N+1 request patterns.
+type Metrics struct {
+	mu         sync.Mutex
+	operations map[operationKey]*OperationStats
+	routes     map[string]*RouteStats
+}
+
+type operationKey struct {
+	table     string
+	operation string
+}
+
+// OperationStats are the counters for one operation on one table.
+type OperationStats struct {
+	Table        string        `json:"table"`
+	Operation    string        `json:"operation"`
+	Requests     int64         `json:"requests"`
+	Errors       int64         `json:"errors"`
+	Retries      int64         `json:"retries"`
+	Records      int64         `json:"records"` // Records sent and received
+	TotalLatency time.Duration `json:"total_latency_ns" swaggertype:"integer"`
+	MaxLatency   time.Duration `json:"max_latency_ns" swaggertype:"integer"`
+}
+
+// RouteStats count the Airtable requests made while serving a route.
+type RouteStats struct {
+	Route            string        `json:"route"`
+	Requests         int64         `json:"requests"`
+	AirtableCalls    int64         `json:"airtable_calls"`
+	MaxAirtableCalls int64         `json:"max_airtable_calls"` // Most calls made by a single request
+	AirtableLatency  time.Duration `json:"airtable_latency_ns" swaggertype:"integer"`
+}
+
+// MetricsSnapshot is a copy of the counters, sorted by table and operation
+// and by route.
+type MetricsSnapshot struct {
+	Operations []OperationStats `json:"operations"`
+	Routes     []RouteStats     `json:"routes"`
+}
+
+// NewMetrics creates an empty set of counters.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		operations: make(map[operationKey]*OperationStats),
+		routes:     make(map[string]*RouteStats),
+	}
+}
+
+// OnRequest implements Hook.
+func (m *Metrics) OnRequest(ctx context.Context, info RequestInfo) {}
+
+// OnResponse implements Hook, counting the request for its table and
+// operation and in the context's CallTally, if any.
+func (m *Metrics) OnResponse(ctx context.Context, info ResponseInfo) {
+	if tally, ok := ctx.Value(callTallyKey{}).(*CallTally); ok {
+		tally.calls.Add(1)
+		tally.latency.Add(int64(info.Latency))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := operationKey{table: info.Request.Table, operation: info.Request.Operation}
+	stats, ok := m.operations[key]
+	if !ok {
+		stats = &OperationStats{Table: key.table, Operation: key.operation}
+		m.operations[key] = stats
+	}
+	stats.Requests++
+	if info.Err != nil {
+		stats.Errors++
+	}
+	stats.Retries += int64(info.Retries)
+	stats.Records += int64(info.Request.Records + info.Records)
+	stats.TotalLatency += info.Latency
+	stats.MaxLatency = max(stats.MaxLatency, info.Latency)
+}
+
+// ObserveRoute records the Airtable requests counted by tally while serving
+// one request to route.
+func (m *Metrics) ObserveRoute(route string, tally *CallTally) {
+	calls := tally.Calls()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.routes[route]
+	if !ok {
+		stats = &RouteStats{Route: route}
+		m.routes[route] = stats
+	}
+	stats.Requests++
+	stats.AirtableCalls += calls
+	stats.MaxAirtableCalls = max(stats.MaxAirtableCalls, calls)
+	stats.AirtableLatency += tally.Latency()
+}
+
+// Snapshot returns a copy of the counters.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := MetricsSnapshot{
+		Operations: make([]OperationStats, 0, len(m.operations)),
+		Routes:     make([]RouteStats, 0, len(m.routes)),
+	}
+	for _, stats := range m.operations {
+		snapshot.Operations = append(snapshot.Operations, *stats)
+	}
+	for _, stats := range m.routes {
+		snapshot.Routes = append(snapshot.Routes, *stats)
+	}
+
+	sort.Slice(snapshot.Operations, func(i, j int) bool {
+		a, b := snapshot.Operations[i], snapshot.Operations[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		return a.Operation < b.Operation
+	})
+	sort.Slice(snapshot.Routes, func(i, j int) bool {
+		return snapshot.Routes[i].Route < snapshot.Routes[j].Route
+	})
+	return snapshot
+}
+
+// CallTally counts the Airtable requests made with a context returned by
+// TrackCalls. Requests are counted by the Metrics hook.
+type CallTally struct {
+	calls   atomic.Int64
+	latency atomic.Int64
+}
+
+type callTallyKey struct{}
+
+// TrackCalls returns a context whose Airtable requests are counted in the
+// returned tally, for example for the duration of one HTTP request.
+func TrackCalls(ctx context.Context) (context.Context, *CallTally) {
+	tally := &CallTally{}
+	return context.WithValue(ctx, callTallyKey{}, tally), tally
+}
+
+// Calls returns the number of requests made so far.
+func (t *CallTally) Calls() int64 {
+	return t.calls.Load()
+}
+
+// Latency returns the total time spent in those requests.
+func (t *CallTally) Latency() time.Duration {
+	return time.Duration(t.latency.Load())
+}
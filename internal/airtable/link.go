@@ -0,0 +1,158 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// maxIDsPerFormula bounds how many record IDs are looked up with a single
+// OR(RECORD_ID()=...) formula, keeping request URLs well under Airtable's
+// 16k character limit.
+const maxIDsPerFormula = 50
+
+// Link declares that a field of one table links to records of another, so
+// the linked records can be fetched with ListParams.Expand or Expand.
+type Link struct {
+	Table       string // Table holding the link field
+	Field       string // Name of the link field
+	LinkedTable string // Table the linked records belong to
+}
+
+type linkKey struct {
+	table string
+	field string
+}
+
+// WithLinks declares links between tables.
+func WithLinks(links ...Link) Option {
+	return func(o *clientOptions) {
+		o.links = append(o.links, links...)
+	}
+}
+
+// LinkedIDs returns the record IDs held by a link field, or nil when the
+// field is empty.
+func (r Record) LinkedIDs(field string) []string {
+	values, _ := r.Fields[field].([]interface{})
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(string); ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
+
+// GetRecords fetches records by ID with as few requests as possible: IDs are
+// looked up in chunks with OR(RECORD_ID()=...) formulas, and the chunks are
+// fetched concurrently. Records are returned in the order of ids; IDs that
+// do not exist are left out.
+func (c *Client) GetRecords(ctx context.Context, table string, ids []string) ([]Record, error) {
+	unique := make([]string, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, maxConcurrentChunks)
+		byID     = make(map[string]Record, len(unique))
+		firstErr error
+	)
+
+	for start := 0; start < len(unique); start += maxIDsPerFormula {
+		chunk := unique[start:min(start+maxIDsPerFormula, len(unique))]
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			conditions := make([]Formula, 0, len(chunk))
+			for _, id := range chunk {
+				conditions = append(conditions, RecordID().Eq(id))
+			}
+			records, err := c.ListRecords(ctx, table, &ListParams{FilterByFormula: Or(conditions...).String()})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			for _, record := range records {
+				byID[record.ID] = record
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, fmt.Errorf("airtable: get records failed: %w", firstErr)
+	}
+
+	result := make([]Record, 0, len(byID))
+	for _, id := range unique {
+		if record, ok := byID[id]; ok {
+			result = append(result, record)
+		}
+	}
+	return result, nil
+}
+
+// Expand fetches the records linked from the given fields of records and
+// stores them in each record's Expanded map. Each field must be declared
+// with WithLinks. Linked records are fetched in one batch per field, however
+// many records link to them.
+func (c *Client) Expand(ctx context.Context, table string, records []Record, fields ...string) error {
+	for _, field := range fields {
+		linkedTable, ok := c.links[linkKey{table: table, field: field}]
+		if !ok {
+			return fmt.Errorf("airtable: expand failed: %w: no link declared for field %q of table %q",
+				ErrInvalidRequest, field, table)
+		}
+
+		var ids []string
+		for _, record := range records {
+			ids = append(ids, record.LinkedIDs(field)...)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+
+		linked, err := c.GetRecords(ctx, linkedTable, ids)
+		if err != nil {
+			return fmt.Errorf("airtable: expand %s failed: %w", field, err)
+		}
+		byID := make(map[string]Record, len(linked))
+		for _, record := range linked {
+			byID[record.ID] = record
+		}
+
+		for i := range records {
+			var expanded []Record
+			for _, id := range records[i].LinkedIDs(field) {
+				if record, ok := byID[id]; ok {
+					expanded = append(expanded, record)
+				}
+			}
+			if records[i].Expanded == nil {
+				records[i].Expanded = make(map[string][]Record)
+			}
+			records[i].Expanded[field] = expanded
+		}
+	}
+	return nil
+}
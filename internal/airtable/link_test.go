@@ -0,0 +1,138 @@
+package airtable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+// locationManagers links each location to its managers.
+var locationManagers = airtable.Link{Table: "Locations", Field: "Managers", LinkedTable: "Users"}
+
+// names returns the Name field of each record, comma-separated.
+func names(records []airtable.Record) string {
+	parts := make([]string, len(records))
+	for i, r := range records {
+		parts[i], _ = r.Fields["Name"].(string)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestGetRecordsKeepsOrder(t *testing.T) {
+	srv, client := newTestClient(t)
+	ids := addLocations(srv, 3)
+
+	records, err := client.GetRecords(context.Background(), "Locations", []string{ids[2], "recMissing", ids[0], ids[2], ""})
+	if err != nil {
+		t.Fatalf("GetRecords: %v", err)
+	}
+	// Duplicates, empty and missing IDs are left out
+	if got := names(records); got != "Location 2,Location 0" {
+		t.Errorf("records = %s, want Location 2,Location 0", got)
+	}
+}
+
+func TestGetRecordsChunksIDs(t *testing.T) {
+	srv, client := newTestClient(t)
+	ids := addLocations(srv, 120)
+
+	records, err := client.GetRecords(context.Background(), "Locations", ids)
+	if err != nil {
+		t.Fatalf("GetRecords: %v", err)
+	}
+	if len(records) != 120 {
+		t.Fatalf("records = %d, want 120", len(records))
+	}
+	for i, r := range records {
+		if r.ID != ids[i] {
+			t.Fatalf("record %d = %s, want %s", i, r.ID, ids[i])
+		}
+	}
+	// 50 IDs per formula
+	if n := countRequests(srv, http.MethodGet); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestGetRecordsFails(t *testing.T) {
+	srv, client := newTestClient(t)
+	ids := addLocations(srv, 2)
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodGet, StatusCode: http.StatusUnauthorized})
+
+	if _, err := client.GetRecords(context.Background(), "Locations", ids); !errors.Is(err, airtable.ErrUnauthorized) {
+		t.Errorf("GetRecords error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestExpandFetchesLinkedRecords(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t, airtable.WithLinks(locationManagers))
+	users := srv.AddRecords("Users",
+		map[string]interface{}{"Name": "An"},
+		map[string]interface{}{"Name": "Bình"},
+		map[string]interface{}{"Name": "Chi"},
+	)
+	srv.AddRecords("Locations",
+		map[string]interface{}{"Name": "Main", "Managers": []string{users[1].ID, users[0].ID}},
+		map[string]interface{}{"Name": "Branch", "Managers": []string{users[1].ID, "recDeleted"}},
+		map[string]interface{}{"Name": "Empty"},
+	)
+
+	records, err := client.ListRecords(ctx, "Locations", &airtable.ListParams{Expand: []string{"Managers"}})
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	want := map[string]string{"Main": "Bình,An", "Branch": "Bình", "Empty": ""}
+	for _, r := range records {
+		name := r.Fields["Name"].(string)
+		if got := names(r.Expanded["Managers"]); got != want[name] {
+			t.Errorf("managers of %s = %q, want %q", name, got, want[name])
+		}
+	}
+
+	// One request for the locations and one for all their managers
+	if n := countRequests(srv, http.MethodGet); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+}
+
+func TestExpandRejectsUndeclaredLink(t *testing.T) {
+	srv, client := newTestClient(t, airtable.WithLinks(locationManagers))
+	srv.AddRecords("Locations", map[string]interface{}{"Name": "Main", "Owner": []string{"recOwner"}})
+
+	records, err := client.ListRecords(context.Background(), "Locations", nil)
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	// The link is declared for another table, or not at all
+	for _, link := range []airtable.Link{{Table: "Users", Field: "Managers"}, {Table: "Locations", Field: "Owner"}} {
+		err := client.Expand(context.Background(), link.Table, records, link.Field)
+		if !errors.Is(err, airtable.ErrInvalidRequest) {
+			t.Errorf("Expand(%s, %s) error = %v, want ErrInvalidRequest", link.Table, link.Field, err)
+		}
+	}
+}
+
+func TestLinkedIDs(t *testing.T) {
+	record := airtable.Record{Fields: map[string]interface{}{
+		"Managers": []interface{}{"rec1", 2.0, "rec2"},
+		"Empty":    []interface{}{},
+		"Name":     "Main",
+	}}
+	tests := map[string]string{"Managers": "[rec1 rec2]", "Empty": "[]", "Name": "[]", "Missing": "[]"}
+	for field, want := range tests {
+		ids := record.LinkedIDs(field)
+		if got := fmt.Sprint(ids); got != want {
+			t.Errorf("LinkedIDs(%s) = %s, want %s", field, got, want)
+		}
+		if want == "[]" && ids != nil {
+			t.Errorf("LinkedIDs(%s) = %#v, want nil", field, ids)
+		}
+	}
+}
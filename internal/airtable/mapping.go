@@ -0,0 +1,349 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fieldNameCacheTTL is how long field names looked up from the base schema
+// are trusted before being fetched again, bounding how long a renamed column
+// can break formulas.
+const fieldNameCacheTTL = 5 * time.Minute
+
+// TableMapping maps the logical field names used in code to the columns of
+// one table, so the code keeps working when columns are renamed in Airtable
+// or named in another language. Columns can be given by name or, to survive
+// renames, by field ID ("fldXXXXXXXXXXXXXX"). Fields without a mapping use
+// their logical name as the column name.
+type TableMapping struct {
+	Table  string            // Table name or ID, as passed to the client
+	Fields map[string]string // Logical field name to column name or field ID
+}
+
+// WithTableMappings sets how the logical field names of tables map to
+// columns. Records, formulas, sorts and writes all use logical names; the
+// client translates them on the way to and from Airtable.
+func WithTableMappings(mappings ...TableMapping) Option {
+	return func(o *clientOptions) {
+		o.mappings = append(o.mappings, mappings...)
+	}
+}
+
+// WithReturnFieldsByFieldID asks Airtable to key the fields of returned
+// records by field ID instead of name, so reads are unaffected by a column
+// being renamed mid-request. Field IDs without a mapping are translated back
+// to names using the base schema, which needs the schema.bases:read scope.
+func WithReturnFieldsByFieldID(enabled bool) Option {
+	return func(o *clientOptions) {
+		o.returnFieldsByFieldID = enabled
+	}
+}
+
+// tableMapping is the lookup form of a TableMapping.
+type tableMapping struct {
+	columns map[string]string // Logical name to column name or field ID
+	byID    map[string]string // Field ID to logical name
+	byName  map[string]string // Column name to logical name
+}
+
+func newTableMapping(fields map[string]string) *tableMapping {
+	m := &tableMapping{
+		columns: make(map[string]string, len(fields)),
+		byID:    make(map[string]string),
+		byName:  make(map[string]string),
+	}
+	for logical, column := range fields {
+		m.columns[logical] = column
+		if isFieldID(column) {
+			m.byID[column] = logical
+		} else {
+			m.byName[column] = logical
+		}
+	}
+	return m
+}
+
+// isFieldID reports whether s looks like an Airtable field ID.
+func isFieldID(s string) bool {
+	return len(s) == 17 && strings.HasPrefix(s, "fld")
+}
+
+// fieldNameCache holds the field names of every table, keyed by table name
+// and ID, then by field ID.
+type fieldNameCache struct {
+	mu        sync.Mutex
+	fetchedAt time.Time
+	tables    map[string]map[string]string
+}
+
+// mapsFields reports whether requests for table need translating.
+func (c *Client) mapsFields(table string) bool {
+	return table != "" && (c.mappings[table] != nil || c.returnFieldsByFieldID)
+}
+
+// column returns the column (name or field ID) of a logical field. Airtable
+// accepts either when writing records.
+func (c *Client) column(table, field string) string {
+	if m := c.mappings[table]; m != nil {
+		if column, ok := m.columns[field]; ok {
+			return column
+		}
+	}
+	return field
+}
+
+// columnName returns the current column name of a logical field, for the
+// places where Airtable only accepts names, such as formulas.
+func (c *Client) columnName(ctx context.Context, table, field string) (string, error) {
+	column := c.column(table, field)
+	if !isFieldID(column) {
+		return column, nil
+	}
+
+	names, err := c.fieldNames(ctx, table)
+	if err != nil {
+		return "", err
+	}
+	name, ok := names[column]
+	if !ok {
+		return "", fmt.Errorf("%w: field %s of %q not found", ErrInvalidRequest, column, table)
+	}
+	return name, nil
+}
+
+// fieldNames returns the field names of table keyed by field ID, fetching
+// the base schema if the cached copy is missing or stale. The schema is
+// fetched without holding the cache's lock, so a slow fetch does not block
+// lookups; concurrent fetches may race, and the last one wins.
+func (c *Client) fieldNames(ctx context.Context, table string) (map[string]string, error) {
+	c.names.mu.Lock()
+	tables := c.names.tables
+	stale := tables == nil || time.Since(c.names.fetchedAt) > fieldNameCacheTTL
+	c.names.mu.Unlock()
+
+	if stale {
+		schema, err := c.GetBaseSchema(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tables = make(map[string]map[string]string, 2*len(schema))
+		for _, t := range schema {
+			names := make(map[string]string, len(t.Fields))
+			for _, f := range t.Fields {
+				names[f.ID] = f.Name
+			}
+			tables[t.Name] = names
+			tables[t.ID] = names
+		}
+
+		c.names.mu.Lock()
+		c.names.tables = tables
+		c.names.fetchedAt = time.Now()
+		c.names.mu.Unlock()
+	}
+
+	names, ok := tables[table]
+	if !ok {
+		return nil, fmt.Errorf("%w: table %q not found in base schema", ErrNotFound, table)
+	}
+	return names, nil
+}
+
+// toColumns returns fields keyed by column instead of logical name.
+func (c *Client) toColumns(table string, fields map[string]interface{}) map[string]interface{} {
+	if c.mappings[table] == nil || fields == nil {
+		return fields
+	}
+	mapped := make(map[string]interface{}, len(fields))
+	for field, value := range fields {
+		mapped[c.column(table, field)] = value
+	}
+	return mapped
+}
+
+// fromColumns returns the fields of a returned record keyed by logical name.
+// Keys that match no mapping are translated from field ID to name when
+// returnFieldsByFieldId is on, and from name to field ID when the table is
+// mapped by field ID, using the base schema.
+func (c *Client) fromColumns(ctx context.Context, table string, fields map[string]interface{}) (map[string]interface{}, error) {
+	m := c.mappings[table]
+	if m == nil {
+		m = newTableMapping(nil)
+	}
+
+	mapped := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		if logical, ok := m.byID[key]; ok {
+			mapped[logical] = value
+			continue
+		}
+		if logical, ok := m.byName[key]; ok {
+			mapped[logical] = value
+			continue
+		}
+
+		switch {
+		case isFieldID(key):
+			names, err := c.fieldNames(ctx, table)
+			if err != nil {
+				return nil, err
+			}
+			if name, ok := names[key]; ok {
+				key = name
+				if logical, ok := m.byName[name]; ok {
+					key = logical
+				}
+			}
+		case len(m.byID) > 0:
+			// The column may be mapped by ID and returned by name
+			names, err := c.fieldNames(ctx, table)
+			if err != nil {
+				return nil, err
+			}
+			for id, name := range names {
+				if logical, ok := m.byID[id]; ok && name == key {
+					key = logical
+					break
+				}
+			}
+		}
+		mapped[key] = value
+	}
+	return mapped, nil
+}
+
+// mapFormula rewrites the {Field} references of a formula from logical names
+// to column names. String literals are left untouched.
+func (c *Client) mapFormula(ctx context.Context, table, formula string) (string, error) {
+	var b strings.Builder
+	b.Grow(len(formula))
+
+	for i := 0; i < len(formula); i++ {
+		switch ch := formula[i]; ch {
+		case '\'', '"':
+			end := i + 1
+			for end < len(formula) && formula[end] != ch {
+				if formula[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(formula))
+			b.WriteString(formula[i:end])
+			i = end - 1
+		case '{':
+			field, closing := scanFieldRef(formula, i)
+			if closing < 0 {
+				b.WriteString(formula[i:])
+				return b.String(), nil
+			}
+			name, err := c.columnName(ctx, table, field)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(fieldRef(name))
+			i = closing
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String(), nil
+}
+
+// mapRequest translates the query and body of a request on table from
+// logical field names to columns. The caller's values are not modified.
+func (c *Client) mapRequest(ctx context.Context, op, table string, query url.Values, body interface{}) (url.Values, interface{}, error) {
+	if len(query) > 0 || c.returnFieldsByFieldID {
+		mapped := make(url.Values, len(query)+1)
+		for key, values := range query {
+			mapped[key] = values
+			switch {
+			case key == "filterByFormula":
+				formula, err := c.mapFormula(ctx, table, values[0])
+				if err != nil {
+					return nil, nil, err
+				}
+				mapped[key] = []string{formula}
+			case strings.HasPrefix(key, "sort[") && strings.HasSuffix(key, "[field]"):
+				name, err := c.columnName(ctx, table, values[0])
+				if err != nil {
+					return nil, nil, err
+				}
+				mapped[key] = []string{name}
+			case key == "fields[]":
+				columns := make([]string, len(values))
+				for i, field := range values {
+					columns[i] = c.column(table, field)
+				}
+				mapped[key] = columns
+			}
+		}
+		if c.returnFieldsByFieldID && (op == OpList || op == OpGet) {
+			mapped.Set("returnFieldsByFieldId", "true")
+		}
+		query = mapped
+	}
+
+	switch b := body.(type) {
+	case apiRecord:
+		b.Fields = c.toColumns(table, b.Fields)
+		b.ReturnFieldsByFieldID = c.returnFieldsByFieldID
+		body = b
+	case apiRecordList:
+		b.Records = c.recordsToColumns(table, b.Records)
+		b.ReturnFieldsByFieldID = c.returnFieldsByFieldID
+		body = b
+	case apiUpsertRequest:
+		b.Records = c.recordsToColumns(table, b.Records)
+		merge := make([]string, 0, len(b.PerformUpsert.FieldsToMergeOn))
+		for _, field := range b.PerformUpsert.FieldsToMergeOn {
+			merge = append(merge, c.column(table, field))
+		}
+		b.PerformUpsert.FieldsToMergeOn = merge
+		b.ReturnFieldsByFieldID = c.returnFieldsByFieldID
+		body = b
+	}
+	return query, body, nil
+}
+
+func (c *Client) recordsToColumns(table string, records []apiRecord) []apiRecord {
+	mapped := make([]apiRecord, len(records))
+	for i, r := range records {
+		mapped[i] = apiRecord{ID: r.ID, Fields: c.toColumns(table, r.Fields)}
+	}
+	return mapped
+}
+
+// mapResponse translates the records of a decoded response on table back to
+// logical field names.
+func (c *Client) mapResponse(ctx context.Context, table string, out interface{}) error {
+	var records []apiRecord
+	switch o := out.(type) {
+	case *apiRecord:
+		fields, err := c.fromColumns(ctx, table, o.Fields)
+		if err != nil {
+			return err
+		}
+		o.Fields = fields
+		return nil
+	case *apiRecordList:
+		records = o.Records
+	case *apiUpsertResponse:
+		records = o.Records
+	default:
+		return nil
+	}
+
+	for i := range records {
+		fields, err := c.fromColumns(ctx, table, records[i].Fields)
+		if err != nil {
+			return err
+		}
+		records[i].Fields = fields
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package airtable_test
+
+import (
+	"context"
+	"testing"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+// slugFieldID is the field ID of the Slug column in newMappedServer.
+const slugFieldID = "fldSlug0000000000"
+
+// newMappedServer returns a client whose Locations table maps Name to a
+// Vietnamese column and Slug to a field ID.
+func newMappedServer(t *testing.T, opts ...airtable.Option) (*airtabletest.Server, *airtable.Client) {
+	t.Helper()
+	srv, client := newTestClient(t, append([]airtable.Option{airtable.WithTableMappings(airtable.TableMapping{
+		Table:  "Locations",
+		Fields: map[string]string{"Name": "Tên", "Slug": slugFieldID},
+	})}, opts...)...)
+	srv.DefineTable("Locations",
+		airtable.FieldSchema{Name: "Tên", Type: airtable.FieldTypeSingleLineText},
+		airtable.FieldSchema{ID: slugFieldID, Name: "Đường dẫn", Type: airtable.FieldTypeSingleLineText},
+		airtable.FieldSchema{Name: "Notes", Type: airtable.FieldTypeMultilineText},
+	)
+	return srv, client
+}
+
+func TestMappedFieldsReadAndWrite(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		byFieldID bool
+	}{{"by name", false}, {"by field ID", true}} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			srv, client := newMappedServer(t, airtable.WithReturnFieldsByFieldID(tt.byFieldID))
+
+			created, err := client.CreateRecord(ctx, "Locations", map[string]interface{}{
+				"Name": "Lâm Phương", "Slug": "lam-phuong", "Notes": "Main",
+			})
+			if err != nil {
+				t.Fatalf("CreateRecord: %v", err)
+			}
+			// Stored under the columns, returned under the logical names
+			stored := srv.Records("Locations")[0].Fields
+			if stored["Tên"] != "Lâm Phương" || stored["Đường dẫn"] != "lam-phuong" || stored["Notes"] != "Main" {
+				t.Errorf("stored fields = %v", stored)
+			}
+			if created.Fields["Name"] != "Lâm Phương" || created.Fields["Slug"] != "lam-phuong" || created.Fields["Notes"] != "Main" {
+				t.Errorf("created fields = %v", created.Fields)
+			}
+
+			srv.AddRecords("Locations", map[string]interface{}{"Tên": "Chi nhánh", "Đường dẫn": "chi-nhanh"})
+			records, err := client.ListRecords(ctx, "Locations", &airtable.ListParams{
+				FilterByFormula: airtable.Field("Slug").NotBlank().String(),
+				Sort:            []airtable.SortParam{{Field: "Slug", Direction: "desc"}},
+			})
+			if err != nil {
+				t.Fatalf("ListRecords: %v", err)
+			}
+			if names(records) != "Lâm Phương,Chi nhánh" {
+				t.Errorf("records = %s, want Lâm Phương,Chi nhánh", names(records))
+			}
+
+			// Formulas and sorts reach Airtable with column names
+			reqs := srv.Requests()
+			query := reqs[len(reqs)-1].Query
+			if got := query.Get("filterByFormula"); got != "{Đường dẫn} != BLANK()" {
+				t.Errorf("filterByFormula = %q", got)
+			}
+			if got := query.Get("sort[0][field]"); got != "Đường dẫn" {
+				t.Errorf("sort field = %q", got)
+			}
+		})
+	}
+}
+
+func TestSchemaCheckerUsesMappedColumns(t *testing.T) {
+	_, client := newMappedServer(t)
+	text := []string{airtable.FieldTypeSingleLineText}
+
+	report, err := airtable.NewSchemaChecker(client, airtable.TableRequirement{
+		Table: "Locations",
+		Fields: []airtable.FieldRequirement{
+			{Name: "Name", Types: text},
+			{Name: "Slug", Types: text},
+			{Name: "Notes"},
+			{Name: "Description", Column: "Notes"}, // An explicit column wins
+		},
+	}).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if !report.OK {
+		t.Errorf("report not OK: %v", report.Problems())
+	}
+
+	want := map[string]string{"Name": "Tên", "Slug": slugFieldID, "Notes": "", "Description": "Notes"}
+	for _, f := range report.Tables[0].Fields {
+		if f.Column != want[f.Name] {
+			t.Errorf("column of %s = %q, want %q", f.Name, f.Column, want[f.Name])
+		}
+	}
+}
+
+func TestSchemaCheckerReportsMissingMappedColumn(t *testing.T) {
+	srv, client := newTestClient(t, airtable.WithTableMappings(airtable.TableMapping{
+		Table:  "Locations",
+		Fields: map[string]string{"Name": "Tên"},
+	}))
+	// The table has the logical name but not the mapped column
+	srv.DefineTable("Locations", airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeSingleLineText})
+
+	report, err := airtable.NewSchemaChecker(client, airtable.TableRequirement{
+		Table:  "Locations",
+		Fields: []airtable.FieldRequirement{{Name: "Name"}},
+	}).Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	problems := report.Problems()
+	if report.OK || len(problems) != 1 || problems[0] != `table "Locations": field "Tên" not found` {
+		t.Errorf("problems = %q, want the mapped column not found", problems)
+	}
+}
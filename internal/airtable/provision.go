@@ -0,0 +1,290 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// SelectOptions returns the options of a single select field with the given
+// choices, for FieldRequirement.Options.
+func SelectOptions(choices ...string) map[string]interface{} {
+	list := make([]interface{}, len(choices))
+	for i, choice := range choices {
+		list[i] = map[string]interface{}{"name": choice}
+	}
+	return map[string]interface{}{"choices": list}
+}
+
+// defaultFieldOptions returns the options Airtable requires when creating a
+// field of the given type, or nil if none are required.
+func defaultFieldOptions(fieldType string) map[string]interface{} {
+	switch fieldType {
+	case FieldTypeDateTime:
+		return map[string]interface{}{
+			"dateFormat": map[string]interface{}{"name": "iso"},
+			"timeFormat": map[string]interface{}{"name": "24hour"},
+			"timeZone":   "utc",
+		}
+	case FieldTypeDate:
+		return map[string]interface{}{"dateFormat": map[string]interface{}{"name": "iso"}}
+	case FieldTypeNumber:
+		return map[string]interface{}{"precision": 6}
+	}
+	return nil
+}
+
+// Provisioner creates the tables and fields of a base that its requirements
+// name but the base lacks. Existing fields are never modified: a field with
+// an incompatible type is reported as a problem for a person to resolve.
+//
+//	provisioner := airtable.NewProvisioner(client, location.AirtableSchema("Locations"))
+//	plan, err := provisioner.Plan(ctx)
+//	fmt.Print(plan)
+//	err = provisioner.Apply(ctx, plan)
+//
+// Creating tables and fields needs the schema.bases:write scope.
+type Provisioner struct {
+	client       *Client
+	requirements []TableRequirement
+}
+
+// ProvisionPlan lists the changes that make a base meet the requirements.
+type ProvisionPlan struct {
+	Tables   []TablePlan
+	Problems []string // Differences that creating fields cannot fix, such as incompatible types
+}
+
+// TablePlan lists the changes to one table.
+type TablePlan struct {
+	Name   string
+	ID     string // Empty when the table is created
+	Fields []FieldPlan
+}
+
+// FieldPlan is a field to create.
+type FieldPlan struct {
+	FieldSchema
+	LinkedTable string // Name of the table a multipleRecordLinks field links to
+}
+
+// NewProvisioner creates a provisioner for the given requirements. Tables
+// and fields are created under the columns configured with WithTableMappings.
+func NewProvisioner(client *Client, requirements ...TableRequirement) *Provisioner {
+	return &Provisioner{client: client, requirements: requirements}
+}
+
+// Empty reports whether the plan makes no changes.
+func (p ProvisionPlan) Empty() bool {
+	for _, t := range p.Tables {
+		if t.ID == "" || len(t.Fields) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the plan in a human-readable form, one change per line.
+func (p ProvisionPlan) String() string {
+	var b strings.Builder
+	for _, t := range p.Tables {
+		switch {
+		case t.ID == "":
+			fmt.Fprintf(&b, "+ create table %q\n", t.Name)
+		case len(t.Fields) > 0:
+			fmt.Fprintf(&b, "~ update table %q (%s)\n", t.Name, t.ID)
+		default:
+			fmt.Fprintf(&b, "= table %q is up to date\n", t.Name)
+		}
+		for _, f := range t.Fields {
+			fmt.Fprintf(&b, "    + field %q (%s)\n", f.Name, f.describe())
+		}
+	}
+	for _, problem := range p.Problems {
+		fmt.Fprintf(&b, "! %s\n", problem)
+	}
+	return b.String()
+}
+
+// describe returns the type of the field with its notable options.
+func (f FieldPlan) describe() string {
+	switch {
+	case f.LinkedTable != "":
+		return fmt.Sprintf("%s to %q", f.Type, f.LinkedTable)
+	case f.Type == FieldTypeSingleSelect:
+		return fmt.Sprintf("%s: %s", f.Type, strings.Join(choiceNames(f.Options), ", "))
+	}
+	return f.Type
+}
+
+// Plan compares the base schema with the requirements and returns the
+// tables and fields to create.
+func (p *Provisioner) Plan(ctx context.Context) (ProvisionPlan, error) {
+	schema, err := p.client.GetBaseSchema(ctx)
+	if err != nil {
+		return ProvisionPlan{}, err
+	}
+
+	tables := make(map[string]TableSchema, 2*len(schema))
+	for _, t := range schema {
+		tables[t.Name] = t
+		tables[t.ID] = t
+	}
+
+	var plan ProvisionPlan
+	for _, req := range p.client.mapRequirements(p.requirements) {
+		table, exists := tables[req.Table]
+		if !exists && strings.HasPrefix(req.Table, "tbl") && len(req.Table) == 17 {
+			plan.Problems = append(plan.Problems, fmt.Sprintf("table %s not found; tables given by ID cannot be created", req.Table))
+			continue
+		}
+
+		tablePlan := TablePlan{Name: req.Table, ID: table.ID}
+		if exists {
+			tablePlan.Name = table.Name
+		}
+		for _, fieldReq := range req.Fields {
+			column := fieldReq.column()
+			if field, ok := table.Field(column); ok {
+				plan.Problems = append(plan.Problems, checkExistingField(tablePlan.Name, field, fieldReq)...)
+				continue
+			}
+			if isFieldID(column) {
+				plan.Problems = append(plan.Problems, fmt.Sprintf("table %q: field %s not found; fields mapped by ID cannot be created", tablePlan.Name, column))
+				continue
+			}
+			tablePlan.Fields = append(tablePlan.Fields, newFieldPlan(column, fieldReq))
+		}
+		plan.Tables = append(plan.Tables, tablePlan)
+	}
+	return plan, nil
+}
+
+// column returns the column the field is looked up and created as.
+func (f FieldRequirement) column() string {
+	if f.Column != "" {
+		return f.Column
+	}
+	return f.Name
+}
+
+// newFieldPlan returns the field to create for a requirement, using the
+// first of its compatible types.
+func newFieldPlan(column string, req FieldRequirement) FieldPlan {
+	fieldType := FieldTypeSingleLineText
+	if len(req.Types) > 0 {
+		fieldType = req.Types[0]
+	}
+
+	options := req.Options
+	if options == nil {
+		options = defaultFieldOptions(fieldType)
+	}
+	return FieldPlan{
+		FieldSchema: FieldSchema{Name: column, Type: fieldType, Options: options},
+		LinkedTable: req.LinkedTable,
+	}
+}
+
+// checkExistingField describes how an existing field falls short of its
+// requirement: an incompatible type, or select choices the code writes that
+// the field does not offer.
+func checkExistingField(table string, field FieldSchema, req FieldRequirement) []string {
+	if !typeAllowed(field.Type, req.Types) {
+		return []string{fmt.Sprintf("table %q: field %q has type %q, expected one of %v", table, field.Name, field.Type, req.Types)}
+	}
+	if field.Type != FieldTypeSingleSelect {
+		return nil
+	}
+
+	offered := make(map[string]bool)
+	for _, choice := range choiceNames(field.Options) {
+		offered[choice] = true
+	}
+	var missing []string
+	for _, choice := range choiceNames(req.Options) {
+		if !offered[choice] {
+			missing = append(missing, choice)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("table %q: field %q lacks the choices %s; add them in Airtable", table, field.Name, strings.Join(missing, ", "))}
+}
+
+// choiceNames returns the names of the choices in single select options.
+func choiceNames(options map[string]interface{}) []string {
+	choices, _ := options["choices"].([]interface{})
+	names := make([]string, 0, len(choices))
+	for _, choice := range choices {
+		if c, ok := choice.(map[string]interface{}); ok {
+			if name, ok := c["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Apply makes the changes in plan: missing tables are created first, with
+// their first field as the primary field, and link fields last, once the
+// tables they link to exist. It stops at the first failure; planning again
+// shows what remains.
+func (p *Provisioner) Apply(ctx context.Context, plan ProvisionPlan) error {
+	schema, err := p.client.GetBaseSchema(ctx)
+	if err != nil {
+		return err
+	}
+	tableIDs := make(map[string]string, len(schema)+len(plan.Tables))
+	for _, t := range schema {
+		tableIDs[t.Name] = t.ID
+	}
+
+	pending := make([][]FieldPlan, len(plan.Tables))
+	for i, t := range plan.Tables {
+		if t.ID != "" {
+			pending[i] = t.Fields
+			continue
+		}
+
+		var fields []FieldSchema
+		for _, f := range t.Fields {
+			if f.LinkedTable != "" {
+				pending[i] = append(pending[i], f)
+				continue
+			}
+			fields = append(fields, f.FieldSchema)
+		}
+		if len(fields) == 0 {
+			return fmt.Errorf("airtable: table %q needs a field that is not a link to be created", t.Name)
+		}
+
+		table, err := p.client.CreateTable(ctx, t.Name, fields)
+		if err != nil {
+			return err
+		}
+		tableIDs[t.Name] = table.ID
+		log.Printf("Created Airtable table %s (%s) with %d fields", t.Name, table.ID, len(fields))
+	}
+
+	for i, t := range plan.Tables {
+		for _, f := range pending[i] {
+			field := f.FieldSchema
+			if f.LinkedTable != "" {
+				linkedID, ok := tableIDs[f.LinkedTable]
+				if !ok {
+					return fmt.Errorf("airtable: table %q linked from %q.%q not found", f.LinkedTable, t.Name, f.Name)
+				}
+				field.Options = map[string]interface{}{"linkedTableId": linkedID}
+			}
+
+			if _, err := p.client.CreateField(ctx, tableIDs[t.Name], field); err != nil {
+				return err
+			}
+			log.Printf("Created Airtable field %s.%s (%s)", t.Name, f.Name, f.Type)
+		}
+	}
+	return nil
+}
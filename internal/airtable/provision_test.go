@@ -0,0 +1,182 @@
+package airtable_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+// schemaRequests counts the tables and fields created through the Metadata API.
+func schemaRequests(srv *airtabletest.Server) (tables, fields int) {
+	for _, req := range srv.Requests() {
+		switch {
+		case req.Method != http.MethodPost || !strings.Contains(req.Path, "/meta/"):
+		case strings.HasSuffix(req.Path, "/fields"):
+			fields++
+		case strings.HasSuffix(req.Path, "/tables"):
+			tables++
+		}
+	}
+	return tables, fields
+}
+
+// tableSchema returns the schema of a table of the fake base.
+func tableSchema(t *testing.T, client *airtable.Client, name string) airtable.TableSchema {
+	t.Helper()
+	schema, err := client.GetBaseSchema(context.Background())
+	if err != nil {
+		t.Fatalf("GetBaseSchema: %v", err)
+	}
+	for _, table := range schema {
+		if table.Name == name {
+			return table
+		}
+	}
+	t.Fatalf("table %q not found", name)
+	return airtable.TableSchema{}
+}
+
+// applyPlan plans and applies the changes of a provisioner.
+func applyPlan(t *testing.T, p *airtable.Provisioner) airtable.ProvisionPlan {
+	t.Helper()
+	ctx := context.Background()
+	plan, err := p.Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if err := p.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	return plan
+}
+
+func TestProvisionerCreatesOnlyMissingFields(t *testing.T) {
+	srv, client := newTestClient(t, airtable.WithTableMappings(airtable.TableMapping{
+		Table:  "Locations",
+		Fields: map[string]string{"Description": "Mô tả"},
+	}))
+	srv.DefineTable("Locations",
+		airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeSingleLineText},
+		airtable.FieldSchema{Name: "Slug", Type: airtable.FieldTypeSingleLineText},
+	)
+	provisioner := airtable.NewProvisioner(client, airtable.TableRequirement{
+		Table: "Locations",
+		Fields: []airtable.FieldRequirement{
+			{Name: "Name", Types: []string{airtable.FieldTypeSingleLineText}},
+			{Name: "Slug", Types: []string{airtable.FieldTypeSingleLineText}},
+			{Name: "Description", Types: []string{airtable.FieldTypeMultilineText, airtable.FieldTypeRichText}},
+			{Name: "Created At", Types: []string{airtable.FieldTypeDateTime}},
+		},
+	})
+
+	plan := applyPlan(t, provisioner)
+	want := `~ update table "Locations" (` + plan.Tables[0].ID + `)
+    + field "Mô tả" (multilineText)
+    + field "Created At" (dateTime)
+`
+	if plan.String() != want {
+		t.Errorf("plan =\n%s\nwant\n%s", plan, want)
+	}
+	if tables, fields := schemaRequests(srv); tables != 0 || fields != 2 {
+		t.Errorf("created %d tables and %d fields, want only 2 fields", tables, fields)
+	}
+
+	table := tableSchema(t, client, "Locations")
+	if len(table.Fields) != 4 {
+		t.Fatalf("fields = %+v, want 4", table.Fields)
+	}
+	if created, _ := table.Field("Created At"); created.Options["timeZone"] != "utc" {
+		t.Errorf("Created At options = %v, want the defaults", created.Options)
+	}
+
+	// Nothing is left to create
+	again := applyPlan(t, provisioner)
+	if !again.Empty() {
+		t.Errorf("second plan =\n%s", again)
+	}
+	if _, fields := schemaRequests(srv); fields != 2 {
+		t.Errorf("fields created = %d after the second apply, want 2", fields)
+	}
+}
+
+func TestProvisionerCreatesTablesBeforeLinks(t *testing.T) {
+	srv, client := newTestClient(t)
+	provisioner := airtable.NewProvisioner(client,
+		airtable.TableRequirement{
+			Table: "Locations",
+			Fields: []airtable.FieldRequirement{
+				{Name: "Managers", Types: []string{airtable.FieldTypeRecordLinks}, LinkedTable: "Users"},
+				{Name: "Name"},
+			},
+		},
+		airtable.TableRequirement{
+			Table: "Users",
+			Fields: []airtable.FieldRequirement{
+				{Name: "Email", Types: []string{airtable.FieldTypeEmail}},
+				{Name: "Role", Types: []string{airtable.FieldTypeSingleSelect}, Options: airtable.SelectOptions("Admin", "Staff")},
+			},
+		},
+	)
+
+	plan := applyPlan(t, provisioner)
+	if !strings.Contains(plan.String(), `+ field "Role" (singleSelect: Admin, Staff)`) {
+		t.Errorf("plan =\n%s", plan)
+	}
+	if tables, fields := schemaRequests(srv); tables != 2 || fields != 1 {
+		t.Errorf("created %d tables and %d fields, want 2 tables and the link field", tables, fields)
+	}
+
+	// The primary field is the first that is not a link
+	locations := tableSchema(t, client, "Locations")
+	users := tableSchema(t, client, "Users")
+	if primary := locations.Fields[0]; primary.ID != locations.PrimaryFieldID || primary.Name != "Name" {
+		t.Errorf("primary field = %+v", primary)
+	}
+	if managers, _ := locations.Field("Managers"); managers.Options["linkedTableId"] != users.ID {
+		t.Errorf("Managers options = %v, want a link to %s", managers.Options, users.ID)
+	}
+}
+
+func TestProvisionerReportsProblems(t *testing.T) {
+	srv, client := newTestClient(t, airtable.WithTableMappings(airtable.TableMapping{
+		Table:  "Locations",
+		Fields: map[string]string{"Slug": "fldSlug0000000000"},
+	}))
+	srv.DefineTable("Locations",
+		airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeNumber},
+		airtable.FieldSchema{Name: "Status", Type: airtable.FieldTypeSingleSelect, Options: airtable.SelectOptions("Open")},
+	)
+	provisioner := airtable.NewProvisioner(client,
+		airtable.TableRequirement{
+			Table: "Locations",
+			Fields: []airtable.FieldRequirement{
+				{Name: "Name", Types: []string{airtable.FieldTypeSingleLineText}},
+				{Name: "Status", Types: []string{airtable.FieldTypeSingleSelect}, Options: airtable.SelectOptions("Open", "Closed")},
+				{Name: "Slug"},
+			},
+		},
+		airtable.TableRequirement{Table: "tblMissing0000000", Fields: []airtable.FieldRequirement{{Name: "Name"}}},
+	)
+
+	plan := applyPlan(t, provisioner)
+	want := []string{
+		`table "Locations": field "Name" has type "number", expected one of [singleLineText]`,
+		`table "Locations": field "Status" lacks the choices Closed; add them in Airtable`,
+		`table "Locations": field fldSlug0000000000 not found; fields mapped by ID cannot be created`,
+		`table tblMissing0000000 not found; tables given by ID cannot be created`,
+	}
+	if strings.Join(plan.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("problems =\n%s\nwant\n%s", strings.Join(plan.Problems, "\n"), strings.Join(want, "\n"))
+	}
+	if !plan.Empty() {
+		t.Errorf("plan =\n%s\nwant no changes", plan)
+	}
+	// Existing fields are left alone
+	if tables, fields := schemaRequests(srv); tables != 0 || fields != 0 {
+		t.Errorf("created %d tables and %d fields, want none", tables, fields)
+	}
+}
@@ -0,0 +1,115 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultWriteQueueSize is the number of writes a WriteQueue holds when no
+// size is given.
+const DefaultWriteQueueSize = 100
+
+// WriteQueue holds writes that could not be sent while the circuit breaker
+// was open and replays them in order once a probe closes it. The queue lives
+// in memory, so queued writes are lost if the process stops first.
+type WriteQueue struct {
+	client *Client
+	size   int
+
+	mu       sync.Mutex
+	pending  []queuedWrite
+	flushing bool
+}
+
+type queuedWrite struct {
+	description string
+	queuedAt    time.Time
+	apply       func(ctx context.Context) error
+}
+
+// NewWriteQueue creates a queue of at most size writes that is flushed
+// whenever client's circuit closes.
+func NewWriteQueue(client *Client, size int) *WriteQueue {
+	if size <= 0 {
+		size = DefaultWriteQueueSize
+	}
+
+	q := &WriteQueue{client: client, size: size}
+	client.onCircuitClose(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		defer cancel()
+		if err := q.Flush(ctx); err != nil {
+			log.Printf("Failed to replay queued Airtable writes: %v", err)
+		}
+	})
+	return q
+}
+
+// Enqueue adds a write to the queue. apply is called with a fresh context
+// when the queue is flushed. It fails with ErrQueueFull when the queue is full.
+func (q *WriteQueue) Enqueue(description string, apply func(ctx context.Context) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.pending) >= q.size {
+		return ErrQueueFull
+	}
+	q.pending = append(q.pending, queuedWrite{description: description, queuedAt: time.Now(), apply: apply})
+	log.Printf("Queued Airtable write while unavailable: %s (%d pending)", description, len(q.pending))
+	return nil
+}
+
+// Len returns the number of queued writes.
+func (q *WriteQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Flush replays the queued writes in order. A write that fails because
+// Airtable is unavailable again stays queued, together with every write
+// after it; writes Airtable rejects are logged and dropped.
+func (q *WriteQueue) Flush(ctx context.Context) error {
+	q.mu.Lock()
+	if q.flushing {
+		q.mu.Unlock()
+		return nil
+	}
+	q.flushing = true
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		q.flushing = false
+		q.mu.Unlock()
+	}()
+
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return nil
+		}
+		write := q.pending[0]
+		q.mu.Unlock()
+
+		err := write.apply(ctx)
+		if err != nil && (errors.Is(err, ErrUnavailable) || ctx.Err() != nil) {
+			return err
+		}
+
+		q.mu.Lock()
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		if err != nil {
+			log.Printf("Dropping queued Airtable write %q (queued %s ago): %v",
+				write.description, time.Since(write.queuedAt).Round(time.Second), err)
+			continue
+		}
+		log.Printf("Replayed queued Airtable write: %s", write.description)
+	}
+}
@@ -0,0 +1,126 @@
+package airtable_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+// countRequests returns how many requests with the given method the fake
+// server received.
+func countRequests(srv *airtabletest.Server, method string) int {
+	n := 0
+	for _, req := range srv.Requests() {
+		if req.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCreateRecordNotRetriedAfterServerError(t *testing.T) {
+	srv := airtabletest.NewServer()
+	defer srv.Close()
+	client, err := srv.NewClient("appTest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodPost, StatusCode: http.StatusBadGateway, Times: 1})
+	_, err = client.CreateRecord(context.Background(), "Locations", map[string]interface{}{"Name": "Main"})
+	if !errors.Is(err, airtable.ErrUnavailable) {
+		t.Fatalf("CreateRecord error = %v, want ErrUnavailable", err)
+	}
+	if n := countRequests(srv, http.MethodPost); n != 1 {
+		t.Errorf("POST requests = %d, want 1", n)
+	}
+}
+
+func TestCreateRecordRetriedAfterRateLimit(t *testing.T) {
+	srv := airtabletest.NewServer()
+	defer srv.Close()
+	client, err := srv.NewClient("appTest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodPost, StatusCode: http.StatusTooManyRequests, Times: 1})
+	if _, err := client.CreateRecord(context.Background(), "Locations", map[string]interface{}{"Name": "Main"}); err != nil {
+		t.Fatalf("CreateRecord: %v", err)
+	}
+	if n := countRequests(srv, http.MethodPost); n != 2 {
+		t.Errorf("POST requests = %d, want 2", n)
+	}
+	if n := len(srv.Records("Locations")); n != 1 {
+		t.Errorf("records = %d, want 1", n)
+	}
+}
+
+func TestListRecordsRetriedAfterServerError(t *testing.T) {
+	srv := airtabletest.NewServer()
+	defer srv.Close()
+	client, err := srv.NewClient("appTest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.AddRecords("Locations", map[string]interface{}{"Name": "Main"})
+
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodGet, StatusCode: http.StatusServiceUnavailable, Times: 2})
+	records, err := client.ListRecords(context.Background(), "Locations", nil)
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+	if len(records) != 1 {
+		t.Errorf("records = %d, want 1", len(records))
+	}
+	if n := countRequests(srv, http.MethodGet); n != 3 {
+		t.Errorf("GET requests = %d, want 3", n)
+	}
+}
+
+// flakyTransport fails the first request with err, then passes requests on.
+type flakyTransport struct {
+	err      error
+	attempts int
+}
+
+func (t *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.attempts++
+	if t.attempts == 1 {
+		return nil, t.err
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCreateRecordRetriedOnlyWhenNotSent(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		attempts int
+	}{
+		{"dial error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, 2},
+		{"connection dropped", io.ErrUnexpectedEOF, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := airtabletest.NewServer()
+			defer srv.Close()
+			transport := &flakyTransport{err: tt.err}
+			client, err := srv.NewClient("appTest", airtable.WithHTTPClient(&http.Client{Transport: transport}))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client.CreateRecord(context.Background(), "Locations", map[string]interface{}{"Name": "Main"})
+			if transport.attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", transport.attempts, tt.attempts)
+			}
+		})
+	}
+}
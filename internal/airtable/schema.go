@@ -0,0 +1,292 @@
+package airtable
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Airtable field types referenced by the application.
+// See https://airtable.com/developers/web/api/field-model for the full list.
+const (
+	FieldTypeSingleLineText = "singleLineText"
+	FieldTypeMultilineText  = "multilineText"
+	FieldTypeRichText       = "richText"
+	FieldTypeEmail          = "email"
+	FieldTypeNumber         = "number"
+	FieldTypeSingleSelect   = "singleSelect"
+	FieldTypeDate           = "date"
+	FieldTypeDateTime       = "dateTime"
+	FieldTypeAttachments    = "multipleAttachments"
+	FieldTypeRecordLinks    = "multipleRecordLinks"
+)
+
+// TableSchema describes a table as reported by the Airtable Metadata API.
+type TableSchema struct {
+	ID             string        `json:"id"`
+	Name           string        `json:"name"`
+	PrimaryFieldID string        `json:"primaryFieldId"`
+	Fields         []FieldSchema `json:"fields"`
+}
+
+// FieldSchema describes a single field (column) of a table.
+type FieldSchema struct {
+	ID      string                 `json:"id,omitempty"`
+	Name    string                 `json:"name"`
+	Type    string                 `json:"type"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// Field returns the field with the given name or ID.
+func (t TableSchema) Field(name string) (FieldSchema, bool) {
+	for _, f := range t.Fields {
+		if f.Name == name || f.ID != "" && f.ID == name {
+			return f, true
+		}
+	}
+	return FieldSchema{}, false
+}
+
+// GetBaseSchema lists the tables and fields of the client's base using the
+// Metadata API. The API token needs the schema.bases:read scope.
+func (c *Client) GetBaseSchema(ctx context.Context) ([]TableSchema, error) {
+	var resp struct {
+		Tables []TableSchema `json:"tables"`
+	}
+	path := "/meta/bases/" + url.PathEscape(c.baseID) + "/tables"
+	if err := c.do(ctx, OpSchema, "", http.MethodGet, path, nil, nil, &resp); err != nil {
+		return nil, fmt.Errorf("airtable: get base schema failed: %w", err)
+	}
+
+	return resp.Tables, nil
+}
+
+// CreateTable creates a table with the given fields using the Metadata API.
+// The first field becomes the primary field. The API token needs the
+// schema.bases:write scope.
+func (c *Client) CreateTable(ctx context.Context, name string, fields []FieldSchema) (TableSchema, error) {
+	body := struct {
+		Name   string        `json:"name"`
+		Fields []FieldSchema `json:"fields"`
+	}{Name: name, Fields: fields}
+
+	var table TableSchema
+	path := "/meta/bases/" + url.PathEscape(c.baseID) + "/tables"
+	if err := c.do(ctx, OpSchema, "", http.MethodPost, path, nil, body, &table); err != nil {
+		return TableSchema{}, fmt.Errorf("airtable: create table %q failed: %w", name, err)
+	}
+	return table, nil
+}
+
+// CreateField adds a field to the table with the given ID using the Metadata
+// API. The API token needs the schema.bases:write scope.
+func (c *Client) CreateField(ctx context.Context, tableID string, field FieldSchema) (FieldSchema, error) {
+	var created FieldSchema
+	path := "/meta/bases/" + url.PathEscape(c.baseID) + "/tables/" + url.PathEscape(tableID) + "/fields"
+	if err := c.do(ctx, OpSchema, "", http.MethodPost, path, nil, field, &created); err != nil {
+		return FieldSchema{}, fmt.Errorf("airtable: create field %q failed: %w", field.Name, err)
+	}
+	return created, nil
+}
+
+// TableRequirement lists the fields the application reads or writes in a table.
+type TableRequirement struct {
+	Table  string
+	Fields []FieldRequirement
+}
+
+// FieldRequirement names a field and the Airtable field types the code can work with.
+type FieldRequirement struct {
+	Name     string
+	Column   string   // Column name or field ID when it differs from Name
+	Types    []string // Compatible field types, e.g. "singleLineText"; empty accepts any type
+	Optional bool     // A missing optional field is reported but does not fail the check
+
+	// Used by Provisioner when creating a missing field with the first of Types
+	Options     map[string]interface{} // Field options, e.g. SelectOptions for a single select
+	LinkedTable string                 // Table linked to by a multipleRecordLinks field
+}
+
+// SchemaReport is the outcome of verifying the base schema against requirements.
+type SchemaReport struct {
+	CheckedAt time.Time     `json:"checked_at"`
+	OK        bool          `json:"ok"`
+	Error     string        `json:"error,omitempty"` // Set when the schema could not be fetched
+	Tables    []TableReport `json:"tables"`
+}
+
+// TableReport is the verification result for one table.
+type TableReport struct {
+	Name   string        `json:"name"`
+	Found  bool          `json:"found"`
+	Fields []FieldReport `json:"fields"`
+}
+
+// FieldReport is the verification result for one field.
+type FieldReport struct {
+	Name          string   `json:"name"`
+	Column        string   `json:"column,omitempty"` // Set when the field is mapped to another column
+	Found         bool     `json:"found"`
+	Type          string   `json:"type,omitempty"`
+	ExpectedTypes []string `json:"expected_types,omitempty"`
+	Compatible    bool     `json:"compatible"`
+	Optional      bool     `json:"optional,omitempty"`
+}
+
+// Problems describes every failed check in a human-readable form.
+func (r SchemaReport) Problems() []string {
+	var problems []string
+	if r.Error != "" {
+		problems = append(problems, r.Error)
+	}
+	for _, t := range r.Tables {
+		if !t.Found {
+			problems = append(problems, fmt.Sprintf("table %q not found", t.Name))
+			continue
+		}
+		for _, f := range t.Fields {
+			switch {
+			case !f.Found && f.Optional:
+				continue
+			case !f.Found:
+				problems = append(problems, fmt.Sprintf("table %q: field %q not found", t.Name, f.column()))
+			case !f.Compatible:
+				problems = append(problems, fmt.Sprintf("table %q: field %q has type %q, expected one of %v",
+					t.Name, f.column(), f.Type, f.ExpectedTypes))
+			}
+		}
+	}
+	return problems
+}
+
+// column returns the column the field was looked up as.
+func (f FieldReport) column() string {
+	if f.Column != "" {
+		return f.Column
+	}
+	return f.Name
+}
+
+// VerifySchema compares the tables in schema against the requirements.
+func VerifySchema(schema []TableSchema, requirements []TableRequirement) SchemaReport {
+	report := SchemaReport{CheckedAt: time.Now(), OK: true}
+
+	tables := make(map[string]TableSchema, 2*len(schema))
+	for _, t := range schema {
+		tables[t.Name] = t
+		if t.ID != "" {
+			tables[t.ID] = t
+		}
+	}
+
+	for _, req := range requirements {
+		table, found := tables[req.Table]
+		tableReport := TableReport{Name: req.Table, Found: found}
+		if !found {
+			report.OK = false
+		}
+
+		for _, fieldReq := range req.Fields {
+			fieldReport := FieldReport{
+				Name:          fieldReq.Name,
+				Column:        fieldReq.Column,
+				ExpectedTypes: fieldReq.Types,
+				Optional:      fieldReq.Optional,
+			}
+			if field, ok := table.Field(fieldReport.column()); ok {
+				fieldReport.Found = true
+				fieldReport.Type = field.Type
+				fieldReport.Compatible = typeAllowed(field.Type, fieldReq.Types)
+			}
+			if fieldReport.Found && !fieldReport.Compatible || !fieldReport.Found && !fieldReq.Optional {
+				report.OK = false
+			}
+			tableReport.Fields = append(tableReport.Fields, fieldReport)
+		}
+
+		report.Tables = append(report.Tables, tableReport)
+	}
+
+	return report
+}
+
+func typeAllowed(fieldType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, t := range allowed {
+		if t == fieldType {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaChecker verifies the base schema and keeps the latest report so it
+// can be served by a diagnostics endpoint. It is safe for concurrent use.
+type SchemaChecker struct {
+	client       *Client
+	requirements []TableRequirement
+
+	mu     sync.RWMutex
+	report *SchemaReport
+}
+
+// NewSchemaChecker creates a checker for the given requirements.
+func NewSchemaChecker(client *Client, requirements ...TableRequirement) *SchemaChecker {
+	return &SchemaChecker{
+		client:       client,
+		requirements: requirements,
+	}
+}
+
+// Check fetches the base schema, verifies it and stores the report. Fields
+// are looked up by the columns configured with WithTableMappings.
+// The error is non-nil only if the schema could not be fetched.
+func (s *SchemaChecker) Check(ctx context.Context) (SchemaReport, error) {
+	schema, err := s.client.GetBaseSchema(ctx)
+
+	var report SchemaReport
+	if err != nil {
+		report = SchemaReport{CheckedAt: time.Now(), Error: err.Error()}
+	} else {
+		report = VerifySchema(schema, s.client.mapRequirements(s.requirements))
+	}
+
+	s.mu.Lock()
+	s.report = &report
+	s.mu.Unlock()
+
+	return report, err
+}
+
+// mapRequirements returns the requirements with the column of each field
+// filled in from the client's table mappings.
+func (c *Client) mapRequirements(requirements []TableRequirement) []TableRequirement {
+	mapped := make([]TableRequirement, len(requirements))
+	for i, req := range requirements {
+		fields := make([]FieldRequirement, len(req.Fields))
+		for j, field := range req.Fields {
+			if column := c.column(req.Table, field.Name); column != field.Name && field.Column == "" {
+				field.Column = column
+			}
+			fields[j] = field
+		}
+		mapped[i] = TableRequirement{Table: req.Table, Fields: fields}
+	}
+	return mapped
+}
+
+// Report returns the most recent report, or false if Check has not run yet.
+func (s *SchemaChecker) Report() (SchemaReport, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.report == nil {
+		return SchemaReport{}, false
+	}
+	return *s.report, true
+}
@@ -0,0 +1,334 @@
+package airtable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// SyncPolicy configures how a Syncer polls Airtable for changes.
+type SyncPolicy struct {
+	Interval              time.Duration // Time between polls for changed records
+	DeletionCheckInterval time.Duration // Time between listings of every record ID to detect deletions, 0 disables
+	Overlap               time.Duration // How far before the high-water mark each poll looks, to absorb clock skew
+	ModifiedField         string        // Date field set on every edit, such as "Updated At"; empty uses LAST_MODIFIED_TIME()
+}
+
+// DefaultSyncPolicy returns the sync policy used for unset values.
+func DefaultSyncPolicy() SyncPolicy {
+	return SyncPolicy{
+		Interval:              time.Minute,
+		DeletionCheckInterval: 10 * time.Minute,
+		Overlap:               5 * time.Second,
+	}
+}
+
+// Syncer keeps caches in sync with Airtable by polling, for deployments
+// where webhooks cannot reach the API. Each poll lists only the records
+// modified since the table's high-water mark; deletions, which leave no
+// modified record behind, are found by periodically diffing the set of
+// record IDs.
+//
+//	syncer := airtable.NewSyncer(client, airtable.DefaultSyncPolicy())
+//	syncer.Handle("Locations", "Slug", cache.ApplyAirtableChanges)
+//	go syncer.Run(ctx)
+//
+// The first poll of a table lists every record, so handlers also receive the
+// initial contents of the table.
+type Syncer struct {
+	client  *Client
+	policy  SyncPolicy
+	tables  []*syncState
+	started time.Time
+}
+
+// syncState tracks the sync of one table.
+type syncState struct {
+	table    string
+	keyField string
+	handler  ChangeHandler
+
+	syncMu sync.Mutex      // Serializes polls of the table
+	known  map[string]bool // IDs of the records seen so far, guarded by syncMu
+
+	mu                  sync.Mutex
+	cursor              time.Time // High-water mark, zero before the first poll
+	lastPollAt          time.Time
+	lastSuccessAt       time.Time // Start of the last successful poll
+	lastDeletionCheckAt time.Time
+	lastErr             string
+	records             int
+	upserted            int64
+	deleted             int64
+}
+
+// SyncStatus describes the sync of one table.
+type SyncStatus struct {
+	Table               string        `json:"table"`
+	Cursor              *time.Time    `json:"cursor,omitempty"` // High-water mark of the last successful poll
+	LastPollAt          *time.Time    `json:"last_poll_at,omitempty"`
+	LastSuccessAt       *time.Time    `json:"last_success_at,omitempty"`
+	LastDeletionCheckAt *time.Time    `json:"last_deletion_check_at,omitempty"`
+	Lag                 time.Duration `json:"lag_ns" swaggertype:"integer"` // Age of the changes known to be applied
+	Records             int           `json:"records"`                      // Records seen in the table
+	Upserted            int64         `json:"upserted"`                     // Changed records applied since startup
+	Deleted             int64         `json:"deleted"`                      // Deleted records applied since startup
+	LastError           string        `json:"last_error,omitempty"`
+}
+
+// SyncReport describes a Syncer and the tables it polls.
+type SyncReport struct {
+	Interval              time.Duration `json:"interval_ns" swaggertype:"integer"`
+	DeletionCheckInterval time.Duration `json:"deletion_check_interval_ns" swaggertype:"integer"`
+	ModifiedField         string        `json:"modified_field,omitempty"`
+	Tables                []SyncStatus  `json:"tables"`
+}
+
+// NewSyncer creates a syncer. Unset values of policy are taken from
+// DefaultSyncPolicy, except DeletionCheckInterval, where 0 disables
+// deletion checks.
+func NewSyncer(client *Client, policy SyncPolicy) *Syncer {
+	defaults := DefaultSyncPolicy()
+	if policy.Interval <= 0 {
+		policy.Interval = defaults.Interval
+	}
+	if policy.Overlap < 0 {
+		policy.Overlap = 0
+	}
+	return &Syncer{client: client, policy: policy, started: time.Now()}
+}
+
+// Handle sets the handler for changes to a table. keyField names a field
+// every record has, such as the primary field; deletion checks fetch only
+// this field to keep the listing small. Call before Run.
+func (s *Syncer) Handle(table, keyField string, handler ChangeHandler) {
+	s.tables = append(s.tables, &syncState{
+		table:    table,
+		keyField: keyField,
+		handler:  handler,
+		known:    make(map[string]bool),
+	})
+}
+
+// Run polls every handled table until ctx is cancelled, starting immediately.
+func (s *Syncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.SyncAll(ctx); err != nil && !errors.Is(err, ErrCircuitOpen) && ctx.Err() == nil {
+			log.Printf("Failed to sync Airtable changes: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncAll polls every handled table once.
+func (s *Syncer) SyncAll(ctx context.Context) error {
+	var errs []error
+	for _, state := range s.tables {
+		if err := s.sync(ctx, state); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sync applies the records of a table modified since its high-water mark,
+// followed by a deletion check when one is due. The high-water mark only
+// advances once the handler has applied the changes.
+func (s *Syncer) sync(ctx context.Context, state *syncState) (err error) {
+	state.syncMu.Lock()
+	defer state.syncMu.Unlock()
+
+	startedAt := time.Now()
+	state.mu.Lock()
+	cursor := state.cursor
+	checkDeletions := s.policy.DeletionCheckInterval > 0 && !cursor.IsZero() &&
+		startedAt.Sub(state.lastDeletionCheckAt) >= s.policy.DeletionCheckInterval
+	state.lastPollAt = startedAt
+	state.mu.Unlock()
+
+	defer func() {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		if err != nil {
+			state.lastErr = err.Error()
+			return
+		}
+		state.lastErr = ""
+		state.lastSuccessAt = startedAt
+	}()
+
+	params := &ListParams{}
+	if !cursor.IsZero() {
+		modified := LastModifiedTime()
+		if s.policy.ModifiedField != "" {
+			modified = Field(s.policy.ModifiedField)
+		}
+		params.FilterByFormula = IsAfter(modified, cursor.Add(-s.policy.Overlap)).String()
+	}
+	records, err := s.client.ListRecords(ctx, state.table, params)
+	if err != nil {
+		return fmt.Errorf("airtable: sync %s failed: %w", state.table, err)
+	}
+
+	changes := RecordChanges{Table: state.table, Upserted: records}
+	if checkDeletions {
+		missing, deleted, err := s.diffIDs(ctx, state, records)
+		if err != nil {
+			return err
+		}
+		changes.Upserted = append(changes.Upserted, missing...)
+		changes.Deleted = deleted
+	}
+
+	if len(changes.Upserted) > 0 || len(changes.Deleted) > 0 {
+		if err := state.handler(ctx, changes); err != nil {
+			return fmt.Errorf("airtable: apply changes to %s failed: %w", state.table, err)
+		}
+		if !cursor.IsZero() {
+			log.Printf("Synced %d changed and %d deleted records of Airtable table %s",
+				len(changes.Upserted), len(changes.Deleted), state.table)
+		}
+	}
+
+	for _, record := range changes.Upserted {
+		state.known[record.ID] = true
+	}
+	for _, id := range changes.Deleted {
+		delete(state.known, id)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.cursor = s.highWaterMark(cursor, startedAt, records)
+	if cursor.IsZero() || checkDeletions {
+		state.lastDeletionCheckAt = startedAt
+	}
+	state.records = len(state.known)
+	state.upserted += int64(len(changes.Upserted))
+	state.deleted += int64(len(changes.Deleted))
+	return nil
+}
+
+// highWaterMark returns the cursor for the next poll. With a modified field
+// it is the latest value returned, so it follows Airtable's data rather than
+// the local clock; otherwise it is the start of the poll.
+func (s *Syncer) highWaterMark(cursor, startedAt time.Time, records []Record) time.Time {
+	if s.policy.ModifiedField == "" {
+		return startedAt
+	}
+
+	if cursor.IsZero() {
+		// A table whose records all lack the field still needs a mark
+		cursor = startedAt
+	}
+	for _, record := range records {
+		value, ok := record.Fields[s.policy.ModifiedField].(string)
+		if !ok {
+			continue
+		}
+		modified, err := time.Parse(time.RFC3339, value)
+		if err == nil && modified.After(cursor) {
+			cursor = modified
+		}
+	}
+	return cursor
+}
+
+// diffIDs lists the ID of every record in the table and compares them with
+// the records seen so far, including those of the current poll. Records that
+// were never seen, for example because their modified field is empty, are
+// fetched in full.
+func (s *Syncer) diffIDs(ctx context.Context, state *syncState, polled []Record) (missing []Record, deleted []string, err error) {
+	var fields []string
+	if state.keyField != "" {
+		fields = []string{state.keyField}
+	}
+	records, err := s.client.ListRecords(ctx, state.table, &ListParams{Fields: fields})
+	if err != nil {
+		return nil, nil, fmt.Errorf("airtable: list record IDs of %s failed: %w", state.table, err)
+	}
+
+	seen := make(map[string]bool, len(polled))
+	for _, record := range polled {
+		seen[record.ID] = true
+	}
+
+	current := make(map[string]bool, len(records))
+	var unseen []string
+	for _, record := range records {
+		current[record.ID] = true
+		if !state.known[record.ID] && !seen[record.ID] {
+			unseen = append(unseen, record.ID)
+		}
+	}
+	for id := range state.known {
+		if !current[id] {
+			deleted = append(deleted, id)
+		}
+	}
+
+	if len(unseen) > 0 {
+		missing, err = s.client.GetRecords(ctx, state.table, unseen)
+		if err != nil {
+			return nil, nil, fmt.Errorf("airtable: fetch unseen records of %s failed: %w", state.table, err)
+		}
+	}
+	return missing, deleted, nil
+}
+
+// Report describes the syncer and the state of every table.
+func (s *Syncer) Report() SyncReport {
+	report := SyncReport{
+		Interval:              s.policy.Interval,
+		DeletionCheckInterval: s.policy.DeletionCheckInterval,
+		ModifiedField:         s.policy.ModifiedField,
+		Tables:                make([]SyncStatus, 0, len(s.tables)),
+	}
+	for _, state := range s.tables {
+		report.Tables = append(report.Tables, s.status(state))
+	}
+	return report
+}
+
+func (s *Syncer) status(state *syncState) SyncStatus {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	status := SyncStatus{
+		Table:               state.table,
+		Cursor:              timePtr(state.cursor),
+		LastPollAt:          timePtr(state.lastPollAt),
+		LastSuccessAt:       timePtr(state.lastSuccessAt),
+		LastDeletionCheckAt: timePtr(state.lastDeletionCheckAt),
+		Records:             state.records,
+		Upserted:            state.upserted,
+		Deleted:             state.deleted,
+		LastError:           state.lastErr,
+	}
+
+	// Changes made after the last successful poll started may not be applied yet
+	since := state.lastSuccessAt
+	if since.IsZero() {
+		since = s.started
+	}
+	status.Lag = time.Since(since)
+	return status
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
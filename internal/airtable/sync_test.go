@@ -0,0 +1,225 @@
+package airtable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+// changeLog records the changes passed to a ChangeHandler.
+type changeLog struct {
+	changes []airtable.RecordChanges
+	err     error // Returned by the handler when set
+}
+
+func (l *changeLog) handle(_ context.Context, changes airtable.RecordChanges) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.changes = append(l.changes, changes)
+	return nil
+}
+
+// last returns the names of the upserted records and the deleted IDs of the
+// most recent changes, and forgets every change.
+func (l *changeLog) last() (upserted, deleted string) {
+	if len(l.changes) == 0 {
+		return "", ""
+	}
+	changes := l.changes[len(l.changes)-1]
+	l.changes = nil
+
+	sorted := append([]airtable.Record(nil), changes.Upserted...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Fields["Name"].(string) < sorted[j].Fields["Name"].(string) })
+	return names(sorted), strings.Join(changes.Deleted, ",")
+}
+
+// newTestSyncer returns a syncer of the Locations table, keyed by Name.
+func newTestSyncer(t *testing.T, policy airtable.SyncPolicy) (*airtabletest.Server, *airtable.Syncer, *changeLog) {
+	t.Helper()
+	srv, client := newTestClient(t)
+	syncer := airtable.NewSyncer(client, policy)
+	log := &changeLog{}
+	syncer.Handle("Locations", "Name", log.handle)
+	return srv, syncer, log
+}
+
+// mustSync polls every table once.
+func mustSync(t *testing.T, syncer *airtable.Syncer) {
+	t.Helper()
+	if err := syncer.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll: %v", err)
+	}
+}
+
+func TestSyncerPollsChangesSinceHighWaterMark(t *testing.T) {
+	srv, syncer, log := newTestSyncer(t, airtable.SyncPolicy{})
+	ids := addLocations(srv, 3)
+	// Formulas compare whole seconds, so edits in the second of the mark are
+	// polled again
+	time.Sleep(1100 * time.Millisecond)
+
+	// The first poll lists every record
+	mustSync(t, syncer)
+	if got, _ := log.last(); got != "Location 0,Location 1,Location 2" {
+		t.Errorf("first poll = %s, want every location", got)
+	}
+
+	mustSync(t, syncer)
+	if len(log.changes) != 0 {
+		t.Errorf("poll without changes = %+v, want none", log.changes)
+	}
+	reqs := srv.Requests()
+	if formula := reqs[len(reqs)-1].Query.Get("filterByFormula"); !strings.HasPrefix(formula, "IS_AFTER(LAST_MODIFIED_TIME(), ") {
+		t.Errorf("filterByFormula = %q, want records modified since the last poll", formula)
+	}
+
+	srv.UpdateRecord("Locations", ids[1], map[string]interface{}{"Name": "Renamed"})
+	mustSync(t, syncer)
+	if got, _ := log.last(); got != "Renamed" {
+		t.Errorf("poll after an edit = %s, want Renamed", got)
+	}
+
+	status := syncer.Report().Tables[0]
+	if status.Cursor == nil || status.LastError != "" || status.Records != 3 || status.Upserted != 4 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestSyncerOverlapRepeatsRecentChanges(t *testing.T) {
+	for _, tt := range []struct {
+		overlap time.Duration
+		want    string
+	}{
+		{0, ""},
+		{time.Hour, "Earlier,Latest"},
+	} {
+		t.Run(tt.overlap.String(), func(t *testing.T) {
+			srv, syncer, log := newTestSyncer(t, airtable.SyncPolicy{ModifiedField: "Updated At", Overlap: tt.overlap})
+			latest := time.Now().UTC().Truncate(time.Second)
+			srv.AddRecords("Locations",
+				map[string]interface{}{"Name": "Earlier", "Updated At": latest.Add(-30 * time.Minute).Format(time.RFC3339)},
+				map[string]interface{}{"Name": "Latest", "Updated At": latest.Format(time.RFC3339)},
+			)
+			mustSync(t, syncer)
+			log.last()
+
+			// Records modified within the overlap before the mark are polled again
+			mustSync(t, syncer)
+			if got, _ := log.last(); got != tt.want {
+				t.Errorf("second poll = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncerHighWaterMarkFollowsModifiedField(t *testing.T) {
+	srv, syncer, log := newTestSyncer(t, airtable.SyncPolicy{ModifiedField: "Updated At"})
+
+	// Airtable's clock runs an hour ahead of ours
+	ahead := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	srv.AddRecords("Locations",
+		map[string]interface{}{"Name": "Old", "Updated At": ahead.Add(-time.Minute).Format(time.RFC3339)},
+		map[string]interface{}{"Name": "Latest", "Updated At": ahead.Format(time.RFC3339)},
+	)
+	mustSync(t, syncer)
+	log.last()
+
+	if cursor := syncer.Report().Tables[0].Cursor; cursor == nil || !cursor.Equal(ahead) {
+		t.Fatalf("cursor = %v, want the latest Updated At %v", cursor, ahead)
+	}
+
+	// An edit stamped by Airtable's clock is after the mark, although it
+	// is in our future
+	srv.AddRecords("Locations", map[string]interface{}{"Name": "New", "Updated At": ahead.Add(time.Second).Format(time.RFC3339)})
+	mustSync(t, syncer)
+	if got, _ := log.last(); got != "New" {
+		t.Errorf("poll = %s, want only New", got)
+	}
+	reqs := srv.Requests()
+	if formula := reqs[len(reqs)-1].Query.Get("filterByFormula"); !strings.HasPrefix(formula, "IS_AFTER({Updated At}, ") {
+		t.Errorf("filterByFormula = %q, want the modified field", formula)
+	}
+}
+
+func TestSyncerDetectsDeletions(t *testing.T) {
+	srv, syncer, log := newTestSyncer(t, airtable.SyncPolicy{
+		ModifiedField:         "Updated At",
+		DeletionCheckInterval: time.Nanosecond,
+	})
+	ids := addLocations(srv, 3)
+	mustSync(t, syncer)
+	log.last()
+
+	// A record without the modified field is never polled; the deletion
+	// check fetches it instead
+	srv.DeleteRecords("Locations", ids[0], ids[2])
+	srv.AddRecords("Locations", map[string]interface{}{"Name": "Unstamped"})
+	mustSync(t, syncer)
+
+	upserted, deleted := log.last()
+	if upserted != "Unstamped" {
+		t.Errorf("upserted = %s, want Unstamped", upserted)
+	}
+	want := []string{ids[0], ids[2]}
+	sort.Strings(want)
+	got := strings.Split(deleted, ",")
+	sort.Strings(got)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("deleted = %v, want %v", got, want)
+	}
+
+	// The check lists only the key field of each record
+	var listed bool
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodGet && fmt.Sprint(req.Query["fields[]"]) == "[Name]" {
+			listed = true
+		}
+	}
+	if !listed {
+		t.Error("no listing of the Name field")
+	}
+
+	status := syncer.Report().Tables[0]
+	if status.Records != 2 || status.Deleted != 2 || status.LastDeletionCheckAt == nil {
+		t.Errorf("status = %+v", status)
+	}
+
+	// Deleted records are reported once
+	mustSync(t, syncer)
+	if upserted, deleted := log.last(); upserted != "" || deleted != "" {
+		t.Errorf("poll after the deletions = %q, %q, want no changes", upserted, deleted)
+	}
+}
+
+func TestSyncerKeepsMarkWhenHandlerFails(t *testing.T) {
+	srv, syncer, log := newTestSyncer(t, airtable.SyncPolicy{})
+	addLocations(srv, 2)
+
+	log.err = errors.New("cache unavailable")
+	if err := syncer.SyncAll(context.Background()); err == nil {
+		t.Fatal("SyncAll succeeded with a failing handler")
+	}
+	status := syncer.Report().Tables[0]
+	if status.Cursor != nil || status.LastError == "" {
+		t.Errorf("status after a failure = %+v, want no cursor and the error", status)
+	}
+
+	// The next poll delivers the changes again
+	log.err = nil
+	mustSync(t, syncer)
+	if got, _ := log.last(); got != "Location 0,Location 1" {
+		t.Errorf("poll after the failure = %s, want both locations", got)
+	}
+	if status := syncer.Report().Tables[0]; status.LastError != "" {
+		t.Errorf("last error = %q after a successful poll", status.LastError)
+	}
+}
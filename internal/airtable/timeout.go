@@ -0,0 +1,71 @@
+package airtable
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Timeouts bounds how long a single API request may take, including rate
+// limiter waits and retries. A list call applies the timeout to each page and
+// a batch call to each chunk of 10 records. The caller's context deadline
+// still wins when it is shorter. Zero disables the default for that kind of request.
+type Timeouts struct {
+	Read  time.Duration // GET requests
+	Write time.Duration // POST, PUT, PATCH and DELETE requests
+}
+
+// DefaultTimeouts returns the per-request timeouts used when none are configured.
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Read:  10 * time.Second,
+		Write: 20 * time.Second,
+	}
+}
+
+// WithTimeouts sets the default per-request timeouts.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(o *clientOptions) {
+		o.timeouts = timeouts
+	}
+}
+
+// forMethod returns the timeout for a request with the given HTTP method.
+func (t Timeouts) forMethod(method string) time.Duration {
+	if method == http.MethodGet {
+		return t.Read
+	}
+	return t.Write
+}
+
+// withTimeout derives a context bounded by the timeout for method.
+func (t Timeouts) withTimeout(ctx context.Context, method string) (context.Context, context.CancelFunc) {
+	if d := t.forMethod(method); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// NewHTTPClient returns the HTTP client NewClient uses by default. Requests
+// are bounded by contexts rather than http.Client.Timeout, and the transport
+// keeps enough idle connections to api.airtable.com for concurrent batch chunks.
+func NewHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   maxConcurrentChunks * 2,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,210 @@
+package airtable
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookMACHeader is the header carrying the HMAC of a webhook notification body.
+const WebhookMACHeader = "X-Airtable-Content-MAC"
+
+// ErrInvalidWebhookSignature is returned when a notification's MAC does not match.
+var ErrInvalidWebhookSignature = errors.New("airtable: invalid webhook signature")
+
+// Webhook is a webhook registered on the client's base.
+type Webhook struct {
+	ID                      string               `json:"id"`
+	NotificationURL         string               `json:"notificationUrl"`
+	MACSecretBase64         string               `json:"macSecretBase64,omitempty"` // Only returned by CreateWebhook
+	ExpirationTime          time.Time            `json:"expirationTime"`
+	CursorForNextPayload    int                  `json:"cursorForNextPayload,omitempty"`
+	IsHookEnabled           bool                 `json:"isHookEnabled,omitempty"`
+	AreNotificationsEnabled bool                 `json:"areNotificationsEnabled,omitempty"`
+	Specification           WebhookSpecification `json:"specification"`
+}
+
+// WebhookSpecification selects which changes a webhook reports.
+type WebhookSpecification struct {
+	Options WebhookOptions `json:"options"`
+}
+
+// WebhookOptions holds the filters of a webhook specification.
+type WebhookOptions struct {
+	Filters WebhookFilters `json:"filters"`
+}
+
+// WebhookFilters limits a webhook to certain data types and, optionally, a single table.
+type WebhookFilters struct {
+	DataTypes         []string `json:"dataTypes"`                   // e.g. "tableData"
+	RecordChangeScope string   `json:"recordChangeScope,omitempty"` // Table ID (tbl...) or view ID
+}
+
+// WebhookNotification is the body Airtable posts to the notification URL. It
+// carries no change data; fetch the changes with ListWebhookPayloads.
+type WebhookNotification struct {
+	Base struct {
+		ID string `json:"id"`
+	} `json:"base"`
+	Webhook struct {
+		ID string `json:"id"`
+	} `json:"webhook"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// WebhookPayloads is a page of webhook payloads.
+type WebhookPayloads struct {
+	Cursor        int              `json:"cursor"` // Pass to the next ListWebhookPayloads call
+	MightHaveMore bool             `json:"mightHaveMore"`
+	Payloads      []WebhookPayload `json:"payloads"`
+}
+
+// WebhookPayload describes the changes made in one base transaction.
+type WebhookPayload struct {
+	Timestamp             time.Time                      `json:"timestamp"`
+	BaseTransactionNumber int                            `json:"baseTransactionNumber"`
+	ChangedTablesByID     map[string]WebhookTableChanges `json:"changedTablesById"`
+}
+
+// WebhookTableChanges lists the records of one table changed by a transaction.
+// Cell values are keyed by field ID, not name.
+type WebhookTableChanges struct {
+	CreatedRecordsByID map[string]WebhookRecord       `json:"createdRecordsById"`
+	ChangedRecordsByID map[string]WebhookRecordChange `json:"changedRecordsById"`
+	DestroyedRecordIDs []string                       `json:"destroyedRecordIds"`
+}
+
+// WebhookRecord holds the cell values reported for a record.
+type WebhookRecord struct {
+	CreatedTime         string                 `json:"createdTime,omitempty"`
+	CellValuesByFieldID map[string]interface{} `json:"cellValuesByFieldId"`
+}
+
+// WebhookRecordChange holds the changed cells of a record before and after the change.
+type WebhookRecordChange struct {
+	Current  WebhookRecord  `json:"current"`
+	Previous *WebhookRecord `json:"previous,omitempty"`
+}
+
+// CreateWebhook registers a webhook that notifies notificationURL about
+// changes matching filters. The returned MACSecretBase64 is needed to verify
+// notifications and cannot be retrieved later. The token needs the
+// webhook:manage scope.
+func (c *Client) CreateWebhook(ctx context.Context, notificationURL string, filters WebhookFilters) (Webhook, error) {
+	body := map[string]interface{}{
+		"notificationUrl": notificationURL,
+		"specification":   WebhookSpecification{Options: WebhookOptions{Filters: filters}},
+	}
+
+	var webhook Webhook
+	if err := c.do(ctx, OpWebhook, "", http.MethodPost, c.webhooksPath(), nil, body, &webhook); err != nil {
+		return Webhook{}, fmt.Errorf("airtable: create webhook failed: %w", err)
+	}
+	webhook.NotificationURL = notificationURL
+	webhook.Specification.Options.Filters = filters
+
+	return webhook, nil
+}
+
+// ListWebhooks lists the webhooks registered on the client's base.
+func (c *Client) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	var resp struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+	if err := c.do(ctx, OpWebhook, "", http.MethodGet, c.webhooksPath(), nil, nil, &resp); err != nil {
+		return nil, fmt.Errorf("airtable: list webhooks failed: %w", err)
+	}
+
+	return resp.Webhooks, nil
+}
+
+// DeleteWebhook removes a webhook.
+func (c *Client) DeleteWebhook(ctx context.Context, webhookID string) error {
+	if err := c.do(ctx, OpWebhook, "", http.MethodDelete, c.webhookPath(webhookID), nil, nil, nil); err != nil {
+		return fmt.Errorf("airtable: delete webhook failed: %w", err)
+	}
+
+	return nil
+}
+
+// RefreshWebhook extends a webhook's life. Webhooks expire 7 days after they
+// are created or last refreshed.
+func (c *Client) RefreshWebhook(ctx context.Context, webhookID string) (time.Time, error) {
+	var resp struct {
+		ExpirationTime time.Time `json:"expirationTime"`
+	}
+	if err := c.do(ctx, OpWebhook, "", http.MethodPost, c.webhookPath(webhookID)+"/refresh", nil, nil, &resp); err != nil {
+		return time.Time{}, fmt.Errorf("airtable: refresh webhook failed: %w", err)
+	}
+
+	return resp.ExpirationTime, nil
+}
+
+// ListWebhookPayloads returns the payloads of a webhook starting at cursor.
+// Cursors start at 1; keep calling with the returned Cursor while MightHaveMore is set.
+func (c *Client) ListWebhookPayloads(ctx context.Context, webhookID string, cursor int) (WebhookPayloads, error) {
+	query := url.Values{}
+	if cursor > 0 {
+		query.Set("cursor", strconv.Itoa(cursor))
+	}
+
+	var page WebhookPayloads
+	if err := c.do(ctx, OpWebhook, "", http.MethodGet, c.webhookPath(webhookID)+"/payloads", query, nil, &page); err != nil {
+		return WebhookPayloads{}, fmt.Errorf("airtable: list webhook payloads failed: %w", err)
+	}
+
+	return page, nil
+}
+
+func (c *Client) webhooksPath() string {
+	return "/bases/" + url.PathEscape(c.baseID) + "/webhooks"
+}
+
+func (c *Client) webhookPath(webhookID string) string {
+	return c.webhooksPath() + "/" + url.PathEscape(webhookID)
+}
+
+// VerifyWebhookMAC checks a notification body against the value of the
+// X-Airtable-Content-MAC header ("hmac-sha256=<hex>") using the webhook's
+// base64-encoded MAC secret.
+func VerifyWebhookMAC(macSecretBase64 string, body []byte, header string) error {
+	secret, err := base64.StdEncoding.DecodeString(macSecretBase64)
+	if err != nil {
+		return fmt.Errorf("airtable: invalid webhook MAC secret: %w", err)
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(header, "hmac-sha256="))
+	if err != nil || !strings.HasPrefix(header, "hmac-sha256=") {
+		return ErrInvalidWebhookSignature
+	}
+
+	if !hmac.Equal(got, webhookMAC(secret, body)) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
+
+// SignWebhookBody returns the X-Airtable-Content-MAC header value for body,
+// as Airtable computes it. It is useful for fakes and tests.
+func SignWebhookBody(macSecretBase64 string, body []byte) (string, error) {
+	secret, err := base64.StdEncoding.DecodeString(macSecretBase64)
+	if err != nil {
+		return "", fmt.Errorf("airtable: invalid webhook MAC secret: %w", err)
+	}
+	return "hmac-sha256=" + hex.EncodeToString(webhookMAC(secret, body)), nil
+}
+
+func webhookMAC(secret, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
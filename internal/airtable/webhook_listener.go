@@ -0,0 +1,414 @@
+package airtable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// webhookRefreshInterval is how often Run extends registered webhooks, well
+// within Airtable's 7-day expiry.
+const webhookRefreshInterval = 24 * time.Hour
+
+// webhookInstanceParam is the query parameter naming the instance that
+// registered a webhook, see SetInstance.
+const webhookInstanceParam = "instance"
+
+// RecordChanges lists the records of one table that changed in Airtable.
+type RecordChanges struct {
+	Table    string
+	Upserted []Record // Created or updated records with all their current fields
+	Deleted  []string // IDs of destroyed records
+}
+
+// ChangeHandler applies changes made in Airtable, for example to a cache.
+// Returning an error leaves the webhook cursor in place so the same changes
+// are delivered again on the next notification.
+type ChangeHandler func(ctx context.Context, changes RecordChanges) error
+
+// WebhookListener registers one Airtable webhook per table and turns the
+// notifications it receives into RecordChanges for the table's handler.
+//
+//	listener := airtable.NewWebhookListener(client, "https://api.example.com/api/webhooks/airtable")
+//	listener.Handle("Locations", cache.ApplyAirtableChanges)
+//	err := listener.Register(ctx)
+//
+// The HTTP endpoint at the notification URL passes each request to Verify and
+// then calls Sync with the returned webhook ID.
+//
+// Webhooks are told apart by their notification URL: Register replaces every
+// webhook registered with the listener's URL, including those left over from
+// earlier runs. Replicas sharing a base and endpoint must each call
+// SetInstance with a name that is stable across restarts, or they replace
+// each other's webhooks.
+type WebhookListener struct {
+	client          *Client
+	notificationURL string                   // Without the instance parameter
+	instance        string                   // Set by SetInstance
+	handlers        map[string]ChangeHandler // Keyed by table name
+
+	mu    sync.RWMutex
+	hooks map[string]*webhookState // Keyed by webhook ID
+}
+
+// webhookState tracks a registered webhook.
+type webhookState struct {
+	table     string
+	tableID   string
+	secret    string // Base64 MAC secret
+	expiresAt time.Time
+
+	syncMu sync.Mutex // Serializes Sync calls so payloads are applied in order
+	cursor int        // Written under both syncMu and the listener's mu
+}
+
+// WebhookStatus describes a registered webhook for diagnostics.
+type WebhookStatus struct {
+	ID        string    `json:"id"`
+	Table     string    `json:"table"`
+	Cursor    int       `json:"cursor"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewWebhookListener creates a listener whose webhooks notify notificationURL.
+func NewWebhookListener(client *Client, notificationURL string) *WebhookListener {
+	return &WebhookListener{
+		client:          client,
+		notificationURL: notificationURL,
+		handlers:        make(map[string]ChangeHandler),
+		hooks:           make(map[string]*webhookState),
+	}
+}
+
+// Handle sets the handler for changes to a table. Call before Register.
+func (l *WebhookListener) Handle(table string, handler ChangeHandler) {
+	l.handlers[table] = handler
+}
+
+// SetInstance names the replica the listener runs in. The name is added to
+// the notification URL as the instance query parameter, so each replica
+// recognizes the webhooks it registered in earlier runs and leaves the
+// others alone. Call before Register.
+func (l *WebhookListener) SetInstance(name string) {
+	l.instance = name
+}
+
+// Register creates a webhook for every handled table. Webhooks registered
+// with the listener's notification URL before, by this or an earlier run, are
+// deleted first, since their MAC secrets are only revealed at creation.
+// Expired webhooks of other instances are deleted too.
+func (l *WebhookListener) Register(ctx context.Context) error {
+	schema, err := l.client.GetBaseSchema(ctx)
+	if err != nil {
+		return fmt.Errorf("airtable: register webhooks failed: %w", err)
+	}
+	tableIDs := make(map[string]string, 2*len(schema))
+	for _, t := range schema {
+		tableIDs[t.Name] = t.ID
+		tableIDs[t.ID] = t.ID
+	}
+
+	if err := l.deleteWebhooks(ctx, true); err != nil {
+		return err
+	}
+
+	hooks := make(map[string]*webhookState, len(l.handlers))
+	for table := range l.handlers {
+		tableID, ok := tableIDs[table]
+		if !ok {
+			return fmt.Errorf("airtable: register webhooks failed: table %q not found", table)
+		}
+
+		webhook, err := l.client.CreateWebhook(ctx, l.webhookURL(), WebhookFilters{
+			DataTypes:         []string{"tableData"},
+			RecordChangeScope: tableID,
+		})
+		if err != nil {
+			return err
+		}
+		hooks[webhook.ID] = &webhookState{
+			table:     table,
+			tableID:   tableID,
+			secret:    webhook.MACSecretBase64,
+			expiresAt: webhook.ExpirationTime,
+			cursor:    1,
+		}
+		log.Printf("Registered Airtable webhook %s for table %s", webhook.ID, table)
+	}
+
+	l.mu.Lock()
+	l.hooks = hooks
+	l.mu.Unlock()
+
+	return nil
+}
+
+// Unregister deletes the webhooks created by Register.
+func (l *WebhookListener) Unregister(ctx context.Context) error {
+	return l.deleteWebhooks(ctx, false)
+}
+
+// deleteWebhooks removes the webhooks registered by this listener. With
+// previous set, it also removes the webhooks of earlier runs, which have the
+// same notification URL, and expired webhooks of other instances.
+func (l *WebhookListener) deleteWebhooks(ctx context.Context, previous bool) error {
+	webhooks, err := l.client.ListWebhooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	own := normalizeWebhookURL(l.webhookURL(), false)
+	shared := normalizeWebhookURL(l.notificationURL, true)
+	now := time.Now()
+	for _, webhook := range webhooks {
+		l.mu.RLock()
+		_, registered := l.hooks[webhook.ID]
+		l.mu.RUnlock()
+
+		var stale bool
+		if previous {
+			expired := !webhook.ExpirationTime.IsZero() && webhook.ExpirationTime.Before(now)
+			stale = normalizeWebhookURL(webhook.NotificationURL, false) == own ||
+				expired && normalizeWebhookURL(webhook.NotificationURL, true) == shared
+		}
+		if !registered && !stale {
+			continue
+		}
+		if err := l.client.DeleteWebhook(ctx, webhook.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	l.hooks = make(map[string]*webhookState)
+	l.mu.Unlock()
+	return nil
+}
+
+// webhookURL returns the notification URL of the listener's webhooks.
+func (l *WebhookListener) webhookURL() string {
+	if l.instance == "" {
+		return l.notificationURL
+	}
+	u, err := url.Parse(l.notificationURL)
+	if err != nil {
+		return l.notificationURL
+	}
+	query := u.Query()
+	query.Set(webhookInstanceParam, l.instance)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// normalizeWebhookURL returns rawURL with its query parameters in a fixed
+// order, for comparing notification URLs. With dropInstance set, the
+// instance parameter is removed.
+func normalizeWebhookURL(rawURL string, dropInstance bool) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	if dropInstance {
+		query.Del(webhookInstanceParam)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// Verify checks the MAC of a notification body and returns the webhook ID it
+// refers to. It returns ErrInvalidWebhookSignature for bad or missing MACs
+// and ErrNotFound for webhooks this listener did not register.
+func (l *WebhookListener) Verify(body []byte, macHeader string) (string, error) {
+	var notification WebhookNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return "", ErrInvalidWebhookSignature
+	}
+
+	l.mu.RLock()
+	hook, ok := l.hooks[notification.Webhook.ID]
+	l.mu.RUnlock()
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	if err := VerifyWebhookMAC(hook.secret, body, macHeader); err != nil {
+		return "", err
+	}
+	return notification.Webhook.ID, nil
+}
+
+// Sync fetches the payloads of a webhook since the last successful sync and
+// passes the resulting changes to the table's handler.
+func (l *WebhookListener) Sync(ctx context.Context, webhookID string) error {
+	l.mu.RLock()
+	hook, ok := l.hooks[webhookID]
+	l.mu.RUnlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	hook.syncMu.Lock()
+	defer hook.syncMu.Unlock()
+
+	// Collapse the payloads into the latest state of each record
+	deleted := make(map[string]bool)
+	var order []string
+	cursor := hook.cursor
+	for {
+		page, err := l.client.ListWebhookPayloads(ctx, webhookID, cursor)
+		if err != nil {
+			return err
+		}
+		for _, payload := range page.Payloads {
+			changes, ok := payload.ChangedTablesByID[hook.tableID]
+			if !ok {
+				continue
+			}
+			for id := range changes.CreatedRecordsByID {
+				order = appendOnce(order, deleted, id)
+				deleted[id] = false
+			}
+			for id := range changes.ChangedRecordsByID {
+				order = appendOnce(order, deleted, id)
+				deleted[id] = false
+			}
+			for _, id := range changes.DestroyedRecordIDs {
+				order = appendOnce(order, deleted, id)
+				deleted[id] = true
+			}
+		}
+		cursor = page.Cursor
+		if !page.MightHaveMore {
+			break
+		}
+	}
+
+	if len(order) > 0 {
+		changes, err := l.resolve(ctx, hook.table, order, deleted)
+		if err != nil {
+			return err
+		}
+		if err := l.handlers[hook.table](ctx, changes); err != nil {
+			return fmt.Errorf("airtable: apply webhook changes for %s failed: %w", hook.table, err)
+		}
+		log.Printf("Applied Airtable changes to %s: %d upserted, %d deleted",
+			hook.table, len(changes.Upserted), len(changes.Deleted))
+	}
+
+	l.mu.Lock()
+	hook.cursor = cursor
+	l.mu.Unlock()
+	return nil
+}
+
+func appendOnce(order []string, seen map[string]bool, id string) []string {
+	if _, ok := seen[id]; ok {
+		return order
+	}
+	return append(order, id)
+}
+
+// resolve fetches the current fields of created and changed records, since
+// payloads only carry changed cells keyed by field ID.
+func (l *WebhookListener) resolve(ctx context.Context, table string, ids []string, deleted map[string]bool) (RecordChanges, error) {
+	changes := RecordChanges{Table: table}
+	for _, id := range ids {
+		if deleted[id] {
+			changes.Deleted = append(changes.Deleted, id)
+			continue
+		}
+
+		record, err := l.client.GetRecord(ctx, table, id)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			// Destroyed after the payloads were read
+			changes.Deleted = append(changes.Deleted, id)
+		case err != nil:
+			return RecordChanges{}, err
+		default:
+			changes.Upserted = append(changes.Upserted, record)
+		}
+	}
+	return changes, nil
+}
+
+// SyncAll syncs every registered webhook, catching up on changes whose
+// notifications were missed.
+func (l *WebhookListener) SyncAll(ctx context.Context) error {
+	var errs []error
+	for _, id := range l.webhookIDs() {
+		if err := l.Sync(ctx, id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Refresh extends the expiry of every registered webhook.
+func (l *WebhookListener) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, id := range l.webhookIDs() {
+		expiresAt, err := l.client.RefreshWebhook(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		l.mu.Lock()
+		if hook, ok := l.hooks[id]; ok {
+			hook.expiresAt = expiresAt
+		}
+		l.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// Run refreshes the webhooks once a day and catches up on missed changes
+// until ctx is cancelled.
+func (l *WebhookListener) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.Refresh(ctx); err != nil {
+				log.Printf("Failed to refresh Airtable webhooks: %v", err)
+			}
+			if err := l.SyncAll(ctx); err != nil {
+				log.Printf("Failed to sync Airtable webhooks: %v", err)
+			}
+		}
+	}
+}
+
+// Status describes the registered webhooks.
+func (l *WebhookListener) Status() []WebhookStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	statuses := make([]WebhookStatus, 0, len(l.hooks))
+	for id, hook := range l.hooks {
+		statuses = append(statuses, WebhookStatus{ID: id, Table: hook.table, Cursor: hook.cursor, ExpiresAt: hook.expiresAt})
+	}
+	return statuses
+}
+
+func (l *WebhookListener) webhookIDs() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	ids := make([]string, 0, len(l.hooks))
+	for id := range l.hooks {
+		ids = append(ids, id)
+	}
+	return ids
+}
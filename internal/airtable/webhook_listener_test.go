@@ -0,0 +1,334 @@
+package airtable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+	"lam-phuong-api/internal/location"
+	"lam-phuong-api/internal/publicid"
+	"lam-phuong-api/internal/user"
+)
+
+// notification is a webhook notification received from the fake server.
+type notification struct {
+	body []byte
+	mac  string
+}
+
+// receiveNotifications starts a server for webhook notifications and returns
+// its URL and a channel with the notifications it receives.
+func receiveNotifications(t *testing.T) (string, <-chan notification) {
+	t.Helper()
+	notifications := make(chan notification, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		select {
+		case notifications <- notification{body: body, mac: r.Header.Get(airtable.WebhookMACHeader)}:
+		default:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL + "/api/webhooks/airtable", notifications
+}
+
+// recordingHandler is a ChangeHandler that keeps the changes it is given and
+// fails with err while err is set.
+type recordingHandler struct {
+	changes []airtable.RecordChanges
+	err     error
+}
+
+func (h *recordingHandler) handle(ctx context.Context, changes airtable.RecordChanges) error {
+	if h.err != nil {
+		return h.err
+	}
+	h.changes = append(h.changes, changes)
+	return nil
+}
+
+// newTestListener registers a listener for the Locations table of a fake
+// server and returns the server, the listener and its webhook ID.
+func newTestListener(t *testing.T, handler airtable.ChangeHandler) (*airtabletest.Server, *airtable.WebhookListener, string) {
+	t.Helper()
+	srv, client := newTestClient(t)
+	srv.DefineTable("Locations", airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeSingleLineText})
+
+	url, _ := receiveNotifications(t)
+	listener := airtable.NewWebhookListener(client, url)
+	listener.Handle("Locations", handler)
+	if err := listener.Register(context.Background()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	status := listener.Status()
+	if len(status) != 1 {
+		t.Fatalf("registered webhooks = %d, want 1", len(status))
+	}
+	return srv, listener, status[0].ID
+}
+
+func TestWebhookListenerVerify(t *testing.T) {
+	srv, client := newTestClient(t)
+	srv.DefineTable("Locations", airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeSingleLineText})
+	url, notifications := receiveNotifications(t)
+	listener := airtable.NewWebhookListener(client, url)
+	listener.Handle("Locations", func(context.Context, airtable.RecordChanges) error { return nil })
+	if err := listener.Register(context.Background()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	srv.AddRecords("Locations", map[string]interface{}{"Name": "Main"})
+	var n notification
+	select {
+	case n = <-notifications:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+	}
+
+	id, err := listener.Verify(n.body, n.mac)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if status := listener.Status(); id != status[0].ID {
+		t.Errorf("Verify = %s, want %s", id, status[0].ID)
+	}
+
+	tampered := append([]byte{}, n.body...)
+	tampered[len(tampered)-2] ^= 1
+	if _, err := listener.Verify(tampered, n.mac); !errors.Is(err, airtable.ErrInvalidWebhookSignature) {
+		t.Errorf("Verify of a tampered body error = %v, want ErrInvalidWebhookSignature", err)
+	}
+	other := []byte(`{"base":{"id":"appTest"},"webhook":{"id":"achUnknown"}}`)
+	if _, err := listener.Verify(other, n.mac); !errors.Is(err, airtable.ErrNotFound) {
+		t.Errorf("Verify of an unknown webhook error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestWebhookListenerSyncCollapsesPayloads(t *testing.T) {
+	handler := &recordingHandler{}
+	srv, listener, webhookID := newTestListener(t, handler.handle)
+
+	records := srv.AddRecords("Locations",
+		map[string]interface{}{"Name": "Main"},
+		map[string]interface{}{"Name": "Temporary"},
+	)
+	kept, removed := records[0].ID, records[1].ID
+	srv.UpdateRecord("Locations", kept, map[string]interface{}{"Name": "Main 1"})
+	srv.UpdateRecord("Locations", kept, map[string]interface{}{"Name": "Main 2"})
+	srv.UpdateRecord("Locations", removed, map[string]interface{}{"Name": "Temporary 1"})
+	srv.DeleteRecords("Locations", removed)
+
+	if err := listener.Sync(context.Background(), webhookID); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(handler.changes) != 1 {
+		t.Fatalf("handler calls = %d, want 1", len(handler.changes))
+	}
+	changes := handler.changes[0]
+	if len(changes.Upserted) != 1 || changes.Upserted[0].ID != kept || changes.Upserted[0].Fields["Name"] != "Main 2" {
+		t.Errorf("upserted = %+v, want only %s with its latest name", changes.Upserted, kept)
+	}
+	if len(changes.Deleted) != 1 || changes.Deleted[0] != removed {
+		t.Errorf("deleted = %v, want [%s]", changes.Deleted, removed)
+	}
+}
+
+func TestWebhookListenerSyncMovesCursor(t *testing.T) {
+	ctx := context.Background()
+	handler := &recordingHandler{}
+	srv, listener, webhookID := newTestListener(t, handler.handle)
+	cursor := func() int { return listener.Status()[0].Cursor }
+
+	// Nothing changed yet
+	if err := listener.Sync(ctx, webhookID); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(handler.changes) != 0 || cursor() != 1 {
+		t.Fatalf("after an empty sync: handler calls = %d, cursor = %d; want 0 and 1", len(handler.changes), cursor())
+	}
+
+	// More payloads than fit in one page
+	id := srv.AddRecords("Locations", map[string]interface{}{"Name": "Main"})[0].ID
+	for i := 1; i < 60; i++ {
+		srv.UpdateRecord("Locations", id, map[string]interface{}{"Name": fmt.Sprintf("Main %d", i)})
+	}
+	if err := listener.Sync(ctx, webhookID); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(handler.changes) != 1 || cursor() != 61 {
+		t.Fatalf("after 60 payloads: handler calls = %d, cursor = %d; want 1 and 61", len(handler.changes), cursor())
+	}
+
+	// A failed handler leaves the cursor, so the changes are delivered again
+	srv.UpdateRecord("Locations", id, map[string]interface{}{"Name": "Main"})
+	handler.err = errors.New("cache unavailable")
+	if err := listener.Sync(ctx, webhookID); err == nil {
+		t.Fatal("Sync succeeded with a failing handler")
+	}
+	if cursor() != 61 {
+		t.Errorf("cursor after a failed sync = %d, want 61", cursor())
+	}
+
+	handler.err = nil
+	if err := listener.Sync(ctx, webhookID); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(handler.changes) != 2 || cursor() != 62 {
+		t.Fatalf("after a retried sync: handler calls = %d, cursor = %d; want 2 and 62", len(handler.changes), cursor())
+	}
+	if upserted := handler.changes[1].Upserted; len(upserted) != 1 || upserted[0].Fields["Name"] != "Main" {
+		t.Errorf("upserted = %+v, want only the last change", upserted)
+	}
+}
+
+// registerListener registers a listener for the Locations table with the
+// given instance name, as a starting replica would.
+func registerListener(t *testing.T, client *airtable.Client, url, instance string) *airtable.WebhookListener {
+	t.Helper()
+	listener := airtable.NewWebhookListener(client, url)
+	listener.SetInstance(instance)
+	listener.Handle("Locations", func(context.Context, airtable.RecordChanges) error { return nil })
+	if err := listener.Register(context.Background()); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return listener
+}
+
+func TestWebhookListenerRegisterReplacesEarlierRuns(t *testing.T) {
+	srv, client := newTestClient(t)
+	srv.DefineTable("Locations", airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeSingleLineText})
+	url, _ := receiveNotifications(t)
+
+	// Each listener stands for the process after a restart, which starts
+	// without knowing the webhooks it registered before
+	var last *airtable.WebhookListener
+	for i := 0; i < 3; i++ {
+		last = registerListener(t, client, url, "")
+		if n := len(srv.Webhooks()); n != 1 {
+			t.Fatalf("webhooks after start %d = %d, want 1", i+1, n)
+		}
+	}
+	if id := srv.Webhooks()[0].ID; id != last.Status()[0].ID {
+		t.Errorf("webhook = %s, want the last run's %s", id, last.Status()[0].ID)
+	}
+}
+
+func TestWebhookListenerRegisterKeepsOtherInstances(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+	srv.DefineTable("Locations", airtable.FieldSchema{Name: "Name", Type: airtable.FieldTypeSingleLineText})
+	url, _ := receiveNotifications(t)
+
+	// Two replicas share the endpoint, and the first one restarts
+	registerListener(t, client, url, "replica-1")
+	second := registerListener(t, client, url, "replica-2")
+	secondID := second.Status()[0].ID
+	first := registerListener(t, client, url, "replica-1")
+
+	webhooks := srv.Webhooks()
+	if len(webhooks) != 2 {
+		t.Fatalf("webhooks = %d, want 2", len(webhooks))
+	}
+	ids := map[string]bool{webhooks[0].ID: true, webhooks[1].ID: true}
+	if !ids[secondID] || !ids[first.Status()[0].ID] {
+		t.Errorf("webhooks = %v, want %s and the restarted replica's new one", ids, secondID)
+	}
+
+	if err := first.Unregister(ctx); err != nil {
+		t.Fatalf("Unregister: %v", err)
+	}
+	if webhooks := srv.Webhooks(); len(webhooks) != 1 || webhooks[0].ID != secondID {
+		t.Errorf("webhooks after Unregister = %v, want only %s", webhooks, secondID)
+	}
+}
+
+func TestWebhookListenerUpdatesCaches(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+	srv.DefineTable("Locations", airtable.FieldSchema{Name: location.FieldName, Type: airtable.FieldTypeSingleLineText})
+	srv.DefineTable("Users", airtable.FieldSchema{Name: user.FieldEmail, Type: airtable.FieldTypeEmail})
+	locationIDs := []string{publicid.New(), publicid.New()}
+	locations := srv.AddRecords("Locations",
+		map[string]interface{}{location.FieldPublicID: locationIDs[0], location.FieldName: "Main", location.FieldSlug: "main"},
+		map[string]interface{}{location.FieldPublicID: locationIDs[1], location.FieldName: "Branch", location.FieldSlug: "branch"},
+	)
+	userIDs := []string{publicid.New(), publicid.New()}
+	users := srv.AddRecords("Users",
+		map[string]interface{}{user.FieldPublicID: userIDs[0], user.FieldEmail: "a@example.com", user.FieldRole: user.RoleAdmin},
+		map[string]interface{}{user.FieldPublicID: userIDs[1], user.FieldEmail: "b@example.com"},
+	)
+
+	locationCache := location.NewInMemoryRepository(nil)
+	userCache := user.NewInMemoryRepository(nil)
+	url, _ := receiveNotifications(t)
+	listener := airtable.NewWebhookListener(client, url)
+	listener.Handle("Locations", locationCache.ApplyAirtableChanges)
+	listener.Handle("Users", userCache.ApplyAirtableChanges)
+	if err := listener.Register(ctx); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// Records created after registering are added to the caches
+	extraLocation := srv.AddRecords("Locations",
+		map[string]interface{}{location.FieldPublicID: publicid.New(), location.FieldName: "New", location.FieldSlug: "new"})[0]
+	extraUser := srv.AddRecords("Users",
+		map[string]interface{}{user.FieldPublicID: publicid.New(), user.FieldEmail: "c@example.com"})[0]
+	if err := listener.SyncAll(ctx); err != nil {
+		t.Fatalf("SyncAll: %v", err)
+	}
+	if _, err := locationCache.GetBySlug(ctx, "new"); err != nil {
+		t.Errorf("created location not cached: %v", err)
+	}
+	if _, err := userCache.GetByEmail(ctx, "c@example.com"); err != nil {
+		t.Errorf("created user not cached: %v", err)
+	}
+
+	// Seed the caches with the records created before registering
+	for _, changes := range []struct {
+		apply   airtable.ChangeHandler
+		table   string
+		records []airtable.Record
+	}{
+		{locationCache.ApplyAirtableChanges, "Locations", locations},
+		{userCache.ApplyAirtableChanges, "Users", users},
+	} {
+		if err := changes.apply(ctx, airtable.RecordChanges{Table: changes.table, Upserted: changes.records}); err != nil {
+			t.Fatalf("ApplyAirtableChanges: %v", err)
+		}
+	}
+
+	srv.UpdateRecord("Locations", locations[0].ID, map[string]interface{}{location.FieldName: "Main Street", location.FieldSlug: "main-street"})
+	srv.DeleteRecords("Locations", locations[1].ID, extraLocation.ID)
+	srv.UpdateRecord("Users", users[0].ID, map[string]interface{}{user.FieldRole: user.RoleSuperAdmin})
+	srv.DeleteRecords("Users", users[1].ID, extraUser.ID)
+	if err := listener.SyncAll(ctx); err != nil {
+		t.Fatalf("SyncAll: %v", err)
+	}
+
+	if got, err := locationCache.Get(ctx, locationIDs[0]); err != nil || got.Name != "Main Street" || got.Slug != "main-street" {
+		t.Errorf("updated location = %+v, %v; want the new name and slug", got, err)
+	}
+	if list := locationCache.List(ctx); len(list) != 1 {
+		t.Errorf("cached locations = %d, want 1", len(list))
+	}
+	if _, err := locationCache.Get(ctx, locationIDs[1]); !errors.Is(err, location.ErrNotFound) {
+		t.Errorf("deleted location error = %v, want ErrNotFound", err)
+	}
+
+	if got, err := userCache.Get(ctx, userIDs[0]); err != nil || got.Role != user.RoleSuperAdmin {
+		t.Errorf("updated user = %+v, %v; want role %s", got, err, user.RoleSuperAdmin)
+	}
+	if list := userCache.List(ctx); len(list) != 1 {
+		t.Errorf("cached users = %d, want 1", len(list))
+	}
+	if _, err := userCache.Get(ctx, userIDs[1]); !errors.Is(err, user.ErrNotFound) {
+		t.Errorf("deleted user error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,41 @@
+package airtable_test
+
+import (
+	"errors"
+	"testing"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+func TestVerifyWebhookMAC(t *testing.T) {
+	const secret = "c2VjcmV0LWtleS1mb3ItdGVzdHM=" // "secret-key-for-tests"
+	body := []byte(`{"base":{"id":"appTest"},"webhook":{"id":"achTest"}}`)
+	mac, err := airtable.SignWebhookBody(secret, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		header string
+		err    error
+	}{
+		{"valid", secret, string(body), mac, nil},
+		{"tampered body", secret, `{"base":{"id":"appTest"},"webhook":{"id":"achOther"}}`, mac, airtable.ErrInvalidWebhookSignature},
+		{"tampered MAC", secret, string(body), mac[:len(mac)-2] + "00", airtable.ErrInvalidWebhookSignature},
+		{"other secret", "b3RoZXItc2VjcmV0", string(body), mac, airtable.ErrInvalidWebhookSignature},
+		{"missing prefix", secret, string(body), mac[len("hmac-sha256="):], airtable.ErrInvalidWebhookSignature},
+		{"not hex", secret, string(body), "hmac-sha256=not-hex", airtable.ErrInvalidWebhookSignature},
+		{"missing header", secret, string(body), "", airtable.ErrInvalidWebhookSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := airtable.VerifyWebhookMAC(tt.secret, []byte(tt.body), tt.header)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("VerifyWebhookMAC error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
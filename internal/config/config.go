@@ -3,9 +3,12 @@ package config
 import (
 	"fmt"
 	"log"
+	"regexp"
 	"strings"
+	"time"
 
 	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/tenant"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
@@ -32,6 +35,76 @@ type AirtableConfig struct {
 	BaseID             string `mapstructure:"base_id"`
 	LocationsTableName string `mapstructure:"locations_table_name"`
 	UsersTableName     string `mapstructure:"users_table_name"`
+
+	// SlugHistoryTableName is the table previous slugs of renamed locations are
+	// saved to, so they keep redirecting after a restart
+	SlugHistoryTableName string `mapstructure:"slug_history_table_name"`
+
+	// Rate limiting and retry settings
+	RequestsPerSecond     float64 `mapstructure:"requests_per_second"`
+	MaxRetries            int     `mapstructure:"max_retries"`
+	RetryInitialBackoffMs int     `mapstructure:"retry_initial_backoff_ms"`
+	RetryMaxBackoffMs     int     `mapstructure:"retry_max_backoff_ms"`
+
+	// Per-request timeouts, including retries (0 disables)
+	ReadTimeoutMs  int `mapstructure:"read_timeout_ms"`
+	WriteTimeoutMs int `mapstructure:"write_timeout_ms"`
+
+	// SchemaCheck controls startup verification of the base schema:
+	// "fail" stops the server on problems, "warn" logs them, "off" skips the check
+	SchemaCheck string `mapstructure:"schema_check"`
+
+	// WebhookURL is the public URL of the webhook endpoint
+	// (e.g. https://api.example.com/api/webhooks/airtable); empty disables webhooks
+	WebhookURL string `mapstructure:"webhook_url"`
+
+	// WebhookInstance names this replica in the webhook notification URL, so
+	// replicas sharing a base replace only their own webhooks on restart.
+	// It must be stable across restarts; empty suits a single replica
+	WebhookInstance string `mapstructure:"webhook_instance"`
+
+	// LogRequests logs every Airtable request with its table, operation,
+	// record counts, status, latency and retries
+	LogRequests bool `mapstructure:"log_requests"`
+
+	// Column mappings from the field names used in code to the columns of each
+	// table, as comma-separated Field=Column pairs (e.g. "Name=Tên,Slug=fldXXXXXXXXXXXXXX").
+	// Columns may be given by name or by field ID; unmapped fields use their own name
+	LocationsFields string `mapstructure:"locations_fields"`
+	UsersFields     string `mapstructure:"users_fields"`
+
+	// ReturnFieldsByFieldID asks Airtable to key returned fields by field ID,
+	// so reads keep working while a column is being renamed
+	ReturnFieldsByFieldID bool `mapstructure:"return_fields_by_field_id"`
+
+	// Circuit breaker: after BreakerFailureThreshold consecutive failed requests
+	// (0 disables) Airtable is not contacted for BreakerOpenTimeoutMs, reads are
+	// served from the in-memory caches and writes are handled per BreakerWriteMode
+	BreakerFailureThreshold int    `mapstructure:"breaker_failure_threshold"`
+	BreakerOpenTimeoutMs    int    `mapstructure:"breaker_open_timeout_ms"`
+	BreakerWriteMode        string `mapstructure:"breaker_write_mode"`
+	BreakerQueueSize        int    `mapstructure:"breaker_queue_size"`
+
+	// Change polling: every SyncIntervalMs (0 disables) the records modified
+	// since the last poll are applied to the in-memory caches, and every
+	// SyncDeletionCheckIntervalMs the record IDs are listed to detect deletions.
+	// SyncModifiedField selects the date field compared, empty uses LAST_MODIFIED_TIME()
+	SyncIntervalMs              int    `mapstructure:"sync_interval_ms"`
+	SyncDeletionCheckIntervalMs int    `mapstructure:"sync_deletion_check_interval_ms"`
+	SyncOverlapMs               int    `mapstructure:"sync_overlap_ms"`
+	SyncModifiedField           string `mapstructure:"sync_modified_field"`
+
+	// Tenants lists additional tenants served from their own Airtable bases,
+	// comma-separated (e.g. "branch1,staging"). Each is configured with
+	// AIRTABLE_TENANT_<NAME>_* variables, see AirtableTenants
+	Tenants string `mapstructure:"tenants"`
+}
+
+// TenantConfig is the Airtable configuration of one tenant
+type TenantConfig struct {
+	Name     string
+	Hosts    []string       // Request hosts served by the tenant
+	Airtable AirtableConfig // Top-level settings with the tenant's overrides applied
 }
 
 // AuthConfig holds authentication-related configuration
@@ -40,6 +113,19 @@ type AuthConfig struct {
 	TokenExpiry int    `mapstructure:"token_expiry"` // in hours
 }
 
+// Write modes while the Airtable circuit breaker is open
+const (
+	BreakerWriteReject = "reject" // Respond with 503
+	BreakerWriteQueue  = "queue"  // Apply locally and replay once Airtable recovers
+)
+
+// Airtable schema check modes
+const (
+	SchemaCheckFail = "fail"
+	SchemaCheckWarn = "warn"
+	SchemaCheckOff  = "off"
+)
+
 var (
 	// Global config instance
 	globalConfig *Config
@@ -96,6 +182,29 @@ func setDefaults() {
 	viper.SetDefault("airtable.base_id", "")
 	viper.SetDefault("airtable.locations_table_name", "Địa điểm")
 	viper.SetDefault("airtable.users_table_name", "Người dùng")
+	viper.SetDefault("airtable.slug_history_table_name", "Lịch sử slug")
+	viper.SetDefault("airtable.requests_per_second", airtable.DefaultRequestsPerSecond)
+	viper.SetDefault("airtable.max_retries", 3)
+	viper.SetDefault("airtable.retry_initial_backoff_ms", 500)
+	viper.SetDefault("airtable.retry_max_backoff_ms", 30000)
+	viper.SetDefault("airtable.read_timeout_ms", 10000)
+	viper.SetDefault("airtable.write_timeout_ms", 20000)
+	viper.SetDefault("airtable.schema_check", SchemaCheckWarn)
+	viper.SetDefault("airtable.webhook_url", "")
+	viper.SetDefault("airtable.webhook_instance", "")
+	viper.SetDefault("airtable.log_requests", false)
+	viper.SetDefault("airtable.locations_fields", "")
+	viper.SetDefault("airtable.users_fields", "")
+	viper.SetDefault("airtable.return_fields_by_field_id", false)
+	viper.SetDefault("airtable.breaker_failure_threshold", 5)
+	viper.SetDefault("airtable.breaker_open_timeout_ms", 30000)
+	viper.SetDefault("airtable.breaker_write_mode", BreakerWriteReject)
+	viper.SetDefault("airtable.breaker_queue_size", airtable.DefaultWriteQueueSize)
+	viper.SetDefault("airtable.sync_interval_ms", 0)
+	viper.SetDefault("airtable.sync_deletion_check_interval_ms", 600000)
+	viper.SetDefault("airtable.sync_overlap_ms", 5000)
+	viper.SetDefault("airtable.sync_modified_field", "")
+	viper.SetDefault("airtable.tenants", "")
 
 	// Auth defaults
 	viper.SetDefault("auth.jwt_secret", "")
@@ -126,6 +235,59 @@ func (c *Config) Validate() error {
 		c.Airtable.UsersTableName = "Người dùng" // Fallback to default if somehow empty
 	}
 
+	// SlugHistoryTableName has a default value, so it's optional but we ensure it's set
+	if c.Airtable.SlugHistoryTableName == "" {
+		c.Airtable.SlugHistoryTableName = "Lịch sử slug" // Fallback to default if somehow empty
+	}
+
+	if c.Airtable.RequestsPerSecond <= 0 {
+		c.Airtable.RequestsPerSecond = airtable.DefaultRequestsPerSecond
+	}
+
+	if c.Airtable.MaxRetries < 0 {
+		c.Airtable.MaxRetries = 0
+	}
+
+	if c.Airtable.ReadTimeoutMs < 0 || c.Airtable.WriteTimeoutMs < 0 {
+		return fmt.Errorf("airtable timeouts must not be negative (set AIRTABLE_READ_TIMEOUT_MS and AIRTABLE_WRITE_TIMEOUT_MS)")
+	}
+
+	switch c.Airtable.SchemaCheck {
+	case SchemaCheckFail, SchemaCheckWarn, SchemaCheckOff:
+	case "":
+		c.Airtable.SchemaCheck = SchemaCheckWarn
+	default:
+		return fmt.Errorf("airtable schema check must be %q, %q or %q (set AIRTABLE_SCHEMA_CHECK)",
+			SchemaCheckFail, SchemaCheckWarn, SchemaCheckOff)
+	}
+
+	if c.Airtable.BreakerFailureThreshold < 0 {
+		c.Airtable.BreakerFailureThreshold = 0
+	}
+
+	switch c.Airtable.BreakerWriteMode {
+	case BreakerWriteReject, BreakerWriteQueue:
+	case "":
+		c.Airtable.BreakerWriteMode = BreakerWriteReject
+	default:
+		return fmt.Errorf("airtable breaker write mode must be %q or %q (set AIRTABLE_BREAKER_WRITE_MODE)",
+			BreakerWriteReject, BreakerWriteQueue)
+	}
+
+	if c.Airtable.SyncIntervalMs < 0 || c.Airtable.SyncDeletionCheckIntervalMs < 0 || c.Airtable.SyncOverlapMs < 0 {
+		return fmt.Errorf("airtable sync intervals must not be negative (set AIRTABLE_SYNC_INTERVAL_MS, AIRTABLE_SYNC_DELETION_CHECK_INTERVAL_MS and AIRTABLE_SYNC_OVERLAP_MS)")
+	}
+
+	tenants, err := c.AirtableTenants()
+	if err != nil {
+		return err
+	}
+	for _, t := range tenants {
+		if _, err := t.Airtable.TableMappings(); err != nil {
+			return fmt.Errorf("tenant %s: %w", t.Name, err)
+		}
+	}
+
 	// Validate auth config
 	if c.Auth.JWTSecret == "" {
 		return fmt.Errorf("JWT secret is required (set AUTH_JWT_SECRET)")
@@ -143,7 +305,175 @@ func (c *Config) ServerAddress() string {
 	return fmt.Sprintf("%s:%s", c.Server.Host, c.Server.Port)
 }
 
-// NewAirtableClient creates a new Airtable client using the configuration
-func (c *Config) NewAirtableClient() (*airtable.Client, error) {
-	return airtable.NewClient(c.Airtable.APIKey, c.Airtable.BaseID)
+// NewAirtableClient creates a new Airtable client for the default tenant
+// using the configuration, followed by any extra options
+func (c *Config) NewAirtableClient(opts ...airtable.Option) (*airtable.Client, error) {
+	return c.Airtable.NewClient(opts...)
+}
+
+// NewClient creates a new Airtable client using the configuration, followed
+// by any extra options
+func (a AirtableConfig) NewClient(opts ...airtable.Option) (*airtable.Client, error) {
+	mappings, err := a.TableMappings()
+	if err != nil {
+		return nil, err
+	}
+
+	return airtable.NewClient(
+		a.APIKey,
+		a.BaseID,
+		append([]airtable.Option{
+			airtable.WithRateLimit(a.RequestsPerSecond),
+			airtable.WithRetryPolicy(a.RetryPolicy()),
+			airtable.WithTimeouts(a.Timeouts()),
+			airtable.WithCircuitBreaker(a.BreakerPolicy()),
+			airtable.WithTableMappings(mappings...),
+			airtable.WithReturnFieldsByFieldID(a.ReturnFieldsByFieldID),
+		}, opts...)...,
+	)
+}
+
+// AirtableTenants returns the default tenant, configured by the top-level
+// AIRTABLE_* variables, followed by the tenants listed in AIRTABLE_TENANTS.
+// A tenant's settings are read from AIRTABLE_TENANT_<NAME>_BASE_ID (required),
+// _API_KEY, _LOCATIONS_TABLE_NAME, _USERS_TABLE_NAME, _SLUG_HISTORY_TABLE_NAME,
+// _LOCATIONS_FIELDS and _USERS_FIELDS, falling back to the top-level values, and the hosts it
+// serves from AIRTABLE_TENANT_<NAME>_HOSTS
+func (c *Config) AirtableTenants() ([]TenantConfig, error) {
+	defaultTenant := c.Airtable
+	defaultTenant.Tenants = ""
+	tenants := []TenantConfig{{Name: tenant.Default, Airtable: defaultTenant}}
+
+	hostTenants := make(map[string]string)
+	for _, name := range splitList(c.Airtable.Tenants) {
+		name = strings.ToLower(name)
+		if !tenantNamePattern.MatchString(name) || name == tenant.Default {
+			return nil, fmt.Errorf("invalid tenant name %q in AIRTABLE_TENANTS: use lowercase letters, digits and underscores", name)
+		}
+		for _, t := range tenants {
+			if t.Name == name {
+				return nil, fmt.Errorf("tenant %q is listed twice in AIRTABLE_TENANTS", name)
+			}
+		}
+
+		prefix := "airtable.tenant_" + name + "."
+		env := "AIRTABLE_TENANT_" + strings.ToUpper(name) + "_"
+		t := TenantConfig{Name: name, Airtable: defaultTenant}
+		t.Airtable.BaseID = viper.GetString(prefix + "base_id")
+		if t.Airtable.BaseID == "" {
+			return nil, fmt.Errorf("airtable base ID of tenant %s is required (set %sBASE_ID)", name, env)
+		}
+		for key, value := range map[string]*string{
+			"api_key":                 &t.Airtable.APIKey,
+			"locations_table_name":    &t.Airtable.LocationsTableName,
+			"users_table_name":        &t.Airtable.UsersTableName,
+			"slug_history_table_name": &t.Airtable.SlugHistoryTableName,
+			"locations_fields":        &t.Airtable.LocationsFields,
+			"users_fields":            &t.Airtable.UsersFields,
+		} {
+			if override := viper.GetString(prefix + key); override != "" {
+				*value = override
+			}
+		}
+
+		t.Hosts = splitList(viper.GetString(prefix + "hosts"))
+		for _, host := range t.Hosts {
+			host = strings.ToLower(host)
+			if other, taken := hostTenants[host]; taken {
+				return nil, fmt.Errorf("host %s is assigned to tenants %s and %s (set %sHOSTS)", host, other, name, env)
+			}
+			hostTenants[host] = name
+		}
+
+		tenants = append(tenants, t)
+	}
+	return tenants, nil
+}
+
+// tenantNamePattern restricts tenant names to characters that can appear in
+// environment variable names
+var tenantNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// BreakerPolicy returns the Airtable circuit breaker policy described by the configuration
+func (a AirtableConfig) BreakerPolicy() airtable.BreakerPolicy {
+	return airtable.BreakerPolicy{
+		FailureThreshold: a.BreakerFailureThreshold,
+		OpenTimeout:      time.Duration(a.BreakerOpenTimeoutMs) * time.Millisecond,
+	}
+}
+
+// SyncPolicy returns the Airtable change polling policy described by the configuration
+func (a AirtableConfig) SyncPolicy() airtable.SyncPolicy {
+	return airtable.SyncPolicy{
+		Interval:              time.Duration(a.SyncIntervalMs) * time.Millisecond,
+		DeletionCheckInterval: time.Duration(a.SyncDeletionCheckIntervalMs) * time.Millisecond,
+		Overlap:               time.Duration(a.SyncOverlapMs) * time.Millisecond,
+		ModifiedField:         a.SyncModifiedField,
+	}
+}
+
+// TableMappings returns the column mappings of the tables that have one
+func (a AirtableConfig) TableMappings() ([]airtable.TableMapping, error) {
+	var mappings []airtable.TableMapping
+	for _, t := range []struct{ table, fields, env string }{
+		{a.LocationsTableName, a.LocationsFields, "AIRTABLE_LOCATIONS_FIELDS"},
+		{a.UsersTableName, a.UsersFields, "AIRTABLE_USERS_FIELDS"},
+	} {
+		fields, err := parseFieldMapping(t.fields)
+		if err != nil {
+			return nil, fmt.Errorf("invalid airtable column mapping (set %s): %w", t.env, err)
+		}
+		if len(fields) > 0 {
+			mappings = append(mappings, airtable.TableMapping{Table: t.table, Fields: fields})
+		}
+	}
+	return mappings, nil
+}
+
+// parseFieldMapping parses comma-separated Field=Column pairs
+func parseFieldMapping(s string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, "=")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || field == "" || column == "" {
+			return nil, fmt.Errorf("%q is not a Field=Column pair", pair)
+		}
+		if _, dup := fields[field]; dup {
+			return nil, fmt.Errorf("field %q is mapped twice", field)
+		}
+		fields[field] = column
+	}
+	return fields, nil
+}
+
+// RetryPolicy returns the Airtable retry policy described by the configuration
+func (a AirtableConfig) RetryPolicy() airtable.RetryPolicy {
+	return airtable.RetryPolicy{
+		MaxRetries:     a.MaxRetries,
+		InitialBackoff: time.Duration(a.RetryInitialBackoffMs) * time.Millisecond,
+		MaxBackoff:     time.Duration(a.RetryMaxBackoffMs) * time.Millisecond,
+	}
+}
+
+// Timeouts returns the Airtable per-request timeouts described by the configuration
+func (a AirtableConfig) Timeouts() airtable.Timeouts {
+	return airtable.Timeouts{
+		Read:  time.Duration(a.ReadTimeoutMs) * time.Millisecond,
+		Write: time.Duration(a.WriteTimeoutMs) * time.Millisecond,
+	}
 }
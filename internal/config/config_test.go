@@ -0,0 +1,68 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFieldMapping(t *testing.T) {
+	tests := []struct {
+		value string
+		want  map[string]string
+	}{
+		{"", map[string]string{}},
+		{"Name=Tên", map[string]string{"Name": "Tên"}},
+		{" Name = Tên địa điểm , Slug=fldSlug0000000000,", map[string]string{"Name": "Tên địa điểm", "Slug": "fldSlug0000000000"}},
+		{"Formula=a=b", map[string]string{"Formula": "a=b"}}, // Only the first = separates
+	}
+	for _, tt := range tests {
+		got, err := parseFieldMapping(tt.value)
+		if err != nil {
+			t.Errorf("parseFieldMapping(%q): %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFieldMapping(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseFieldMappingErrors(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"Name", `"Name" is not a Field=Column pair`},
+		{"Name=", `"Name=" is not a Field=Column pair`},
+		{"=Tên", `"=Tên" is not a Field=Column pair`},
+		{"Name=Tên, Slug", `" Slug" is not a Field=Column pair`},
+		{"Name=Tên,Name=Tên 2", `field "Name" is mapped twice`},
+	}
+	for _, tt := range tests {
+		if _, err := parseFieldMapping(tt.value); err == nil || err.Error() != tt.err {
+			t.Errorf("parseFieldMapping(%q) error = %v, want %s", tt.value, err, tt.err)
+		}
+	}
+}
+
+func TestTableMappings(t *testing.T) {
+	a := AirtableConfig{
+		LocationsTableName: "Địa điểm",
+		UsersTableName:     "Người dùng",
+		LocationsFields:    "Name=Tên",
+	}
+	mappings, err := a.TableMappings()
+	if err != nil {
+		t.Fatalf("TableMappings: %v", err)
+	}
+	// Tables without a mapping are left out
+	if len(mappings) != 1 || mappings[0].Table != "Địa điểm" || mappings[0].Fields["Name"] != "Tên" {
+		t.Errorf("TableMappings = %+v", mappings)
+	}
+
+	a.UsersFields = "Email"
+	if _, err := a.TableMappings(); err == nil || !strings.Contains(err.Error(), "AIRTABLE_USERS_FIELDS") {
+		t.Errorf("TableMappings error = %v, want one naming AIRTABLE_USERS_FIELDS", err)
+	}
+}
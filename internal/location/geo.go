@@ -0,0 +1,233 @@
+package location
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Limits of NearbyQuery.
+const (
+	DefaultNearbyRadiusKm = 10
+	MaxNearbyRadiusKm     = 20000 // About half the Earth's circumference
+	DefaultNearbyLimit    = 20
+	MaxNearbyLimit        = 100
+)
+
+// earthRadiusKm is the mean radius of the Earth.
+const earthRadiusKm = 6371.0088
+
+// ErrInvalidCoordinates is returned for a latitude or longitude out of range,
+// or for only one of the two.
+var ErrInvalidCoordinates = errors.New("invalid coordinates")
+
+// validateCoordinates checks that latitude and longitude are either both nil
+// or both within range.
+func validateCoordinates(latitude, longitude *float64) error {
+	switch {
+	case latitude == nil && longitude == nil:
+		return nil
+	case latitude == nil || longitude == nil:
+		return fmt.Errorf("%w: latitude and longitude must be given together", ErrInvalidCoordinates)
+	case math.IsNaN(*latitude) || *latitude < -90 || *latitude > 90:
+		return fmt.Errorf("%w: latitude must be from -90 to 90", ErrInvalidCoordinates)
+	case math.IsNaN(*longitude) || *longitude < -180 || *longitude > 180:
+		return fmt.Errorf("%w: longitude must be from -180 to 180", ErrInvalidCoordinates)
+	}
+	return nil
+}
+
+// distanceKm returns the great-circle distance between two points, using the
+// haversine formula.
+func distanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	rad1, rad2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLat := rad2 - rad1
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad1)*math.Cos(rad2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Geohash parameters. Points are stored at geohashPrecision characters, about
+// 5 m; lookups use the longest prefix that keeps the covering cells few.
+const (
+	geohashAlphabet  = "0123456789bcdefghjkmnpqrstuvwxyz"
+	geohashPrecision = 9
+	maxLookupCells   = 16
+)
+
+// geoIndex is a spatial index of location coordinates. Each location is kept
+// under its geohash in a sorted slice, so the locations in a geohash cell
+// are a contiguous range found by binary search on the cell's prefix. It is
+// not safe for concurrent use; the InMemoryRepository guards it with its own
+// mutex.
+type geoIndex struct {
+	entries []geoEntry          // Sorted by hash, then ID
+	points  map[string]geoEntry // Location ID to its entry
+}
+
+type geoEntry struct {
+	hash string
+	id   string
+	lat  float64
+	lng  float64
+}
+
+func newGeoIndex() *geoIndex {
+	return &geoIndex{points: make(map[string]geoEntry)}
+}
+
+func (e geoEntry) less(o geoEntry) bool {
+	if e.hash != o.hash {
+		return e.hash < o.hash
+	}
+	return e.id < o.id
+}
+
+// add indexes the coordinates of l, replacing those indexed for it before.
+// Locations without valid coordinates are left out.
+func (idx *geoIndex) add(l Location) {
+	idx.remove(l.ID)
+	if l.Latitude == nil || validateCoordinates(l.Latitude, l.Longitude) != nil {
+		return
+	}
+
+	entry := geoEntry{
+		hash: geohash(*l.Latitude, *l.Longitude, geohashPrecision),
+		id:   l.ID,
+		lat:  *l.Latitude,
+		lng:  *l.Longitude,
+	}
+	i := sort.Search(len(idx.entries), func(i int) bool { return !idx.entries[i].less(entry) })
+	idx.entries = append(idx.entries, geoEntry{})
+	copy(idx.entries[i+1:], idx.entries[i:])
+	idx.entries[i] = entry
+	idx.points[l.ID] = entry
+}
+
+// remove drops the location with the given ID from the index.
+func (idx *geoIndex) remove(id string) {
+	entry, ok := idx.points[id]
+	if !ok {
+		return
+	}
+	i := sort.Search(len(idx.entries), func(i int) bool { return !idx.entries[i].less(entry) })
+	if i < len(idx.entries) && idx.entries[i].id == id {
+		idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+	}
+	delete(idx.points, id)
+}
+
+// within returns the IDs of the locations within radiusKm of the given point,
+// with their distances in kilometers.
+func (idx *geoIndex) within(lat, lng, radiusKm float64) map[string]float64 {
+	distances := make(map[string]float64)
+	for _, cell := range coveringCells(lat, lng, radiusKm) {
+		start := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].hash >= cell })
+		for _, entry := range idx.entries[start:] {
+			if !strings.HasPrefix(entry.hash, cell) {
+				break
+			}
+			if d := distanceKm(lat, lng, entry.lat, entry.lng); d <= radiusKm {
+				distances[entry.id] = d
+			}
+		}
+	}
+	return distances
+}
+
+// geohashBits returns how many of the bits of a geohash with the given number
+// of characters encode latitude and longitude. Bits alternate, starting with
+// longitude.
+func geohashBits(precision int) (latBits, lngBits int) {
+	total := 5 * precision
+	return total / 2, (total + 1) / 2
+}
+
+// geohash returns the geohash of a point.
+func geohash(lat, lng float64, precision int) string {
+	latBits, lngBits := geohashBits(precision)
+	return geohashCell(cellIndex(lat, -90, 180, latBits), cellIndex(lng, -180, 360, lngBits), precision)
+}
+
+// cellIndex returns which of the 2^bits equal parts of [lo, lo+span) value
+// falls in. Values outside are not clamped, so ranges can wrap around.
+func cellIndex(value, lo, span float64, bits int) int64 {
+	n := int64(1) << bits
+	i := int64(math.Floor((value - lo) / span * float64(n)))
+	if value == lo+span {
+		i = n - 1 // The upper edge belongs to the last part
+	}
+	return i
+}
+
+// geohashCell returns the geohash of the cell with the given latitude and
+// longitude indexes.
+func geohashCell(latIndex, lngIndex int64, precision int) string {
+	latBits, lngBits := geohashBits(precision)
+	var b strings.Builder
+	var char, n int
+	for bit := 0; bit < 5*precision; bit++ {
+		var v int64
+		if bit%2 == 0 {
+			lngBits--
+			v = lngIndex >> lngBits & 1
+		} else {
+			latBits--
+			v = latIndex >> latBits & 1
+		}
+		char = char<<1 | int(v)
+		if n++; n == 5 {
+			b.WriteByte(geohashAlphabet[char])
+			char, n = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// coveringCells returns geohash cells that together cover every point within
+// radiusKm of the given point. It uses the longest cells for which at most
+// maxLookupCells are needed.
+func coveringCells(lat, lng, radiusKm float64) []string {
+	// Bounding box of the circle: its latitude span is exact, its longitude
+	// span widest where the circle touches its tangent meridians
+	angular := radiusKm / earthRadiusKm
+	dLat := angular * 180 / math.Pi
+	minLat, maxLat := math.Max(lat-dLat, -90), math.Min(lat+dLat, 90)
+	minLng, maxLng := -180.0, 180.0
+	allLng := true
+	if maxLat < 90 && minLat > -90 {
+		if s := math.Sin(angular) / math.Cos(lat*math.Pi/180); s < 1 {
+			dLng := math.Asin(s) * 180 / math.Pi
+			minLng, maxLng = lng-dLng, lng+dLng
+			allLng = false
+		}
+	}
+
+	for precision := geohashPrecision; ; precision-- {
+		latBits, lngBits := geohashBits(precision)
+		lngCells := int64(1) << lngBits
+		latFrom, latTo := cellIndex(minLat, -90, 180, latBits), cellIndex(maxLat, -90, 180, latBits)
+		lngFrom, lngTo := int64(0), lngCells-1
+		if !allLng {
+			lngFrom, lngTo = cellIndex(minLng, -180, 360, lngBits), cellIndex(maxLng, -180, 360, lngBits)
+			lngTo = min(lngTo, lngFrom+lngCells-1) // A box wider than the world covers every column once
+		}
+
+		count := (latTo - latFrom + 1) * (lngTo - lngFrom + 1)
+		if count > maxLookupCells && precision > 1 {
+			continue
+		}
+
+		cells := make([]string, 0, count)
+		for latIndex := latFrom; latIndex <= latTo; latIndex++ {
+			for lngIndex := lngFrom; lngIndex <= lngTo; lngIndex++ {
+				// Columns past the antimeridian wrap around to the other side
+				wrapped := (lngIndex%lngCells + lngCells) % lngCells
+				cells = append(cells, geohashCell(latIndex, wrapped, precision))
+			}
+		}
+		return cells
+	}
+}
@@ -0,0 +1,239 @@
+package location
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ptr(f float64) *float64 { return &f }
+
+// at returns a location with the given ID and coordinates.
+func at(id string, lat, lng float64) Location {
+	return Location{ID: id, Name: "Location " + id, Slug: "location-" + id, Latitude: ptr(lat), Longitude: ptr(lng)}
+}
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lng1, lat2, lng2 float64
+		want, tolerance        float64
+	}{
+		{"Hanoi to Ho Chi Minh City", 21.0285, 105.8542, 10.7769, 106.7009, 1137, 10},
+		{"same point", 21.0285, 105.8542, 21.0285, 105.8542, 0, 0},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.2, 0.5},
+		{"pole to pole", 90, 0, -90, 0, math.Pi * earthRadiusKm, 0.001},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := distanceKm(tt.lat1, tt.lng1, tt.lat2, tt.lng2)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("distanceKm = %.3f, want %.3f ± %.3f", got, tt.want, tt.tolerance)
+			}
+		})
+	}
+}
+
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		lat, lng  float64
+		precision int
+		want      string
+	}{
+		{57.64911, 10.40744, 11, "u4pruydqqvj"},
+		{-25.382708, -49.265506, 9, "6gkzwgjzn"},
+		{21.0285, 105.8542, 5, "w7er8"},
+		{90, 180, 1, "z"},
+		{-90, -180, 1, "0"},
+	}
+	for _, tt := range tests {
+		if got := geohash(tt.lat, tt.lng, tt.precision); got != tt.want {
+			t.Errorf("geohash(%v, %v, %d) = %s, want %s", tt.lat, tt.lng, tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestGeoIndexWithin(t *testing.T) {
+	idx := newGeoIndex()
+	for _, l := range []Location{
+		// Around the equator and prime meridian, in four different
+		// top-level cells
+		at("ne", 0.001, 0.001),
+		at("nw", 0.001, -0.001),
+		at("se", -0.001, 0.001),
+		at("sw", -0.001, -0.001),
+		// On both sides of the antimeridian
+		at("east", 10, 179.999),
+		at("west", 10, -179.999),
+		// Around the poles, on opposite meridians
+		at("north", 89.999, 0),
+		at("north-opposite", 89.999, 180),
+		at("south", -89.999, 45),
+		at("south-opposite", -89.999, -135),
+		at("hanoi", 21.0285, 105.8542),
+	} {
+		idx.add(l)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lng float64
+		radiusKm float64
+		want     []string
+	}{
+		{"across cell boundaries", 0, 0, 1, []string{"ne", "nw", "se", "sw"}},
+		{"east of the antimeridian", 10, 179.999, 1, []string{"east", "west"}},
+		{"west of the antimeridian", 10, -179.999, 1, []string{"east", "west"}},
+		{"north pole", 89.999, 0, 1, []string{"north", "north-opposite"}},
+		{"south pole", -89.999, -135, 1, []string{"south", "south-opposite"}},
+		{"at the pole", 90, 90, 1, []string{"north", "north-opposite"}},
+		{"nothing in range", 10, 0, 100, nil},
+		{"whole world", 0, 0, MaxNearbyRadiusKm, []string{
+			"east", "hanoi", "ne", "north", "north-opposite", "nw",
+			"se", "south", "south-opposite", "sw", "west",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idx.within(tt.lat, tt.lng, tt.radiusKm)
+			if len(got) != len(tt.want) {
+				t.Errorf("within = %v, want %v", got, tt.want)
+			}
+			for _, id := range tt.want {
+				d, ok := got[id]
+				if !ok {
+					t.Errorf("within = %v, missing %s", got, id)
+					continue
+				}
+				if d > tt.radiusKm {
+					t.Errorf("distance of %s = %.3f, beyond %v", id, d, tt.radiusKm)
+				}
+			}
+		})
+	}
+}
+
+func TestGeoIndexUpdatesIncrementally(t *testing.T) {
+	idx := newGeoIndex()
+	idx.add(at("1", 21.0285, 105.8542))
+	idx.add(at("2", 21.03, 105.85))
+
+	// Moving a location drops it from its old cell
+	idx.add(at("1", 10.7769, 106.7009))
+	if got := idx.within(21.0285, 105.8542, 5); len(got) != 1 {
+		t.Errorf("within the old place = %v, want only 2", got)
+	}
+	if got := idx.within(10.7769, 106.7009, 5); len(got) != 1 {
+		t.Errorf("within the new place = %v, want only 1", got)
+	}
+	if len(idx.entries) != 2 || len(idx.points) != 2 {
+		t.Errorf("index has %d entries and %d points, want 2", len(idx.entries), len(idx.points))
+	}
+
+	// Clearing the coordinates drops the location
+	idx.add(Location{ID: "1"})
+	if got := idx.within(10.7769, 106.7009, 5); len(got) != 0 {
+		t.Errorf("within after clearing = %v, want nothing", got)
+	}
+
+	idx.remove("2")
+	idx.remove("missing")
+	if len(idx.entries) != 0 || len(idx.points) != 0 {
+		t.Errorf("index after removing everything has %d entries and %d points", len(idx.entries), len(idx.points))
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		lat, lng *float64
+		valid    bool
+	}{
+		{"both nil", nil, nil, true},
+		{"in range", ptr(21.0285), ptr(105.8542), true},
+		{"on the edges", ptr(-90), ptr(180), true},
+		{"only latitude", ptr(21.0285), nil, false},
+		{"only longitude", nil, ptr(105.8542), false},
+		{"NaN latitude", &nan, ptr(105.8542), false},
+		{"NaN longitude", ptr(21.0285), &nan, false},
+		{"latitude out of range", ptr(90.1), ptr(0), false},
+		{"longitude out of range", ptr(0), ptr(-180.1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCoordinates(tt.lat, tt.lng)
+			if tt.valid && err != nil {
+				t.Errorf("validateCoordinates: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidCoordinates) {
+				t.Errorf("validateCoordinates error = %v, want ErrInvalidCoordinates", err)
+			}
+		})
+	}
+}
+
+func TestNearbyLocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(NewInMemoryRepository([]Location{
+		at("far", 21.0285, 106.2),
+		at("near", 21.0285, 105.86),
+		at("here", 21.0285, 105.8542),
+		at("hcmc", 10.7769, 106.7009),
+		{ID: "unknown", Name: "Unknown", Slug: "unknown"},
+	})).RegisterRoutes(router.Group("/api"))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/locations/nearby?lat=21.0285&lng=105.8542&radius_km=50", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+	var body struct {
+		Data []struct {
+			ID         string   `json:"id"`
+			DistanceKm *float64 `json:"distance_km"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		id       string
+		distance float64
+	}{{"here", 0}, {"near", 0.6}, {"far", 35.9}}
+	if len(body.Data) != len(want) {
+		t.Fatalf("data = %s, want %d locations", rec.Body, len(want))
+	}
+	for i, w := range want {
+		got := body.Data[i]
+		if got.ID != w.id {
+			t.Errorf("location %d = %s, want %s", i, got.ID, w.id)
+		}
+		if got.DistanceKm == nil {
+			t.Errorf("distance of %s missing", got.ID)
+		} else if math.Abs(*got.DistanceKm-w.distance) > 0.1 {
+			t.Errorf("distance of %s = %.3f, want about %v", got.ID, *got.DistanceKm, w.distance)
+		}
+	}
+
+	for _, target := range []string{
+		"/api/locations/nearby?lat=21.0285",
+		"/api/locations/nearby?lat=NaN&lng=105.8542",
+		"/api/locations/nearby?lat=91&lng=105.8542",
+		"/api/locations/nearby?lat=21&lng=105&radius_km=0",
+		"/api/locations/nearby?lat=21&lng=105&limit=101",
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", target, rec.Code)
+		}
+	}
+}
@@ -1,11 +1,22 @@
 package location
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gosimple/slug"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/publicid"
+	"lam-phuong-api/internal/tenant"
 )
 
 // Handler exposes HTTP handlers for the location resource.
@@ -24,26 +35,355 @@ func NewHandler(repo Repository) *Handler {
 func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/locations", h.ListLocations)
 	router.POST("/locations", h.CreateLocation)
+	router.GET("/locations/nearby", h.NearbyLocations)
+	router.GET("/locations/:slug", h.GetLocation)
+	router.PUT("/locations/:slug", h.UpdateLocation)
+	router.PATCH("/locations/:slug", h.PatchLocation)
 	router.DELETE("/locations/:slug", h.DeleteLocationBySlug)
+	router.POST("/locations/:slug/photos", h.AddLocationPhoto)
 }
 
 // ListLocations godoc
-// @Summary      List all locations
-// @Description  Get a list of all locations (requires authentication)
+// @Summary      List locations
+// @Description  Get a page of locations, optionally searched, filtered and sorted. Search ignores case and diacritics, matches word prefixes and ranks the best matches first. The Link header carries the URLs of the first and next pages (requires authentication)
 // @Tags         locations
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
-// @Success      200  {array}   Location
-// @Failure      401  {object}  map[string]string
+// @Param        limit           query     int     false  "Page size, 1-100"  default(20)
+// @Param        cursor          query     string  false  "next_cursor of the previous page"
+// @Param        q               query     string  false  "Search terms, e.g. lam phuong"
+// @Param        sort            query     string  false  "Sort order: name, slug, created_at or relevance (with q), prefixed with - for descending; defaults to relevance with q, else created_at"
+// @Param        name            query     string  false  "Case-insensitive substring of the name"
+// @Param        slug            query     string  false  "Exact slug"
+// @Param        created_after   query     string  false  "RFC 3339 time the location was created after"
+// @Param        created_before  query     string  false  "RFC 3339 time the location was created before"
+// @Success      200             {object}  Page
+// @Failure      400             {object}  map[string]string
+// @Failure      401             {object}  map[string]string
+// @Failure      404             {object}  map[string]string
 // @Router       /locations [get]
 func (h *Handler) ListLocations(c *gin.Context) {
-	c.JSON(http.StatusOK, h.repo.List())
+	query, err := parseQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.repo.Find(c.Request.Context(), query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	setLinkHeader(c, query, page)
+	c.JSON(http.StatusOK, page)
+}
+
+// parseQuery reads the filters, sort order and page of a list request.
+func parseQuery(c *gin.Context) (Query, error) {
+	query := Query{
+		Q:      c.Query("q"),
+		Name:   c.Query("name"),
+		Slug:   c.Query("slug"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+		Limit:  DefaultPageSize,
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			return Query{}, fmt.Errorf("limit must be a number from 1 to %d", MaxPageSize)
+		}
+		query.Limit = limit
+	}
+	if _, _, err := query.sortOrder(); err != nil {
+		return Query{}, err
+	}
+
+	for param, bound := range map[string]*time.Time{
+		"created_after":  &query.CreatedAfter,
+		"created_before": &query.CreatedBefore,
+	} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return Query{}, fmt.Errorf("%s must be an RFC 3339 time, e.g. 2024-01-31T00:00:00Z", param)
+		}
+		*bound = t
+	}
+
+	return query, nil
+}
+
+// setLinkHeader sets an RFC 8288 Link header with the URLs of the first page
+// and, unless page is the last one, the next page.
+func setLinkHeader(c *gin.Context, query Query, page Page) {
+	link := func(cursor, rel string) string {
+		u := *c.Request.URL
+		values := u.Query()
+		values.Set("limit", strconv.Itoa(query.Limit))
+		values.Del("cursor")
+		if cursor != "" {
+			values.Set("cursor", cursor)
+		}
+		u.RawQuery = values.Encode()
+		return fmt.Sprintf("<%s>; rel=%q", u.RequestURI(), rel)
+	}
+
+	links := []string{link("", "first")}
+	if page.NextCursor != "" {
+		links = append(links, link(page.NextCursor, "next"))
+	}
+	c.Header("Link", strings.Join(links, ", "))
+}
+
+// NearbyLocations godoc
+// @Summary      List locations near a point
+// @Description  Get the locations within a radius of a point, nearest first, with their great-circle distances. Locations without coordinates are left out. (requires authentication)
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        lat        query     number  true   "Latitude, -90 to 90"
+// @Param        lng        query     number  true   "Longitude, -180 to 180"
+// @Param        radius_km  query     number  false  "Radius in kilometers, up to 20000"  default(10)
+// @Param        limit      query     int     false  "Maximum number of locations, 1-100"  default(20)
+// @Success      200        {object}  map[string][]NearbyLocation
+// @Failure      400        {object}  map[string]string
+// @Failure      401        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      503        {object}  map[string]string
+// @Router       /locations/nearby [get]
+func (h *Handler) NearbyLocations(c *gin.Context) {
+	query, err := parseNearbyQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nearby, err := h.repo.Nearby(c.Request.Context(), query)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": nearby})
+}
+
+// parseNearbyQuery reads a NearbyQuery from the query string.
+func parseNearbyQuery(c *gin.Context) (NearbyQuery, error) {
+	query := NearbyQuery{RadiusKm: DefaultNearbyRadiusKm, Limit: DefaultNearbyLimit}
+
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	if latErr != nil || lngErr != nil {
+		return NearbyQuery{}, errors.New("lat and lng are required and must be numbers")
+	}
+	if err := validateCoordinates(&lat, &lng); err != nil {
+		return NearbyQuery{}, err
+	}
+	query.Latitude, query.Longitude = lat, lng
+
+	if value := c.Query("radius_km"); value != "" {
+		radius, err := strconv.ParseFloat(value, 64)
+		if err != nil || !(radius > 0 && radius <= MaxNearbyRadiusKm) {
+			return NearbyQuery{}, fmt.Errorf("radius_km must be a number greater than 0 and at most %d", MaxNearbyRadiusKm)
+		}
+		query.RadiusKm = radius
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > MaxNearbyLimit {
+			return NearbyQuery{}, fmt.Errorf("limit must be a number from 1 to %d", MaxNearbyLimit)
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// GetLocation godoc
+// @Summary      Get a location
+// @Description  Get a single location by its slug or public ID. A previous slug of a renamed location redirects to its current slug. (requires authentication)
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        slug  path      string  true  "Location slug or public ID"
+// @Success      200   {object}  Location
+// @Success      301   {object}  map[string]string
+// @Failure      400   {object}  map[string]string
+// @Failure      401   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /locations/{slug} [get]
+func (h *Handler) GetLocation(c *gin.Context) {
+	location, err := h.lookup(c.Request.Context(), c.Param("slug"))
+	if errors.Is(err, ErrNotFound) && !publicid.Valid(c.Param("slug")) {
+		renamed, historyErr := h.repo.GetByOldSlug(c.Request.Context(), slug.Make(c.Param("slug")))
+		if historyErr == nil {
+			target := path.Join(path.Dir(c.Request.URL.Path), renamed.Slug)
+			if c.Request.URL.RawQuery != "" {
+				target += "?" + c.Request.URL.RawQuery
+			}
+			c.Redirect(http.StatusMovedPermanently, target)
+			return
+		}
+	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// lookup finds a location by the slug or public ID given in a request path.
+// Public IDs are upper case, so they never collide with normalized slugs.
+func (h *Handler) lookup(ctx context.Context, param string) (Location, error) {
+	if publicid.Valid(param) {
+		return h.repo.Get(ctx, param)
+	}
+
+	normalizedSlug := slug.Make(param)
+	if normalizedSlug == "" {
+		return Location{}, errInvalidSlug
+	}
+	return h.repo.GetBySlug(ctx, normalizedSlug)
+}
+
+// UpdateLocation godoc
+// @Summary      Replace a location
+// @Description  Replace the name, slug and coordinates of a location found by slug or public ID. If slug is not provided, it is generated from the name; the previous slug keeps redirecting to the location. Omitting the coordinates clears them. (requires authentication)
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        slug      path      string           true  "Location slug or public ID"
+// @Param        location  body      locationPayload  true  "Location payload"
+// @Success      200       {object}  Location
+// @Failure      400       {object}  map[string]string
+// @Failure      401       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      422       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /locations/{slug} [put]
+func (h *Handler) UpdateLocation(c *gin.Context) {
+	var payload locationPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.update(c, func(existing Location) Location {
+		return Location{Name: payload.Name, Slug: payload.Slug, Latitude: payload.Latitude, Longitude: payload.Longitude}
+	})
+}
+
+// PatchLocation godoc
+// @Summary      Update a location
+// @Description  Update the name, slug and/or coordinates of a location found by slug or public ID. Renaming without a slug regenerates the slug from the new name; the previous slug keeps redirecting to the location. (requires authentication)
+// @Tags         locations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        slug      path      string                true  "Location slug or public ID"
+// @Param        location  body      patchLocationPayload  true  "Fields to update"
+// @Success      200       {object}  Location
+// @Failure      400       {object}  map[string]string
+// @Failure      401       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      422       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /locations/{slug} [patch]
+func (h *Handler) PatchLocation(c *gin.Context) {
+	var payload patchLocationPayload
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if payload.Name == nil && payload.Slug == nil && payload.Latitude == nil && payload.Longitude == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one of name, slug, latitude or longitude must be provided"})
+		return
+	}
+	if payload.Name != nil && strings.TrimSpace(*payload.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name must not be empty"})
+		return
+	}
+
+	h.update(c, func(existing Location) Location {
+		changes := Location{
+			Name:      existing.Name,
+			Slug:      existing.Slug,
+			Latitude:  existing.Latitude,
+			Longitude: existing.Longitude,
+		}
+		if payload.Name != nil && *payload.Name != existing.Name {
+			changes.Name = *payload.Name
+			changes.Slug = "" // Regenerated from the new name
+		}
+		if payload.Slug != nil {
+			changes.Slug = *payload.Slug
+		}
+		if payload.Latitude != nil {
+			changes.Latitude = payload.Latitude
+		}
+		if payload.Longitude != nil {
+			changes.Longitude = payload.Longitude
+		}
+		return changes
+	})
+}
+
+type patchLocationPayload struct {
+	Name      *string  `json:"name"`      // Optional, renaming regenerates the slug unless one is given
+	Slug      *string  `json:"slug"`      // Optional, empty regenerates it from the name
+	Latitude  *float64 `json:"latitude"`  // Optional, -90 to 90
+	Longitude *float64 `json:"longitude"` // Optional, -180 to 180
+}
+
+// update applies the changes returned by apply to the location named in the
+// request path. An empty slug in the changes is generated from the name, and
+// a new slug is made unique among the other locations.
+func (h *Handler) update(c *gin.Context, apply func(existing Location) Location) {
+	ctx := c.Request.Context()
+	existing, err := h.lookup(ctx, c.Param("slug"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	changes := apply(existing)
+	if err := validateCoordinates(changes.Latitude, changes.Longitude); err != nil {
+		respondError(c, err)
+		return
+	}
+	if changes.Slug != "" {
+		changes.Slug = slug.Make(changes.Slug)
+	} else {
+		changes.Slug = slug.Make(changes.Name)
+	}
+	if changes.Slug != existing.Slug {
+		changes.Slug = ensureUniqueSlug(ctx, h.repo, changes.Slug, existing.ID)
+	}
+
+	updated, err := h.repo.Update(ctx, existing.ID, changes)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
 }
 
 // CreateLocation godoc
 // @Summary      Create a new location
-// @Description  Create a new location with name and optional slug. If slug is not provided, it will be generated from the name. (requires authentication)
+// @Description  Create a new location with name, optional slug and optional coordinates. If slug is not provided, it will be generated from the name. (requires authentication)
 // @Tags         locations
 // @Accept       json
 // @Produce      json
@@ -52,7 +392,9 @@ func (h *Handler) ListLocations(c *gin.Context) {
 // @Success      201       {object}  Location
 // @Failure      400       {object}  map[string]string
 // @Failure      401       {object}  map[string]string
+// @Failure      422       {object}  map[string]string
 // @Failure      500       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
 // @Router       /locations [post]
 func (h *Handler) CreateLocation(c *gin.Context) {
 	var payload locationPayload
@@ -60,6 +402,10 @@ func (h *Handler) CreateLocation(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateCoordinates(payload.Latitude, payload.Longitude); err != nil {
+		respondError(c, err)
+		return
+	}
 
 	// Generate slug from name if not provided
 	locationSlug := payload.Slug
@@ -69,17 +415,19 @@ func (h *Handler) CreateLocation(c *gin.Context) {
 		locationSlug = slug.Make(payload.Name)
 	}
 
-	locationSlug = ensureUniqueSlug(h.repo, locationSlug)
+	locationSlug = ensureUniqueSlug(c.Request.Context(), h.repo, locationSlug, "")
 
 	location := Location{
-		Name: payload.Name,
-		Slug: locationSlug,
+		Name:      payload.Name,
+		Slug:      locationSlug,
+		Latitude:  payload.Latitude,
+		Longitude: payload.Longitude,
 	}
 
 	// Create in repository (repository handles Airtable sync if configured)
 	created, err := h.repo.Create(c.Request.Context(), location)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -87,17 +435,32 @@ func (h *Handler) CreateLocation(c *gin.Context) {
 }
 
 type locationPayload struct {
-	Name string `json:"name" binding:"required"` // Required
-	Slug string `json:"slug"`                    // Optional, will be generated from name if not provided
+	Name      string   `json:"name" binding:"required"` // Required
+	Slug      string   `json:"slug"`                    // Optional, will be generated from name if not provided
+	Latitude  *float64 `json:"latitude"`                // Optional, -90 to 90, given together with longitude
+	Longitude *float64 `json:"longitude"`               // Optional, -180 to 180
 }
 
-func ensureUniqueSlug(repo Repository, baseSlug string) string {
+// reservedSlugs are path segments routed to other handlers, which a
+// location with that slug could not be reached through.
+var reservedSlugs = []string{"nearby"}
+
+// ensureUniqueSlug returns baseSlug, or baseSlug with the first numeric
+// suffix that makes it unique. The location with ID ignoreID, the one being
+// renamed, does not count as taking a slug.
+func ensureUniqueSlug(ctx context.Context, repo Repository, baseSlug, ignoreID string) string {
 	if baseSlug == "" {
 		baseSlug = "location"
 	}
 
 	existingSlugs := make(map[string]struct{})
-	for _, loc := range repo.List() {
+	for _, reserved := range reservedSlugs {
+		existingSlugs[reserved] = struct{}{}
+	}
+	for _, loc := range repo.List(ctx) {
+		if ignoreID != "" && loc.ID == ignoreID {
+			continue
+		}
 		existingSlugs[loc.Slug] = struct{}{}
 	}
 
@@ -125,6 +488,7 @@ func ensureUniqueSlug(repo Repository, baseSlug string) string {
 // @Failure      400   {object}  map[string]string
 // @Failure      401   {object}  map[string]string
 // @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
 // @Router       /locations/{slug} [delete]
 func (h *Handler) DeleteLocationBySlug(c *gin.Context) {
 	slugParam := c.Param("slug")
@@ -139,10 +503,116 @@ func (h *Handler) DeleteLocationBySlug(c *gin.Context) {
 		return
 	}
 
-	if ok := h.repo.DeleteBySlug(normalizedSlug); !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "location not found"})
+	if err := h.repo.DeleteBySlug(c.Request.Context(), normalizedSlug); err != nil {
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{})
 }
+
+// AddLocationPhoto godoc
+// @Summary      Add a photo to a location
+// @Description  Upload an image as multipart form field "file" (at most 5 MB), or send JSON with the URL of an image for Airtable to fetch (requires authentication)
+// @Tags         locations
+// @Accept       mpfd
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        slug   path      string           true   "Location slug"
+// @Param        file   formData  file             false  "Image file"
+// @Param        photo  body      photoURLPayload  false  "Image URL"
+// @Success      200    {object}  Location
+// @Failure      400    {object}  map[string]string
+// @Failure      401    {object}  map[string]string
+// @Failure      404    {object}  map[string]string
+// @Failure      413    {object}  map[string]string
+// @Failure      422    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /locations/{slug}/photos [post]
+func (h *Handler) AddLocationPhoto(c *gin.Context) {
+	normalizedSlug := slug.Make(c.Param("slug"))
+	if normalizedSlug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid slug"})
+		return
+	}
+
+	photo, ok := photoFromRequest(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.AddPhoto(c.Request.Context(), normalizedSlug, photo)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+type photoURLPayload struct {
+	URL      string `json:"url" binding:"required,url"` // Public URL Airtable downloads the image from
+	Filename string `json:"filename"`
+}
+
+// photoFromRequest reads a photo from a multipart upload or a JSON URL
+// payload. On failure it writes the error response and returns false.
+func photoFromRequest(c *gin.Context) (airtable.AttachmentSource, bool) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		var payload photoURLPayload
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return airtable.AttachmentSource{}, false
+		}
+		return airtable.AttachmentSource{URL: payload.URL, Filename: payload.Filename}, true
+	}
+
+	// Leave room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, airtable.MaxUploadSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is larger than 5 MB"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		}
+		return airtable.AttachmentSource{}, false
+	}
+	defer file.Close()
+
+	photo, err := airtable.NewAttachmentSource(header.Filename, header.Header.Get("Content-Type"), file)
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is larger than 5 MB"})
+		return airtable.AttachmentSource{}, false
+	}
+	if !strings.HasPrefix(photo.ContentType, "image/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file must be an image"})
+		return airtable.AttachmentSource{}, false
+	}
+	return photo, true
+}
+
+// errInvalidSlug is returned for a path slug that normalizes to nothing.
+var errInvalidSlug = errors.New("invalid slug")
+
+// respondError writes err as a JSON error response with a status code derived
+// from its type: 404 for unknown locations, 400 for invalid slugs and queries and the Airtable mapping (422/503)
+// for failures reported by Airtable.
+func respondError(c *gin.Context, err error) {
+	var status int
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, tenant.ErrUnknown):
+		status = http.StatusNotFound
+	case errors.Is(err, errInvalidSlug), errors.Is(err, ErrInvalidQuery), errors.Is(err, ErrInvalidCoordinates):
+		status = http.StatusBadRequest
+	default:
+		status = airtable.HTTPStatus(err)
+	}
+
+	if retryAfter, ok := airtable.RetryAfter(err); ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -1,25 +1,75 @@
 package location
 
-import "time"
+import (
+	"time"
 
-// ToAirtableFieldsForCreate converts a Location to Airtable fields format for creation
-func (l *Location) ToAirtableFieldsForCreate() map[string]interface{} {
-	now := time.Now().Format(time.RFC3339)
-	return map[string]interface{}{
-		FieldName:     l.Name,
-		FieldSlug:     l.Slug,
-		FieldCreatedAt: now,
-		FieldUpdatedAt: now,
+	"lam-phuong-api/internal/airtable"
+)
+
+// AirtableSchema lists the fields the locations table must provide.
+func AirtableSchema(table string) airtable.TableRequirement {
+	text := []string{airtable.FieldTypeSingleLineText, airtable.FieldTypeMultilineText}
+	date := []string{airtable.FieldTypeDateTime, airtable.FieldTypeDate, airtable.FieldTypeSingleLineText}
+	number := []string{airtable.FieldTypeNumber}
+	return airtable.TableRequirement{
+		Table: table,
+		Fields: []airtable.FieldRequirement{
+			{Name: FieldName, Types: text},
+			{Name: FieldPublicID, Types: text},
+			{Name: FieldSlug, Types: text},
+			{Name: FieldLatitude, Types: number},
+			{Name: FieldLongitude, Types: number},
+			{Name: FieldPhotos, Types: []string{airtable.FieldTypeAttachments}, Optional: true},
+			{Name: FieldCreatedAt, Types: date},
+			{Name: FieldUpdatedAt, Types: date},
+		},
 	}
 }
 
-// ToAirtableFieldsForUpdate converts a Location to Airtable fields format for update
-func (l *Location) ToAirtableFieldsForUpdate() map[string]interface{} {
-	now := time.Now().Format(time.RFC3339)
+// SlugHistorySchema lists the fields the slug history table must provide.
+func SlugHistorySchema(table string) airtable.TableRequirement {
+	text := []string{airtable.FieldTypeSingleLineText, airtable.FieldTypeMultilineText}
+	return airtable.TableRequirement{
+		Table: table,
+		Fields: []airtable.FieldRequirement{
+			{Name: HistoryFieldSlug, Types: text},
+			{Name: HistoryFieldLocationID, Types: text},
+			{Name: HistoryFieldCreatedAt, Types: []string{airtable.FieldTypeDateTime, airtable.FieldTypeSingleLineText}},
+		},
+	}
+}
+
+// slugHistoryFields returns the fields of a slug history record saying that
+// the location with the given public ID was previously at oldSlug.
+func slugHistoryFields(oldSlug, id string) map[string]interface{} {
 	return map[string]interface{}{
-		FieldName:     l.Name,
-		FieldSlug:     l.Slug,
-		FieldUpdatedAt: now,
+		HistoryFieldSlug:       oldSlug,
+		HistoryFieldLocationID: id,
+		HistoryFieldCreatedAt:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"), // Fixed width, so text columns sort too
 	}
 }
 
+// ToAirtableFieldsForCreate converts a Location to Airtable fields format for creation
+func (l *Location) ToAirtableFieldsForCreate() (map[string]interface{}, error) {
+	fields, err := airtable.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	created := l.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+	fields[FieldCreatedAt] = created.Format(time.RFC3339)
+	fields[FieldUpdatedAt] = created.Format(time.RFC3339)
+	return fields, nil
+}
+
+// ToAirtableFieldsForUpdate converts a Location to Airtable fields format for update
+func (l *Location) ToAirtableFieldsForUpdate() (map[string]interface{}, error) {
+	fields, err := airtable.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	fields[FieldUpdatedAt] = time.Now().Format(time.RFC3339)
+	return fields, nil
+}
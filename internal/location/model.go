@@ -1,58 +1,49 @@
 package location
 
-import "fmt"
+import (
+	"time"
 
-// Airtable field names
+	"lam-phuong-api/internal/airtable"
+)
+
+// Airtable field names, as used in code. Each can be mapped to another column
+// name or a field ID with AIRTABLE_LOCATIONS_FIELDS
 const (
+	FieldPublicID  = "Public ID"
 	FieldName      = "Name"
 	FieldSlug      = "Slug"
+	FieldLatitude  = "Latitude"
+	FieldLongitude = "Longitude"
+	FieldPhotos    = "Photos"
 	FieldCreatedAt = "Created At"
 	FieldUpdatedAt = "Updated At"
 )
 
-// Helper functions
-func getStringField(fields map[string]interface{}, key string) string {
-	if val, ok := fields[key]; ok {
-		if str, ok := val.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
+// Airtable field names of the slug history table, which maps the previous
+// slugs of renamed locations to their public IDs.
+const (
+	HistoryFieldSlug       = "Slug"
+	HistoryFieldLocationID = "Location ID"
+	HistoryFieldCreatedAt  = "Created At"
+)
 
 // Location represents a physical place served by the API.
 type Location struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
-	Slug string `json:"slug"`
-}
-
-// ToAirtableFields converts a Location to Airtable fields format (for creation)
-// Deprecated: Use ToAirtableFieldsForCreate() instead
-func (l *Location) ToAirtableFields() map[string]interface{} {
-	return l.ToAirtableFieldsForCreate()
+	ID        string                `json:"id" airtable:"Public ID,omitempty"` // Stable public ID, see package publicid
+	RecordID  string                `json:"-" airtable:",id"`                  // Airtable record ID, never exposed
+	Name      string                `json:"name" airtable:"Name"`
+	Slug      string                `json:"slug" airtable:"Slug"`
+	Latitude  *float64              `json:"latitude,omitempty" airtable:"Latitude"` // Nil together with Longitude when unknown
+	Longitude *float64              `json:"longitude,omitempty" airtable:"Longitude"`
+	Photos    []airtable.Attachment `json:"photos,omitempty" airtable:"Photos,readonly"` // Managed through AddPhoto
+	CreatedAt time.Time             `json:"created_at,omitzero" airtable:"Created At,readonly"`
 }
 
 // FromAirtable maps an Airtable record to a Location.
-// The record should have an "id" field and a "fields" map.
-func FromAirtable(record map[string]interface{}) (*Location, error) {
-	// Safely extract ID
-	id := ""
-	if idVal, ok := record["id"]; ok {
-		if idStr, ok := idVal.(string); ok {
-			id = idStr
-		}
+func FromAirtable(record airtable.Record) (*Location, error) {
+	var location Location
+	if err := airtable.Unmarshal(record, &location); err != nil {
+		return nil, err
 	}
-
-	// Safely extract fields
-	fields, ok := record["fields"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid record: missing or invalid 'fields'")
-	}
-
-	return &Location{
-		ID:   id,
-		Name: getStringField(fields, FieldName),
-		Slug: getStringField(fields, FieldSlug),
-	}, nil
+	return &location, nil
 }
@@ -0,0 +1,227 @@
+package location
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+// Sort orders accepted by Query.Sort. A leading "-" sorts in descending order.
+const (
+	SortName      = "name"
+	SortSlug      = "slug"
+	SortCreatedAt = "created_at"
+	SortRelevance = "relevance" // Best search matches first, only with Query.Q
+)
+
+// Page sizes of Query.Limit.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ErrInvalidQuery is returned for a query with an unknown sort order or a
+// malformed cursor.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Query selects, orders and pages the locations returned by Repository.Find.
+type Query struct {
+	Q             string    // Search terms, matched against names and slugs ignoring case and diacritics
+	Name          string    // Case-insensitive substring of the name
+	Slug          string    // Exact slug
+	CreatedAfter  time.Time // Zero means no lower bound
+	CreatedBefore time.Time // Zero means no upper bound
+	Sort          string    // Sort order such as "name" or "-created_at"; empty sorts by relevance with Q, else by creation time
+	Limit         int       // Page size, 0 means DefaultPageSize
+	Cursor        string    // NextCursor of the previous page, empty for the first page
+}
+
+// Page is one page of the locations matching a Query.
+type Page struct {
+	Locations  []Location `json:"data"`
+	Total      int        `json:"total"`                 // Locations matching the query across all pages
+	NextCursor string     `json:"next_cursor,omitempty"` // Cursor of the next page, empty on the last page
+}
+
+// NearbyQuery selects the locations around a point returned by
+// Repository.Nearby.
+type NearbyQuery struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64 // Great-circle distance from the point, 0 means DefaultNearbyRadiusKm
+	Limit     int     // Maximum number of locations, 0 means DefaultNearbyLimit
+}
+
+// NearbyLocation is a location found by Repository.Nearby, with its distance
+// from the point searched around.
+type NearbyLocation struct {
+	Location
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// cursor is the decoded form of Page.NextCursor: the sort key and ID of the
+// last location on a page. Pages continue after it even when locations are
+// added or deleted in between.
+type cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   string `json:"id"`
+}
+
+// sortOrder returns the field and direction of q.Sort.
+func (q Query) sortOrder() (field string, desc bool, err error) {
+	field = strings.TrimPrefix(q.Sort, "-")
+	desc = field != q.Sort
+	switch field {
+	case SortName, SortSlug, SortCreatedAt:
+		return field, desc, nil
+	case SortRelevance:
+		if q.Q == "" {
+			return "", false, fmt.Errorf("%w: sort order %q needs search terms", ErrInvalidQuery, q.Sort)
+		}
+		return field, desc, nil
+	case "":
+		if desc {
+			break
+		}
+		if q.Q != "" {
+			return SortRelevance, false, nil
+		}
+		return SortCreatedAt, false, nil
+	}
+	return "", false, fmt.Errorf("%w: unknown sort order %q, use %s, %s, %s or %s with an optional leading -",
+		ErrInvalidQuery, q.Sort, SortName, SortSlug, SortCreatedAt, SortRelevance)
+}
+
+// matches reports whether l meets the filters of q.
+func (q Query) matches(l Location) bool {
+	switch {
+	case q.Name != "" && !strings.Contains(strings.ToLower(l.Name), strings.ToLower(q.Name)):
+		return false
+	case q.Slug != "" && l.Slug != q.Slug:
+		return false
+	case !q.CreatedAfter.IsZero() && !l.CreatedAt.After(q.CreatedAfter):
+		return false
+	case !q.CreatedBefore.IsZero() && !l.CreatedAt.Before(q.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+// formula returns the filters of q as an Airtable formula.
+func (q Query) formula() airtable.Formula {
+	var conditions []airtable.Formula
+	if q.Name != "" {
+		conditions = append(conditions, airtable.Search(strings.ToLower(q.Name), airtable.Lower(airtable.Field(FieldName))))
+	}
+	if q.Slug != "" {
+		conditions = append(conditions, airtable.Field(FieldSlug).Eq(q.Slug))
+	}
+	if !q.CreatedAfter.IsZero() {
+		conditions = append(conditions, airtable.IsAfter(airtable.Field(FieldCreatedAt), q.CreatedAfter))
+	}
+	if !q.CreatedBefore.IsZero() {
+		conditions = append(conditions, airtable.IsBefore(airtable.Field(FieldCreatedAt), q.CreatedBefore))
+	}
+	return airtable.And(conditions...)
+}
+
+// sortKey returns the value of field that locations are ordered by, as a
+// string that sorts the same way. Relevance sorts by the search scores,
+// highest first, then by name.
+func sortKey(l Location, field string, scores map[string]int) string {
+	switch field {
+	case SortRelevance:
+		return fmt.Sprintf("%06d %s", maxSearchScore-scores[l.ID], strings.ToLower(l.Name))
+	case SortName:
+		return strings.ToLower(l.Name)
+	case SortSlug:
+		return l.Slug
+	default:
+		return l.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000000Z")
+	}
+}
+
+// compareKeys orders locations by key, then by ID, so every location has a
+// unique position.
+func compareKeys(keyA, idA, keyB, idB string, desc bool) int {
+	c := strings.Compare(keyA, keyB)
+	if c == 0 {
+		c = strings.Compare(idA, idB)
+	}
+	if desc {
+		return -c
+	}
+	return c
+}
+
+// sortLocations sorts locations by field, then by ID. Scores are the search
+// scores of the locations, needed only to sort by relevance.
+func sortLocations(locations []Location, field string, desc bool, scores map[string]int) {
+	sort.Slice(locations, func(i, j int) bool {
+		return compareKeys(sortKey(locations[i], field, scores), locations[i].ID,
+			sortKey(locations[j], field, scores), locations[j].ID, desc) < 0
+	})
+}
+
+// paginate sorts the locations matching q and returns the page q asks for.
+// Scores are the search scores of the locations when q has search terms.
+func paginate(locations []Location, q Query, scores map[string]int) (Page, error) {
+	field, desc, err := q.sortOrder()
+	if err != nil {
+		return Page{}, err
+	}
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if locations == nil {
+		locations = []Location{}
+	}
+	sortLocations(locations, field, desc, scores)
+
+	start := 0
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		if after.Sort != q.Sort {
+			return Page{}, fmt.Errorf("%w: cursor belongs to sort order %q", ErrInvalidQuery, after.Sort)
+		}
+		start = sort.Search(len(locations), func(i int) bool {
+			return compareKeys(sortKey(locations[i], field, scores), locations[i].ID, after.Key, after.ID, desc) > 0
+		})
+	}
+
+	end := min(start+limit, len(locations))
+	page := Page{Locations: locations[start:end], Total: len(locations)}
+	if end < len(locations) {
+		last := locations[end-1]
+		page.NextCursor = encodeCursor(cursor{Sort: q.Sort, Key: sortKey(last, field, scores), ID: last.ID})
+	}
+	return page, nil
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.ID == "" {
+		return cursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return c, nil
+}
@@ -0,0 +1,221 @@
+package location
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// numberedLocations returns locations with IDs "1" to "n", created one
+// minute apart in that order.
+func numberedLocations(n int) []Location {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	locations := make([]Location, n)
+	for i := range locations {
+		locations[i] = Location{
+			ID:        fmt.Sprint(i + 1),
+			Name:      fmt.Sprintf("Location %c", 'A'+i),
+			Slug:      fmt.Sprintf("location-%d", i+1),
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return locations
+}
+
+func ids(locations []Location) string {
+	parts := make([]string, len(locations))
+	for i, l := range locations {
+		parts[i] = l.ID
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	want := cursor{Sort: "-created_at", Key: "2024-01-01T00:00:00.000000000Z", ID: "10"}
+	got, err := decodeCursor(encodeCursor(want))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if got != want {
+		t.Errorf("decodeCursor = %+v, want %+v", got, want)
+	}
+}
+
+func TestDecodeCursorRejectsInvalid(t *testing.T) {
+	valid := encodeCursor(cursor{Sort: "name", Key: "location a", ID: "1"})
+	for name, value := range map[string]string{
+		"not base64": "not a cursor!",
+		"truncated":  valid[:len(valid)/2],
+		"not JSON":   "bm90IGpzb24",
+		"without ID": encodeCursor(cursor{Sort: "name", Key: "location a"}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeCursor(value); !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("decodeCursor error = %v, want ErrInvalidQuery", err)
+			}
+		})
+	}
+}
+
+func TestPaginateRejectsCursorOfOtherSort(t *testing.T) {
+	page, err := paginate(numberedLocations(5), Query{Sort: SortName, Limit: 2}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = paginate(numberedLocations(5), Query{Sort: "-" + SortCreatedAt, Limit: 2, Cursor: page.NextCursor}, nil)
+	if !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("paginate error = %v, want ErrInvalidQuery", err)
+	}
+}
+
+func TestSortOrderRejectsUnknown(t *testing.T) {
+	for _, q := range []Query{
+		{Sort: "price"},
+		{Sort: "-"},
+		{Sort: "--name"},
+		{Sort: "Name"},
+		{Sort: SortRelevance}, // Without search terms
+	} {
+		if _, _, err := q.sortOrder(); !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("sortOrder(%q) error = %v, want ErrInvalidQuery", q.Sort, err)
+		}
+	}
+}
+
+func TestPaginateSortsByCreationNotID(t *testing.T) {
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{"", "1,2,3,4,5,6,7,8,9,10,11,12"},
+		{SortCreatedAt, "1,2,3,4,5,6,7,8,9,10,11,12"},
+		{"-" + SortCreatedAt, "12,11,10,9,8,7,6,5,4,3,2,1"},
+		{SortName, "1,2,3,4,5,6,7,8,9,10,11,12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			// Page through five at a time
+			var got []Location
+			q := Query{Sort: tt.sort, Limit: 5}
+			for {
+				page, err := paginate(numberedLocations(12), q, nil)
+				if err != nil {
+					t.Fatalf("paginate: %v", err)
+				}
+				if page.Total != 12 {
+					t.Errorf("total = %d, want 12", page.Total)
+				}
+				got = append(got, page.Locations...)
+				if page.NextCursor == "" {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+			if ids(got) != tt.want {
+				t.Errorf("order = %s, want %s", ids(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestInMemoryListSortsByCreationNotID(t *testing.T) {
+	repo := NewInMemoryRepository(numberedLocations(12))
+	if got := ids(repo.List(context.Background())); got != "1,2,3,4,5,6,7,8,9,10,11,12" {
+		t.Errorf("List order = %s", got)
+	}
+}
+
+func TestQueryFormula(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{"no filters", Query{}, ""},
+		{"one filter", Query{Slug: "main"}, "{Slug} = 'main'"},
+		{
+			"all filters",
+			Query{
+				Name:          "Lâm's",
+				Slug:          "main",
+				CreatedAfter:  time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+				CreatedBefore: time.Date(2024, 2, 1, 7, 0, 0, 0, time.FixedZone("ICT", 7*3600)),
+			},
+			"AND(SEARCH('lâm\\'s', LOWER({Name})), {Slug} = 'main', " +
+				"IS_AFTER({Created At}, DATETIME_PARSE('2024-01-31T00:00:00Z')), " +
+				"IS_BEFORE({Created At}, DATETIME_PARSE('2024-02-01T00:00:00Z')))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.formula().String(); got != tt.want {
+				t.Errorf("formula = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// linkPattern matches one link of a Link header.
+var linkPattern = regexp.MustCompile(`<([^>]*)>; rel="([a-z]+)"`)
+
+// links returns the URLs of a Link header keyed by relation.
+func links(header string) map[string]string {
+	result := make(map[string]string)
+	for _, m := range linkPattern.FindAllStringSubmatch(header, -1) {
+		result[m[2]] = m[1]
+	}
+	return result
+}
+
+func TestListLocationsLinkHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(NewInMemoryRepository(numberedLocations(5))).RegisterRoutes(router.Group("/api"))
+
+	get := func(target string) (*httptest.ResponseRecorder, map[string]string) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", target, rec.Code, rec.Body)
+		}
+		return rec, links(rec.Header().Get("Link"))
+	}
+
+	_, first := get("/api/locations?sort=-created_at&limit=2")
+	if first["first"] != "/api/locations?limit=2&sort=-created_at" {
+		t.Errorf("first = %q", first["first"])
+	}
+	if !strings.Contains(first["next"], "cursor=") || !strings.Contains(first["next"], "sort=-created_at") {
+		t.Fatalf("next = %q, want a cursor and the sort order", first["next"])
+	}
+
+	// Follow the next links to the last page
+	var seen int
+	for next := first["next"]; next != ""; seen++ {
+		rec, l := get(next)
+		if l["first"] != first["first"] {
+			t.Errorf("first on a later page = %q, want %q", l["first"], first["first"])
+		}
+		if seen > 5 {
+			t.Fatalf("no last page after %d pages: %s", seen, rec.Body)
+		}
+		next = l["next"]
+	}
+	if seen != 2 {
+		t.Errorf("pages after the first = %d, want 2", seen)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/locations?sort=price", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown sort = %d, want 400", rec.Code)
+	}
+}
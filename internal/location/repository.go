@@ -2,51 +2,68 @@ package location
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
 	"sort"
-	"strconv"
-	"strings"
 	"sync"
+	"time"
 
 	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/publicid"
 )
 
+// ErrNotFound is returned when no location matches the requested ID or slug.
+var ErrNotFound = errors.New("location not found")
+
 // Repository defines behavior for storing and retrieving locations.
 type Repository interface {
-	List() []Location
+	List(ctx context.Context) []Location
+	Find(ctx context.Context, query Query) (Page, error)
+	Nearby(ctx context.Context, query NearbyQuery) ([]NearbyLocation, error)
+	Get(ctx context.Context, id string) (Location, error)
+	GetBySlug(ctx context.Context, slug string) (Location, error)
+	GetByOldSlug(ctx context.Context, slug string) (Location, error)
 	Create(ctx context.Context, location Location) (Location, error)
-	DeleteBySlug(slug string) bool
+	Update(ctx context.Context, id string, location Location) (Location, error)
+	DeleteBySlug(ctx context.Context, slug string) error
+	AddPhoto(ctx context.Context, slug string, photo airtable.AttachmentSource) (Location, error)
 }
 
-// InMemoryRepository stores locations in memory and is safe for concurrent access.
+// InMemoryRepository stores locations in memory, keyed by public ID, and is
+// safe for concurrent access. A search index and a spatial index of the
+// locations are kept up to date with every change.
 type InMemoryRepository struct {
-	mu     sync.RWMutex
-	data   map[string]Location
-	nextID int
+	mu      sync.RWMutex
+	data    map[string]Location
+	history map[string]string // Previous slug to the ID of the location that last had it
+	index   *searchIndex
+	geo     *geoIndex
 }
 
-// NewInMemoryRepository creates an in-memory repository seeded with optional data.
+// NewInMemoryRepository creates an in-memory repository seeded with optional
+// data. Seed locations without an ID are assigned one.
 func NewInMemoryRepository(seed []Location) *InMemoryRepository {
 	repo := &InMemoryRepository{
-		data:   make(map[string]Location),
-		nextID: 1,
+		data:    make(map[string]Location),
+		history: make(map[string]string),
+		index:   newSearchIndex(),
+		geo:     newGeoIndex(),
 	}
 
-	maxID := 0
 	for _, l := range seed {
-		repo.data[l.ID] = l
-		if id, err := strconv.Atoi(l.ID); err == nil && id > maxID {
-			maxID = id
+		if l.ID == "" {
+			l.ID = publicid.New()
 		}
+		repo.data[l.ID] = l
+		repo.index.add(l)
+		repo.geo.add(l)
 	}
-	repo.nextID = maxID + 1
 
 	return repo
 }
 
-// List returns all locations sorted by ID.
-func (r *InMemoryRepository) List() []Location {
+// List returns all locations in creation order.
+func (r *InMemoryRepository) List(ctx context.Context) []Location {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -55,28 +72,147 @@ func (r *InMemoryRepository) List() []Location {
 		locations = append(locations, location)
 	}
 
-	sort.Slice(locations, func(i, j int) bool {
-		return locations[i].ID < locations[j].ID
+	sortLocations(locations, SortCreatedAt, false, nil)
+	return locations
+}
+
+// Find returns the page of locations matching query. Search terms are looked
+// up in the search index.
+func (r *InMemoryRepository) Find(ctx context.Context, query Query) (Page, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var scores map[string]int
+	if query.Q != "" {
+		scores = r.index.search(query.Q)
+	}
+
+	var matched []Location
+	for id, location := range r.data {
+		if scores != nil {
+			if _, ok := scores[id]; !ok {
+				continue
+			}
+		}
+		if query.matches(location) {
+			matched = append(matched, location)
+		}
+	}
+	return paginate(matched, query, scores)
+}
+
+// Nearby returns the locations within query.RadiusKm of a point, nearest
+// first. Candidates are looked up in the spatial index.
+func (r *InMemoryRepository) Nearby(ctx context.Context, query NearbyQuery) ([]NearbyLocation, error) {
+	radius := query.RadiusKm
+	if radius <= 0 {
+		radius = DefaultNearbyRadiusKm
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultNearbyLimit
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	nearby := []NearbyLocation{}
+	for id, distance := range r.geo.within(query.Latitude, query.Longitude, radius) {
+		nearby = append(nearby, NearbyLocation{Location: r.data[id], DistanceKm: distance})
+	}
+	sort.Slice(nearby, func(i, j int) bool {
+		if nearby[i].DistanceKm != nearby[j].DistanceKm {
+			return nearby[i].DistanceKm < nearby[j].DistanceKm
+		}
+		return nearby[i].ID < nearby[j].ID
 	})
+	return nearby[:min(limit, len(nearby))], nil
+}
 
-	return locations
+// Get retrieves a location by its public ID.
+func (r *InMemoryRepository) Get(ctx context.Context, id string) (Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	location, exists := r.data[id]
+	if !exists {
+		return Location{}, ErrNotFound
+	}
+	return location, nil
+}
+
+// GetBySlug retrieves a location by its slug.
+func (r *InMemoryRepository) GetBySlug(ctx context.Context, slug string) (Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, location := range r.data {
+		if location.Slug == slug {
+			return location, nil
+		}
+	}
+	return Location{}, ErrNotFound
+}
+
+// GetByOldSlug retrieves the location that was renamed away from slug.
+func (r *InMemoryRepository) GetByOldSlug(ctx context.Context, slug string) (Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	location, exists := r.data[r.history[slug]]
+	if !exists || location.Slug == slug {
+		return Location{}, ErrNotFound
+	}
+	return location, nil
 }
 
-// Create adds a new location and automatically assigns an ID.
+// Create adds a new location, assigning a public ID unless it already has one.
 // Note: ctx parameter is for interface compatibility but not used in in-memory implementation.
 func (r *InMemoryRepository) Create(ctx context.Context, location Location) (Location, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	location.ID = strconv.Itoa(r.nextID)
-	r.nextID++
+	if location.ID == "" {
+		location.ID = publicid.New()
+	}
+	if location.CreatedAt.IsZero() {
+		location.CreatedAt = time.Now()
+	}
 	r.data[location.ID] = location
+	r.index.add(location)
+	r.geo.add(location)
 
 	return location, nil
 }
 
+// Update replaces the name, slug and coordinates of the location with the
+// given public ID. A previous slug is kept in the slug history.
+func (r *InMemoryRepository) Update(ctx context.Context, id string, location Location) (Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.data[id]
+	if !exists {
+		return Location{}, ErrNotFound
+	}
+
+	// Preserve IDs, photos (managed through AddPhoto) and creation time
+	location.ID = id
+	location.RecordID = existing.RecordID
+	location.Photos = existing.Photos
+	location.CreatedAt = existing.CreatedAt
+
+	if location.Slug != existing.Slug {
+		r.history[existing.Slug] = id
+	}
+	r.data[id] = location
+	r.index.add(location)
+	r.geo.add(location)
+	return location, nil
+}
+
 // DeleteBySlug removes a location by its slug.
-func (r *InMemoryRepository) DeleteBySlug(slug string) bool {
+func (r *InMemoryRepository) DeleteBySlug(ctx context.Context, slug string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -89,11 +225,86 @@ func (r *InMemoryRepository) DeleteBySlug(slug string) bool {
 	}
 
 	if targetID == "" {
-		return false
+		return ErrNotFound
 	}
 
 	delete(r.data, targetID)
-	return true
+	r.index.remove(targetID)
+	r.geo.remove(targetID)
+	return nil
+}
+
+// AddPhoto appends a photo to the location with the given slug. Without
+// Airtable there is nowhere to store uploaded content, so only the photo's
+// metadata is kept.
+func (r *InMemoryRepository) AddPhoto(ctx context.Context, slug string, photo airtable.AttachmentSource) (Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, loc := range r.data {
+		if loc.Slug != slug {
+			continue
+		}
+		loc.Photos = append(loc.Photos, airtable.Attachment{
+			URL:      photo.URL,
+			Filename: photo.Filename,
+			Size:     int64(len(photo.Content)),
+			Type:     photo.ContentType,
+		})
+		r.data[id] = loc
+		return loc, nil
+	}
+
+	return Location{}, ErrNotFound
+}
+
+// ApplyAirtableChanges updates the cache with locations created, edited or
+// deleted directly in Airtable. It has the signature of airtable.ChangeHandler.
+// Records without a public ID are skipped until one is assigned.
+func (r *InMemoryRepository) ApplyAirtableChanges(ctx context.Context, changes airtable.RecordChanges) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, record := range changes.Upserted {
+		loc, err := FromAirtable(record)
+		if err != nil {
+			log.Printf("Skipping Airtable record %s due to mapping error: %v", record.ID, err)
+			continue
+		}
+		if loc.ID == "" {
+			log.Printf("Skipping Airtable record %s without a public ID", record.ID)
+			continue
+		}
+
+		// Keep slugs changed in Airtable resolving, like those changed through the API
+		if existing, ok := r.data[loc.ID]; ok && existing.Slug != loc.Slug {
+			r.history[existing.Slug] = loc.ID
+		}
+
+		// Replace the copy cached under the record's previous public ID, if it was edited
+		r.deleteRecord(record.ID)
+		r.data[loc.ID] = *loc
+		r.index.add(*loc)
+		r.geo.add(*loc)
+	}
+
+	for _, recordID := range changes.Deleted {
+		r.deleteRecord(recordID)
+	}
+
+	return nil
+}
+
+// deleteRecord removes the location stored in the given Airtable record. The
+// caller must hold r.mu.
+func (r *InMemoryRepository) deleteRecord(recordID string) {
+	for id, loc := range r.data {
+		if loc.RecordID == recordID {
+			delete(r.data, id)
+			r.index.remove(id)
+			r.geo.remove(id)
+		}
+	}
 }
 
 // AirtableRepository wraps a Repository and adds Airtable persistence.
@@ -101,6 +312,8 @@ type AirtableRepository struct {
 	repo           Repository
 	airtableClient *airtable.Client
 	airtableTable  string
+	queue          *airtable.WriteQueue // Nil rejects writes while Airtable is unavailable
+	historyTable   string               // Slug history table, empty keeps the history in memory only
 }
 
 // NewAirtableRepository creates a repository that syncs to Airtable.
@@ -112,34 +325,238 @@ func NewAirtableRepository(repo Repository, airtableClient *airtable.Client, air
 	}
 }
 
+// SetWriteQueue makes writes that fail because the Airtable circuit breaker
+// is open succeed locally and be replayed once Airtable recovers, instead of
+// being rejected.
+func (r *AirtableRepository) SetWriteQueue(queue *airtable.WriteQueue) {
+	r.queue = queue
+}
+
+// SetSlugHistoryTable sets the Airtable table the previous slugs of renamed
+// locations are saved to, so they keep redirecting after a restart.
+func (r *AirtableRepository) SetSlugHistoryTable(table string) {
+	r.historyTable = table
+}
+
 // List returns all locations from the underlying repository.
-func (r *AirtableRepository) List() []Location {
-	records, err := r.airtableClient.ListRecords(context.Background(), r.airtableTable, nil)
+func (r *AirtableRepository) List(ctx context.Context) []Location {
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, nil)
 	if err != nil {
 		log.Printf("Failed to list locations from Airtable: %v", err)
-		return r.repo.List()
+		return r.repo.List(ctx)
+	}
+
+	// Locations added in the Airtable UI have no public ID until first listed
+	if _, err := publicid.Assign(ctx, r.airtableClient, r.airtableTable, FieldPublicID, records); err != nil {
+		log.Printf("Failed to assign public IDs to locations: %v", err)
 	}
 
 	locations := make([]Location, 0, len(records))
 	for _, record := range records {
-		loc, err := mapAirtableRecord(record)
+		loc, err := FromAirtable(record)
 		if err != nil {
 			log.Printf("Skipping Airtable record due to mapping error: %v", err)
 			continue
 		}
-		locations = append(locations, loc)
+		if loc.ID == "" {
+			continue // Assigning its public ID failed
+		}
+		locations = append(locations, *loc)
 	}
 
 	// If Airtable returns no records, fall back to underlying repository
 	if len(locations) == 0 {
-		return r.repo.List()
+		return r.repo.List(ctx)
 	}
 
 	return locations
 }
 
+// Find returns the page of locations matching query. Filters are applied by
+// Airtable, but every matching record is listed for each page: the total
+// count needs them all, and Airtable has no cursor that survives records
+// being added. They are sorted and paged in memory, so no sort is sent to
+// Airtable. Searches are answered by the search index of the underlying
+// repository, which Airtable formulas cannot match diacritics-insensitively
+// or rank; see LoadCache.
+func (r *AirtableRepository) Find(ctx context.Context, query Query) (Page, error) {
+	if _, _, err := query.sortOrder(); err != nil {
+		return Page{}, err
+	}
+	if query.Q != "" {
+		return r.repo.Find(ctx, query)
+	}
+
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, &airtable.ListParams{
+		FilterByFormula: query.formula().String(),
+	})
+	if err != nil {
+		log.Printf("Failed to query locations from Airtable: %v", err)
+		return r.repo.Find(ctx, query)
+	}
+
+	if _, err := publicid.Assign(ctx, r.airtableClient, r.airtableTable, FieldPublicID, records); err != nil {
+		log.Printf("Failed to assign public IDs to locations: %v", err)
+	}
+
+	locations := make([]Location, 0, len(records))
+	for _, record := range records {
+		loc, err := FromAirtable(record)
+		if err != nil {
+			log.Printf("Skipping Airtable record due to mapping error: %v", err)
+			continue
+		}
+		if loc.ID == "" {
+			continue // Assigning its public ID failed
+		}
+		locations = append(locations, *loc)
+	}
+	return paginate(locations, query, nil)
+}
+
+// Nearby returns the locations within query.RadiusKm of a point, nearest
+// first. Airtable has no spatial queries, so it is answered by the spatial
+// index of the underlying repository; see LoadCache.
+func (r *AirtableRepository) Nearby(ctx context.Context, query NearbyQuery) ([]NearbyLocation, error) {
+	return r.repo.Nearby(ctx, query)
+}
+
+// LoadCache stores every location in Airtable in the underlying repository,
+// so that searches and nearby lookups find locations not created or synced
+// through this process. Changes made afterwards reach the cache through the
+// repository, webhooks or the syncer.
+func (r *AirtableRepository) LoadCache(ctx context.Context) error {
+	cache, ok := r.repo.(interface {
+		ApplyAirtableChanges(ctx context.Context, changes airtable.RecordChanges) error
+	})
+	if !ok {
+		return nil
+	}
+
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := publicid.Assign(ctx, r.airtableClient, r.airtableTable, FieldPublicID, records); err != nil {
+		log.Printf("Failed to assign public IDs to locations: %v", err)
+	}
+	return cache.ApplyAirtableChanges(ctx, airtable.RecordChanges{
+		Table:    r.airtableTable,
+		Upserted: records,
+	})
+}
+
+// Get retrieves a location by its public ID from Airtable, falling back to
+// the underlying repository.
+func (r *AirtableRepository) Get(ctx context.Context, id string) (Location, error) {
+	if !publicid.Valid(id) {
+		return Location{}, ErrNotFound
+	}
+
+	loc, err := r.findOne(ctx, airtable.Field(FieldPublicID).Eq(id))
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Printf("Failed to get location %s from Airtable: %v", id, err)
+		}
+		if cached, repoErr := r.repo.Get(ctx, id); repoErr == nil {
+			return cached, nil
+		}
+		return Location{}, err
+	}
+	return loc, nil
+}
+
+// GetBySlug retrieves a location by its slug from Airtable, falling back to
+// the underlying repository.
+func (r *AirtableRepository) GetBySlug(ctx context.Context, slug string) (Location, error) {
+	loc, err := r.findOne(ctx, airtable.Field(FieldSlug).Eq(slug))
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Printf("Failed to query Airtable for slug %s: %v", slug, err)
+		}
+		if cached, repoErr := r.repo.GetBySlug(ctx, slug); repoErr == nil {
+			return cached, nil
+		}
+		return Location{}, err
+	}
+	return loc, nil
+}
+
+// GetByOldSlug retrieves the location that was most recently renamed away
+// from slug, according to the slug history table, falling back to the
+// underlying repository.
+func (r *AirtableRepository) GetByOldSlug(ctx context.Context, slug string) (Location, error) {
+	if r.historyTable == "" {
+		return r.repo.GetByOldSlug(ctx, slug)
+	}
+
+	records, err := r.airtableClient.ListRecords(ctx, r.historyTable, &airtable.ListParams{
+		MaxRecords:      1,
+		FilterByFormula: airtable.Field(HistoryFieldSlug).Eq(slug).String(),
+		Sort:            []airtable.SortParam{{Field: HistoryFieldCreatedAt, Direction: "desc"}},
+	})
+	if err != nil {
+		log.Printf("Failed to query slug history for %s: %v", slug, err)
+	}
+	if err != nil || len(records) == 0 {
+		// Slugs changed in the Airtable UI are only known to the cache
+		cached, repoErr := r.repo.GetByOldSlug(ctx, slug)
+		if repoErr != nil && err != nil {
+			return Location{}, err
+		}
+		return cached, repoErr
+	}
+
+	id, _ := records[0].Fields[HistoryFieldLocationID].(string)
+	loc, err := r.Get(ctx, id)
+	if err != nil {
+		return Location{}, err
+	}
+	if loc.Slug == slug {
+		return Location{}, ErrNotFound
+	}
+	return loc, nil
+}
+
+// findOne returns the first location matching filter, assigning it a public
+// ID if it has none yet.
+func (r *AirtableRepository) findOne(ctx context.Context, filter airtable.Formula) (Location, error) {
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, &airtable.ListParams{
+		MaxRecords:      1,
+		FilterByFormula: filter.String(),
+	})
+	if err != nil {
+		return Location{}, err
+	}
+	if len(records) == 0 {
+		return Location{}, ErrNotFound
+	}
+
+	if _, err := publicid.Assign(ctx, r.airtableClient, r.airtableTable, FieldPublicID, records); err != nil {
+		return Location{}, err
+	}
+	loc, err := FromAirtable(records[0])
+	if err != nil {
+		return Location{}, err
+	}
+	return *loc, nil
+}
+
 // Create adds a new location to the repository and syncs it to Airtable.
+// If Airtable rejects the location, the local copy is removed again and the error is returned.
 func (r *AirtableRepository) Create(ctx context.Context, location Location) (Location, error) {
+	if location.ID == "" {
+		location.ID = publicid.New()
+	}
+	if location.CreatedAt.IsZero() {
+		// Airtable keeps whole seconds, so the cache matches what it stores
+		location.CreatedAt = time.Now().Truncate(time.Second)
+	}
+	airtableFields, err := location.ToAirtableFieldsForCreate()
+	if err != nil {
+		return Location{}, err
+	}
+
 	// Create in the underlying repository first
 	created, err := r.repo.Create(ctx, location)
 	if err != nil {
@@ -147,41 +564,173 @@ func (r *AirtableRepository) Create(ctx context.Context, location Location) (Loc
 	}
 
 	// Save to Airtable
-	airtableFields := created.ToAirtableFieldsForCreate()
 	log.Printf("Attempting to save location to Airtable table: %s", r.airtableTable)
 	airtableRecord, err := r.airtableClient.CreateRecord(ctx, r.airtableTable, airtableFields)
+	if err != nil && r.queueable(err) {
+		queueErr := r.queue.Enqueue("create location "+created.Slug, func(ctx context.Context) error {
+			record, err := r.airtableClient.CreateRecord(ctx, r.airtableTable, airtableFields)
+			if err != nil {
+				return err
+			}
+			r.cacheRecord(ctx, record)
+			return nil
+		})
+		if queueErr == nil {
+			return created, nil
+		}
+		err = queueErr
+	}
 	if err != nil {
-		// Log error but don't fail - location is already created in repo
 		log.Printf("Failed to save location to Airtable: %v", err)
 		log.Printf("Error details - Table: %s, Fields: %+v", r.airtableTable, airtableFields)
-		return created, nil // Return created location even if Airtable save failed
+		if deleteErr := r.repo.DeleteBySlug(ctx, created.Slug); deleteErr != nil {
+			log.Printf("Failed to roll back location %s: %v", created.Slug, deleteErr)
+		}
+		return Location{}, err
 	}
 
-	// Update the created location with Airtable ID
-	created.ID = airtableRecord.ID
-	log.Printf("Location saved to Airtable successfully with ID: %s", airtableRecord.ID)
+	// Remember the Airtable record ID; the public ID stays the same
+	created.RecordID = airtableRecord.ID
+	r.cacheRecord(ctx, airtableRecord)
+	log.Printf("Location %s saved to Airtable successfully with record ID: %s", created.ID, airtableRecord.ID)
 	return created, nil
 }
 
-// DeleteBySlug removes a location by its slug.
-func (r *AirtableRepository) DeleteBySlug(slug string) bool {
+// Update replaces the name, slug and coordinates of the location with the
+// given public ID and syncs it to Airtable. When the slug changes, the previous one is saved
+// to the slug history table. If Airtable rejects the update, the local copy is
+// restored and the error is returned.
+func (r *AirtableRepository) Update(ctx context.Context, id string, location Location) (Location, error) {
+	existing, err := r.repo.Get(ctx, id)
+	cached := err == nil
+	if !cached {
+		existing, err = r.Get(ctx, id)
+		if err != nil {
+			return Location{}, err
+		}
+	}
+
+	// Preserve IDs, photos (managed through AddPhoto) and creation time
+	location.ID = id
+	location.RecordID = existing.RecordID
+	location.Photos = existing.Photos
+	location.CreatedAt = existing.CreatedAt
+
+	airtableFields, err := location.ToAirtableFieldsForUpdate()
+	if err != nil {
+		return Location{}, err
+	}
+
+	// Update in the underlying repository first
+	updated := location
+	if cached {
+		updated, err = r.repo.Update(ctx, id, location)
+		if err != nil {
+			return Location{}, err
+		}
+	}
+
+	// Update in Airtable (partial update - only changed fields)
+	log.Printf("Attempting to update location in Airtable table: %s", r.airtableTable)
+	err = r.updateInAirtable(ctx, id, existing.RecordID, airtableFields)
+	if err != nil && cached && r.queueable(err) {
+		queueErr := r.queue.Enqueue("update location "+updated.Slug, func(ctx context.Context) error {
+			if err := r.updateInAirtable(ctx, id, existing.RecordID, airtableFields); err != nil {
+				return err
+			}
+			return r.saveOldSlug(ctx, existing.Slug, updated)
+		})
+		if queueErr == nil {
+			return updated, nil
+		}
+		err = queueErr
+	}
+	if err != nil {
+		log.Printf("Failed to update location in Airtable: %v", err)
+		log.Printf("Error details - Table: %s, ID: %s, Fields: %+v", r.airtableTable, id, airtableFields)
+		if cached {
+			if _, restoreErr := r.repo.Update(ctx, id, existing); restoreErr != nil {
+				log.Printf("Failed to restore location %s: %v", id, restoreErr)
+			}
+		}
+		if errors.Is(err, airtable.ErrNotFound) {
+			return Location{}, ErrNotFound
+		}
+		return Location{}, err
+	}
+
+	if err := r.saveOldSlug(ctx, existing.Slug, updated); err != nil {
+		log.Printf("Failed to save previous slug %s of location %s: %v", existing.Slug, id, err)
+	}
+	log.Printf("Location updated in Airtable successfully with ID: %s", id)
+	return updated, nil
+}
+
+// updateInAirtable writes fields to the Airtable record of the location with
+// the given public ID. recordID may be empty when the record ID is not known
+// yet, for example for a location whose creation was queued.
+func (r *AirtableRepository) updateInAirtable(ctx context.Context, id, recordID string, fields map[string]interface{}) error {
+	if recordID == "" {
+		loc, err := r.findOne(ctx, airtable.Field(FieldPublicID).Eq(id))
+		if err != nil {
+			return err
+		}
+		recordID = loc.RecordID
+	}
+	_, err := r.airtableClient.UpdateRecordPartial(ctx, r.airtableTable, recordID, fields)
+	return err
+}
+
+// saveOldSlug adds oldSlug to the slug history table if the update changed
+// the location's slug.
+func (r *AirtableRepository) saveOldSlug(ctx context.Context, oldSlug string, updated Location) error {
+	if r.historyTable == "" || oldSlug == updated.Slug {
+		return nil
+	}
+	_, err := r.airtableClient.CreateRecord(ctx, r.historyTable, slugHistoryFields(oldSlug, updated.ID))
+	return err
+}
+
+// DeleteBySlug removes a location by its slug from Airtable and the underlying repository.
+func (r *AirtableRepository) DeleteBySlug(ctx context.Context, slug string) error {
+	deleted, err := r.deleteFromAirtable(ctx, slug)
+	if err != nil && r.queueable(err) {
+		if repoErr := r.repo.DeleteBySlug(ctx, slug); repoErr != nil {
+			return repoErr
+		}
+		queueErr := r.queue.Enqueue("delete location "+slug, func(ctx context.Context) error {
+			_, err := r.deleteFromAirtable(ctx, slug)
+			return err
+		})
+		if queueErr == nil {
+			return nil
+		}
+		err = queueErr
+	}
+	if err != nil {
+		return err
+	}
+
 	// Delete from underlying repository
-	deleted := r.repo.DeleteBySlug(slug)
+	repoErr := r.repo.DeleteBySlug(ctx, slug)
+	if deleted == 0 {
+		return repoErr
+	}
+
+	return nil
+}
 
-	// Attempt to delete from Airtable
-	filterValue := escapeAirtableFormulaValue(slug)
+// deleteFromAirtable deletes the Airtable records with the given slug and
+// returns how many were deleted.
+func (r *AirtableRepository) deleteFromAirtable(ctx context.Context, slug string) (int, error) {
 	params := &airtable.ListParams{
-		FilterByFormula: fmt.Sprintf("{%s} = '%s'", FieldSlug, filterValue),
+		FilterByFormula: airtable.Field(FieldSlug).Eq(slug).String(),
 	}
 
-	records, err := r.airtableClient.ListRecords(context.Background(), r.airtableTable, params)
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, params)
 	if err != nil {
 		log.Printf("Failed to query Airtable for slug %s: %v", slug, err)
-		return deleted
-	}
-
-	if len(records) == 0 {
-		return deleted
+		return 0, err
 	}
 
 	ids := make([]string, 0, len(records))
@@ -189,21 +738,80 @@ func (r *AirtableRepository) DeleteBySlug(slug string) bool {
 		ids = append(ids, record.ID)
 	}
 
-	if err := r.airtableClient.BulkDeleteRecords(context.Background(), r.airtableTable, ids); err != nil {
+	result, err := r.airtableClient.BulkDeleteRecords(ctx, r.airtableTable, ids)
+	if err != nil {
 		log.Printf("Failed to delete Airtable records for slug %s: %v", slug, err)
+		for id, failure := range result.Failed {
+			log.Printf("Error details - Table: %s, ID: %s, Error: %v", r.airtableTable, id, failure)
+		}
+		return 0, err
+	}
+	if len(result.Missing) > 0 {
+		log.Printf("Airtable records for slug %s were already deleted: %v", slug, result.Missing)
 	}
 
-	return deleted || len(ids) > 0
+	return len(result.Deleted), nil
 }
 
-func mapAirtableRecord(record airtable.Record) (Location, error) {
-	return Location{
-		ID:     record.ID,
-		Name:   getStringField(record.Fields, FieldName),
-		Slug:   getStringField(record.Fields, FieldSlug),
-	}, nil
+// AddPhoto attaches a photo to the location's Photos field in Airtable and
+// returns the updated location.
+func (r *AirtableRepository) AddPhoto(ctx context.Context, slug string, photo airtable.AttachmentSource) (Location, error) {
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, &airtable.ListParams{
+		MaxRecords:      1,
+		FilterByFormula: airtable.Field(FieldSlug).Eq(slug).String(),
+	})
+	if err != nil {
+		log.Printf("Failed to query Airtable for slug %s: %v", slug, err)
+		return Location{}, err
+	}
+	if len(records) == 0 {
+		return Location{}, ErrNotFound
+	}
+	recordID := records[0].ID
+
+	attachment, err := r.airtableClient.Attach(ctx, r.airtableTable, recordID, FieldPhotos, photo)
+	if err != nil {
+		log.Printf("Failed to attach photo to location %s: %v", slug, err)
+		return Location{}, err
+	}
+	log.Printf("Photo %s attached to location %s", attachment.ID, slug)
+
+	record, err := r.airtableClient.GetRecord(ctx, r.airtableTable, recordID)
+	if err != nil {
+		return Location{}, err
+	}
+	updated, err := FromAirtable(record)
+	if err != nil {
+		return Location{}, err
+	}
+
+	// Keep the cache in step; it only stores the photo's URL and metadata
+	if _, err := r.repo.AddPhoto(ctx, slug, airtable.AttachmentSource{URL: attachment.URL, Filename: attachment.Filename}); err != nil && !errors.Is(err, ErrNotFound) {
+		log.Printf("Failed to cache photo for location %s: %v", slug, err)
+	}
+
+	return *updated, nil
+}
+
+// queueable reports whether a failed write should be queued rather than
+// returned: a write queue is set and the circuit breaker is open.
+func (r *AirtableRepository) queueable(err error) bool {
+	return r.queue != nil && errors.Is(err, airtable.ErrCircuitOpen)
 }
 
-func escapeAirtableFormulaValue(value string) string {
-	return strings.ReplaceAll(value, "'", "''")
+// cacheRecord stores a record written to Airtable in the underlying
+// repository, so the cached copy knows its record ID.
+func (r *AirtableRepository) cacheRecord(ctx context.Context, record airtable.Record) {
+	cache, ok := r.repo.(interface {
+		ApplyAirtableChanges(ctx context.Context, changes airtable.RecordChanges) error
+	})
+	if !ok {
+		return
+	}
+	if err := cache.ApplyAirtableChanges(ctx, airtable.RecordChanges{
+		Table:    r.airtableTable,
+		Upserted: []airtable.Record{record},
+	}); err != nil {
+		log.Printf("Failed to cache replayed Airtable record %s: %v", record.ID, err)
+	}
 }
@@ -0,0 +1,64 @@
+package location_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lam-phuong-api/internal/airtable/airtabletest"
+	"lam-phuong-api/internal/location"
+)
+
+func TestAirtableRepositoryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	srv := airtabletest.NewServer()
+	defer srv.Close()
+	client, err := srv.NewClient("appTest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := location.NewAirtableRepository(location.NewInMemoryRepository(nil), client, "Locations")
+
+	created, err := repo.Create(ctx, location.Location{Name: "Lâm Phương", Slug: "lam-phuong"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	records := srv.Records("Locations")
+	if len(records) != 1 || records[0].Fields[location.FieldPublicID] != created.ID {
+		t.Fatalf("Airtable records = %v, want one with public ID %s", records, created.ID)
+	}
+
+	// A repository with an empty cache reads the location back from Airtable
+	fresh := location.NewAirtableRepository(location.NewInMemoryRepository(nil), client, "Locations")
+	got, err := fresh.GetBySlug(ctx, "lam-phuong")
+	if err != nil {
+		t.Fatalf("GetBySlug: %v", err)
+	}
+	if got.ID != created.ID || got.Name != "Lâm Phương" {
+		t.Errorf("GetBySlug = %+v, want %+v", got, created)
+	}
+
+	if _, err := repo.Update(ctx, created.ID, location.Location{Name: "Lâm Phương 2", Slug: "lam-phuong-2"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = fresh.Get(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Name != "Lâm Phương 2" || got.Slug != "lam-phuong-2" {
+		t.Errorf("Get after update = %+v", got)
+	}
+	if list := fresh.List(ctx); len(list) != 1 || list[0].ID != created.ID {
+		t.Errorf("List = %+v, want the created location", list)
+	}
+
+	if err := repo.DeleteBySlug(ctx, "lam-phuong-2"); err != nil {
+		t.Fatalf("DeleteBySlug: %v", err)
+	}
+	if n := len(srv.Records("Locations")); n != 0 {
+		t.Errorf("Airtable records after delete = %d, want 0", n)
+	}
+	if _, err := fresh.Get(ctx, created.ID); !errors.Is(err, location.ErrNotFound) {
+		t.Errorf("Get after delete error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,185 @@
+package location
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gosimple/unidecode"
+)
+
+// Weights of the fields a term was found in, and the scores of a query term
+// matching an indexed term exactly or as a prefix.
+const (
+	weightName = 3
+	weightSlug = 1
+
+	scoreExact  = 10
+	scorePrefix = 4
+
+	scorePhrase      = 20 // Query terms appear in order in the name
+	scoreNamePrefix  = 20 // The name starts with the query terms
+	scoreExactPhrase = 40 // The name is exactly the query terms
+
+	maxSearchTerms = 32 // Query terms beyond this are ignored
+	maxSearchScore = scoreExact*weightName*maxSearchTerms + scorePhrase + scoreNamePrefix + scoreExactPhrase
+)
+
+// searchIndex is an inverted index of location names and slugs. Terms are
+// folded to lowercase ASCII, so "lam phuong" finds "Lâm Phương" and
+// "chi nhanh" finds "Chi nhánh". It is not safe for concurrent use; the
+// InMemoryRepository guards it with its own mutex.
+type searchIndex struct {
+	postings map[string]map[string]int // Term to the IDs of the locations it is in, with its weight in each
+	terms    []string                  // Sorted keys of postings, for prefix lookups
+	docs     map[string]indexedDoc     // Location ID to what was indexed for it
+}
+
+// indexedDoc is what the index keeps about a location to remove or rank it.
+type indexedDoc struct {
+	terms []string
+	name  string // Folded name terms joined by single spaces
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[string]int),
+		docs:     make(map[string]indexedDoc),
+	}
+}
+
+// fold lowercases s and transliterates it to ASCII, dropping diacritics.
+func fold(s string) string {
+	return strings.ToLower(unidecode.Unidecode(s))
+}
+
+// tokenize splits s into folded terms at every character that is not a
+// letter or digit.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(fold(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// add indexes l, replacing what was indexed for it before.
+func (idx *searchIndex) add(l Location) {
+	idx.remove(l.ID)
+
+	weights := make(map[string]int)
+	for _, term := range tokenize(l.Slug) {
+		weights[term] = weightSlug
+	}
+	nameTerms := tokenize(l.Name)
+	for _, term := range nameTerms {
+		weights[term] = weightName
+	}
+
+	doc := indexedDoc{name: strings.Join(nameTerms, " ")}
+	for term, weight := range weights {
+		ids, ok := idx.postings[term]
+		if !ok {
+			ids = make(map[string]int)
+			idx.postings[term] = ids
+			idx.insertTerm(term)
+		}
+		ids[l.ID] = weight
+		doc.terms = append(doc.terms, term)
+	}
+	idx.docs[l.ID] = doc
+}
+
+// remove drops the location with the given ID from the index.
+func (idx *searchIndex) remove(id string) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range doc.terms {
+		ids := idx.postings[term]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx.postings, term)
+			idx.deleteTerm(term)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+func (idx *searchIndex) insertTerm(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	idx.terms = append(idx.terms, "")
+	copy(idx.terms[i+1:], idx.terms[i:])
+	idx.terms[i] = term
+}
+
+func (idx *searchIndex) deleteTerm(term string) {
+	i := sort.SearchStrings(idx.terms, term)
+	if i < len(idx.terms) && idx.terms[i] == term {
+		idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+	}
+}
+
+// search returns the IDs of the locations matching every term of q, with
+// their scores. A query term matches indexed terms it equals or is a prefix
+// of, so results show up while the last word is still being typed. Exact
+// matches, matches in the name and names that contain the query as a phrase
+// score higher. A query without terms matches nothing.
+func (idx *searchIndex) search(q string) map[string]int {
+	queryTerms := tokenize(q)
+	if len(queryTerms) == 0 {
+		return map[string]int{}
+	}
+	if len(queryTerms) > maxSearchTerms {
+		queryTerms = queryTerms[:maxSearchTerms]
+	}
+
+	var scores map[string]int
+	for _, queryTerm := range queryTerms {
+		termScores := idx.match(queryTerm)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id, score := range scores {
+			if termScore, ok := termScores[id]; ok {
+				scores[id] = score + termScore
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	phrase := strings.Join(queryTerms, " ")
+	for id := range scores {
+		name := idx.docs[id].name
+		switch {
+		case name == phrase:
+			scores[id] += scoreExactPhrase + scoreNamePrefix + scorePhrase
+		case strings.HasPrefix(name, phrase):
+			scores[id] += scoreNamePrefix + scorePhrase
+		case strings.Contains(name, phrase):
+			scores[id] += scorePhrase
+		}
+	}
+	return scores
+}
+
+// match returns the IDs of the locations with a term that queryTerm equals or
+// is a prefix of, with the score of their best matching term.
+func (idx *searchIndex) match(queryTerm string) map[string]int {
+	scores := make(map[string]int)
+	for i := sort.SearchStrings(idx.terms, queryTerm); i < len(idx.terms); i++ {
+		term := idx.terms[i]
+		if !strings.HasPrefix(term, queryTerm) {
+			break
+		}
+		score := scorePrefix
+		if term == queryTerm {
+			score = scoreExact
+		}
+		for id, weight := range idx.postings[term] {
+			scores[id] = max(scores[id], score*weight)
+		}
+	}
+	return scores
+}
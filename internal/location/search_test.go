@@ -0,0 +1,150 @@
+package location
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := map[string]string{
+		"Lâm Phương":  "lam phuong",
+		"Đà Nẵng":     "da nang",
+		"đường Láng":  "duong lang",
+		"Chi nhánh 2": "chi nhanh 2",
+		"ASCII":       "ascii",
+	}
+	for in, want := range tests {
+		if got := fold(in); got != want {
+			t.Errorf("fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Lâm Phương - chi-nhánh/Đống Đa, số 2")
+	want := []string{"lam", "phuong", "chi", "nhanh", "dong", "da", "so", "2"}
+	if len(got) != len(want) {
+		t.Fatalf("tokenize = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("tokenize = %q, want %q", got, want)
+		}
+	}
+}
+
+// newTestIndex indexes locations with the given IDs and names.
+func newTestIndex(names map[string]string) *searchIndex {
+	idx := newSearchIndex()
+	for id, name := range names {
+		idx.add(Location{ID: id, Name: name})
+	}
+	return idx
+}
+
+// matchedIDs returns the IDs found by a search, sorted.
+func matchedIDs(scores map[string]int) []string {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSearchIgnoresDiacriticsAndMatchesPrefixes(t *testing.T) {
+	idx := newTestIndex(map[string]string{
+		"1": "Lâm Phương",
+		"2": "Chi nhánh Đống Đa",
+		"3": "Nhà sách",
+	})
+
+	tests := []struct {
+		q    string
+		want string
+	}{
+		{"lam phuong", "[1]"},
+		{"LÂM", "[1]"},
+		{"chi nh", "[2]"},
+		{"dong da", "[2]"},
+		{"nh", "[2 3]"},
+		{"lam nha", "[]"}, // Every term must match
+		{"  ", "[]"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(matchedIDs(idx.search(tt.q))); got != tt.want {
+			t.Errorf("search(%q) = %s, want %s", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestSearchRanksExactMatchesFirst(t *testing.T) {
+	idx := newSearchIndex()
+	idx.add(Location{ID: "prefix", Name: "Lamington"})
+	idx.add(Location{ID: "exact", Name: "Lam"})
+	idx.add(Location{ID: "slug", Name: "Other", Slug: "lam"})
+
+	scores := idx.search("lam")
+	order := []string{"exact", "prefix", "slug"}
+	for i := 1; i < len(order); i++ {
+		if scores[order[i-1]] <= scores[order[i]] {
+			t.Errorf("score of %s (%d) not above %s (%d)", order[i-1], scores[order[i-1]], order[i], scores[order[i]])
+		}
+	}
+}
+
+func TestSearchIndexUpdatesIncrementally(t *testing.T) {
+	idx := newSearchIndex()
+	idx.add(Location{ID: "1", Name: "Lâm Phương", Slug: "lam-phuong"})
+	idx.add(Location{ID: "2", Name: "Hoa Sen", Slug: "hoa-sen"})
+
+	// Renaming replaces every term of the old name and slug
+	idx.add(Location{ID: "1", Name: "Bạch Mai", Slug: "bach-mai"})
+	if got := matchedIDs(idx.search("lam")); len(got) != 0 {
+		t.Errorf("search for the old name = %v, want nothing", got)
+	}
+	if got := fmt.Sprint(matchedIDs(idx.search("bach"))); got != "[1]" {
+		t.Errorf("search for the new name = %s, want [1]", got)
+	}
+
+	idx.remove("1")
+	if got := matchedIDs(idx.search("bach")); len(got) != 0 {
+		t.Errorf("search after remove = %v, want nothing", got)
+	}
+	idx.remove("2")
+	if len(idx.terms) != 0 || len(idx.postings) != 0 || len(idx.docs) != 0 {
+		t.Errorf("index after removing everything: %d terms, %d postings, %d docs", len(idx.terms), len(idx.postings), len(idx.docs))
+	}
+}
+
+func TestInMemoryFindSearches(t *testing.T) {
+	repo := NewInMemoryRepository([]Location{
+		{ID: "1", Name: "Lâm Phương", Slug: "lam-phuong"},
+		{ID: "2", Name: "Chi nhánh Lâm Đồng", Slug: "lam-dong"},
+	})
+
+	page, err := repo.Find(t.Context(), Query{Q: "lam phuong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids(page.Locations) != "1" {
+		t.Errorf("Find = %s, want 1", ids(page.Locations))
+	}
+
+	// Renaming through Update reindexes the location
+	if _, err := repo.Update(t.Context(), "2", Location{Name: "Chi nhánh Phương Mai", Slug: "phuong-mai"}); err != nil {
+		t.Fatal(err)
+	}
+	page, err = repo.Find(t.Context(), Query{Q: "phuong"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 {
+		t.Errorf("Find after rename = %s, want both", ids(page.Locations))
+	}
+	page, _ = repo.Find(t.Context(), Query{Q: "dong"})
+	if page.Total != 0 {
+		t.Errorf("Find for the old name = %s, want nothing", ids(page.Locations))
+	}
+}
@@ -0,0 +1,112 @@
+package location
+
+import (
+	"context"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/tenant"
+)
+
+// TenantRepository serves each call from the repository of the tenant in
+// the request context, so the location handler can work with several
+// Airtable bases.
+type TenantRepository struct {
+	repos *tenant.Registry[Repository]
+}
+
+// NewTenantRepository creates a repository from the repositories of each
+// tenant, keyed by tenant name.
+func NewTenantRepository(repos map[string]Repository) *TenantRepository {
+	return &TenantRepository{repos: tenant.NewRegistry(repos)}
+}
+
+// List returns the tenant's locations, or none for an unknown tenant.
+func (r *TenantRepository) List(ctx context.Context) []Location {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return []Location{}
+	}
+	return repo.List(ctx)
+}
+
+// Find returns a page of the tenant's locations.
+func (r *TenantRepository) Find(ctx context.Context, query Query) (Page, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	return repo.Find(ctx, query)
+}
+
+// Nearby returns the locations around a point from the tenant's repository.
+func (r *TenantRepository) Nearby(ctx context.Context, query NearbyQuery) ([]NearbyLocation, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Nearby(ctx, query)
+}
+
+// Get retrieves a location by public ID from the tenant's repository.
+func (r *TenantRepository) Get(ctx context.Context, id string) (Location, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return Location{}, err
+	}
+	return repo.Get(ctx, id)
+}
+
+// GetBySlug retrieves a location by slug from the tenant's repository.
+func (r *TenantRepository) GetBySlug(ctx context.Context, slug string) (Location, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return Location{}, err
+	}
+	return repo.GetBySlug(ctx, slug)
+}
+
+// GetByOldSlug retrieves a renamed location by a previous slug from the
+// tenant's repository.
+func (r *TenantRepository) GetByOldSlug(ctx context.Context, slug string) (Location, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return Location{}, err
+	}
+	return repo.GetByOldSlug(ctx, slug)
+}
+
+// Create adds a location to the tenant's repository.
+func (r *TenantRepository) Create(ctx context.Context, location Location) (Location, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return Location{}, err
+	}
+	return repo.Create(ctx, location)
+}
+
+// Update updates a location in the tenant's repository.
+func (r *TenantRepository) Update(ctx context.Context, id string, location Location) (Location, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return Location{}, err
+	}
+	return repo.Update(ctx, id, location)
+}
+
+// DeleteBySlug removes a location from the tenant's repository.
+func (r *TenantRepository) DeleteBySlug(ctx context.Context, slug string) error {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.DeleteBySlug(ctx, slug)
+}
+
+// AddPhoto adds a photo to a location in the tenant's repository.
+func (r *TenantRepository) AddPhoto(ctx context.Context, slug string, photo airtable.AttachmentSource) (Location, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return Location{}, err
+	}
+	return repo.AddPhoto(ctx, slug, photo)
+}
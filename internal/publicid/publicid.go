@@ -0,0 +1,122 @@
+// Package publicid generates the stable public IDs of API resources. Public
+// IDs are stored in an Airtable field and are the only IDs the API exposes,
+// so they do not change when a record moves between bases or caches.
+//
+// IDs are ULIDs: 26 characters of Crockford base32 encoding a 48-bit
+// millisecond timestamp followed by 80 random bits, so they sort by creation
+// time, e.g. "01J9ZQ4D5XK3M7W2B8R6T0VNCE".
+package publicid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+// Length is the number of characters in a public ID.
+const Length = 26
+
+const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// New returns a new public ID.
+func New() string {
+	return newAt(time.Now())
+}
+
+func newAt(t time.Time) string {
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(t.UnixMilli())<<16)
+	_, _ = rand.Read(id[6:])
+
+	// Encode the 128 bits five at a time, most significant first; the first
+	// character carries only the top three bits
+	var b strings.Builder
+	b.Grow(Length)
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	for i := Length - 1; i >= 0; i-- {
+		shift := uint(5 * i)
+		var v uint64
+		switch {
+		case shift >= 64:
+			v = hi >> (shift - 64)
+		case shift > 59:
+			v = hi<<(64-shift) | lo>>shift
+		default:
+			v = lo >> shift
+		}
+		b.WriteByte(alphabet[v&0x1f])
+	}
+	return b.String()
+}
+
+// Valid reports whether id is a well-formed public ID.
+func Valid(id string) bool {
+	if len(id) != Length || id[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if strings.IndexByte(alphabet, id[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Assign gives the records among records whose field is blank a new public
+// ID, saving it to Airtable and setting it on the records in place. It
+// returns how many records were assigned an ID.
+func Assign(ctx context.Context, client *airtable.Client, table, field string, records []airtable.Record) (int, error) {
+	var inputs []airtable.RecordInput
+	var indexes []int
+	for i, record := range records {
+		if id, _ := record.Fields[field].(string); id != "" {
+			continue
+		}
+		inputs = append(inputs, airtable.RecordInput{
+			ID:     record.ID,
+			Fields: map[string]interface{}{field: New()},
+		})
+		indexes = append(indexes, i)
+	}
+	if len(inputs) == 0 {
+		return 0, nil
+	}
+
+	results, err := client.UpdateRecords(ctx, table, inputs)
+	assigned := 0
+	for i, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		if records[indexes[i]].Fields == nil {
+			records[indexes[i]].Fields = make(map[string]interface{})
+		}
+		records[indexes[i]].Fields[field] = inputs[i].Fields[field]
+		assigned++
+	}
+	if err != nil {
+		return assigned, fmt.Errorf("assign public IDs in %s: %w", table, err)
+	}
+	log.Printf("Assigned public IDs to %d records of Airtable table %s", assigned, table)
+	return assigned, nil
+}
+
+// Backfill assigns a public ID to every record of table whose field is blank,
+// such as records created in the Airtable UI or before public IDs existed.
+func Backfill(ctx context.Context, client *airtable.Client, table, field string) (int, error) {
+	records, err := client.ListRecords(ctx, table, &airtable.ListParams{
+		FilterByFormula: airtable.Field(field).Blank().String(),
+		Fields:          []string{field},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("find records without public IDs in %s: %w", table, err)
+	}
+	return Assign(ctx, client, table, field, records)
+}
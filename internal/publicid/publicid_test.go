@@ -0,0 +1,164 @@
+package publicid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+	"time"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/airtable/airtabletest"
+)
+
+func TestNewEncodesTimestamp(t *testing.T) {
+	tests := []struct {
+		millis int64
+		prefix string
+	}{
+		{0, "0000000000"},
+		{1469918176385, "01ARYZ6S41"}, // Example of the ULID specification
+		{1<<48 - 1, "7ZZZZZZZZZ"},
+	}
+	for _, tt := range tests {
+		id := newAt(time.UnixMilli(tt.millis))
+		if id[:10] != tt.prefix {
+			t.Errorf("newAt(%d) = %s, want the prefix %s", tt.millis, id, tt.prefix)
+		}
+		if !Valid(id) {
+			t.Errorf("newAt(%d) = %s, not valid", tt.millis, id)
+		}
+	}
+}
+
+func TestNewSortsByCreationTime(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = newAt(start.Add(time.Duration(i) * time.Millisecond))
+	}
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("IDs created in order do not sort in order: %v", ids)
+	}
+
+	// IDs of the same millisecond differ in their random part
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := newAt(start)
+		if seen[id] {
+			t.Fatalf("duplicate ID %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := map[string]bool{
+		New():                         true,
+		"01ARYZ6S41TSV4RRFFQ69G5FAV":  true,
+		"01ARYZ6S41TSV4RRFFQ69G5FA":   false, // Too short
+		"01ARYZ6S41TSV4RRFFQ69G5FAVX": false, // Too long
+		"01aryz6s41tsv4rrffq69g5fav":  false, // Lowercase
+		"01ARYZ6S41TSV4RRFFQ69G5FAU":  false, // U is not in the alphabet
+		"01ARYZ6S41TSV4RRFFQ69G5FAI":  false,
+		"81ARYZ6S41TSV4RRFFQ69G5FAV":  false, // Timestamp overflows 48 bits
+		"":                            false,
+		"01ARYZ6S41-SV4RRFFQ69G5FAV":  false,
+	}
+	for id, want := range tests {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
+
+// newTestClient returns a fake Airtable server and a client of it.
+func newTestClient(t *testing.T) (*airtabletest.Server, *airtable.Client) {
+	t.Helper()
+	srv := airtabletest.NewServer()
+	t.Cleanup(srv.Close)
+	client, err := srv.NewClient("appTest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, client
+}
+
+func TestBackfillAssignsBlankIDsInBatches(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+
+	existing := New()
+	fields := []map[string]interface{}{{"Name": "Has an ID", "Public ID": existing}}
+	for i := 0; i < 23; i++ {
+		fields = append(fields, map[string]interface{}{"Name": fmt.Sprintf("Location %d", i)})
+	}
+	srv.AddRecords("Locations", fields...)
+
+	assigned, err := Backfill(ctx, client, "Locations", "Public ID")
+	if err != nil {
+		t.Fatalf("Backfill: %v", err)
+	}
+	if assigned != 23 {
+		t.Errorf("assigned = %d, want 23", assigned)
+	}
+
+	// Airtable takes at most 10 records per write
+	var patches int
+	for _, req := range srv.Requests() {
+		if req.Method == http.MethodPatch {
+			patches++
+		}
+	}
+	if patches != 3 {
+		t.Errorf("PATCH requests = %d, want 3", patches)
+	}
+
+	seen := make(map[string]bool)
+	for _, record := range srv.Records("Locations") {
+		id, _ := record.Fields["Public ID"].(string)
+		if !Valid(id) || seen[id] {
+			t.Errorf("record %s has the public ID %q", record.Fields["Name"], id)
+		}
+		seen[id] = true
+		if record.Fields["Name"] == "Has an ID" && id != existing {
+			t.Errorf("existing public ID replaced by %s", id)
+		}
+	}
+
+	// Nothing is left to assign
+	if assigned, err := Backfill(ctx, client, "Locations", "Public ID"); err != nil || assigned != 0 {
+		t.Errorf("second Backfill = %d, %v, want 0", assigned, err)
+	}
+}
+
+func TestAssignReportsFailedBatches(t *testing.T) {
+	ctx := context.Background()
+	srv, client := newTestClient(t)
+
+	fields := make([]map[string]interface{}, 15)
+	for i := range fields {
+		fields[i] = map[string]interface{}{"Name": fmt.Sprintf("Location %d", i)}
+	}
+	records := srv.AddRecords("Locations", fields...)
+	srv.InjectFault(airtabletest.Fault{Method: http.MethodPatch, StatusCode: http.StatusUnprocessableEntity, Times: 1})
+
+	assigned, err := Assign(ctx, client, "Locations", "Public ID", records)
+	if err == nil {
+		t.Fatal("Assign succeeded with a failed batch")
+	}
+	if assigned != 5 {
+		t.Errorf("assigned = %d, want the 5 records of the second batch", assigned)
+	}
+	// Only the records that were saved get their ID in place
+	var withID int
+	for _, record := range records {
+		if id, _ := record.Fields["Public ID"].(string); id != "" {
+			withID++
+		}
+	}
+	if withID != 5 {
+		t.Errorf("records with an ID = %d, want 5", withID)
+	}
+}
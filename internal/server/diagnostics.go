@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/tenant"
+)
+
+// DiagnosticsHandler exposes the state of backing services to administrators.
+type DiagnosticsHandler struct {
+	schemaCheckers map[string]*airtable.SchemaChecker // Keyed by tenant
+	syncers        map[string]*airtable.Syncer        // Keyed by tenant
+	metrics        *airtable.Metrics
+}
+
+// NewDiagnosticsHandler creates a diagnostics handler with the schema checker
+// and change syncer of each tenant. schemaCheckers is empty when the schema
+// check is disabled and syncers when change polling is.
+func NewDiagnosticsHandler(schemaCheckers map[string]*airtable.SchemaChecker, syncers map[string]*airtable.Syncer, metrics *airtable.Metrics) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		schemaCheckers: schemaCheckers,
+		syncers:        syncers,
+		metrics:        metrics,
+	}
+}
+
+// AirtableSchema godoc
+// @Summary      Airtable schema diagnostics
+// @Description  Report whether the Airtable tables and fields of the caller's tenant exist with compatible types (requires admin role)
+// @Tags         diagnostics
+// @Produce      json
+// @Security     BearerAuth
+// @Param        refresh  query     bool  false  "Run the check again instead of returning the last result"
+// @Success      200      {object}  airtable.SchemaReport
+// @Failure      401      {object}  map[string]string
+// @Failure      403      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      503      {object}  airtable.SchemaReport
+// @Router       /diagnostics/airtable [get]
+func (h *DiagnosticsHandler) AirtableSchema(c *gin.Context) {
+	schemaChecker, ok := h.schemaCheckers[tenant.FromContext(c.Request.Context())]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Airtable schema check is disabled"})
+		return
+	}
+
+	report, ok := schemaChecker.Report()
+	if !ok || c.Query("refresh") == "true" {
+		report, _ = schemaChecker.Check(c.Request.Context())
+	}
+
+	status := http.StatusOK
+	if !report.OK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// AirtableMetrics godoc
+// @Summary      Airtable request metrics
+// @Description  Counters of the Airtable requests made since startup, per table and operation and per API route. A high max_airtable_calls for a route points to an N+1 request pattern (requires admin role)
+// @Tags         diagnostics
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  airtable.MetricsSnapshot
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /diagnostics/airtable/metrics [get]
+func (h *DiagnosticsHandler) AirtableMetrics(c *gin.Context) {
+	if h.metrics == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Airtable metrics are disabled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.metrics.Snapshot())
+}
+
+// AirtableSync godoc
+// @Summary      Airtable change polling status
+// @Description  Polling interval, high-water mark and lag of each table synced from Airtable for the caller's tenant (requires admin role)
+// @Tags         diagnostics
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  airtable.SyncReport
+// @Failure      401  {object}  map[string]string
+// @Failure      403  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /diagnostics/airtable/sync [get]
+func (h *DiagnosticsHandler) AirtableSync(c *gin.Context) {
+	syncer, ok := h.syncers[tenant.FromContext(c.Request.Context())]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Airtable change polling is disabled"})
+		return
+	}
+
+	c.JSON(http.StatusOK, syncer.Report())
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/tenant"
+)
+
+// Health statuses
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded" // Airtable is unavailable; reads are served from the in-memory caches
+)
+
+// HealthResponse is the body of the health endpoint.
+type HealthResponse struct {
+	Status   string                    `json:"status"`
+	Airtable *AirtableHealth           `json:"airtable,omitempty"` // Default tenant
+	Tenants  map[string]AirtableHealth `json:"tenants,omitempty"`  // Other tenants
+}
+
+// AirtableHealth reports the Airtable circuit breaker and what happens to writes while it is open.
+type AirtableHealth struct {
+	Circuit      airtable.BreakerStatus `json:"circuit"`
+	WriteMode    string                 `json:"write_mode"` // "reject" or "queue"
+	QueuedWrites int                    `json:"queued_writes"`
+}
+
+// HealthHandler reports whether the API and its backing services are healthy.
+type HealthHandler struct {
+	clients map[string]*airtable.Client     // Keyed by tenant
+	queues  map[string]*airtable.WriteQueue // Keyed by tenant; missing when writes are rejected
+}
+
+// NewHealthHandler creates a health handler for the Airtable client of each
+// tenant. A tenant has a queue when its writes are queued while Airtable is
+// unavailable instead of rejected.
+func NewHealthHandler(clients map[string]*airtable.Client, queues map[string]*airtable.WriteQueue) *HealthHandler {
+	return &HealthHandler{
+		clients: clients,
+		queues:  queues,
+	}
+}
+
+// Health responds with 200 while the API is serving, including in degraded
+// read-only mode, so load balancers keep routing reads to it. The status is
+// degraded when the circuit of any tenant is not closed.
+func (h *HealthHandler) Health(c *gin.Context) {
+	resp := HealthResponse{Status: HealthOK}
+
+	for name, client := range h.clients {
+		health := AirtableHealth{Circuit: client.BreakerStatus(), WriteMode: "reject"}
+		if queue, ok := h.queues[name]; ok {
+			health.WriteMode = "queue"
+			health.QueuedWrites = queue.Len()
+		}
+		if health.Circuit.State != airtable.BreakerClosed {
+			resp.Status = HealthDegraded
+		}
+
+		if name == tenant.Default {
+			resp.Airtable = &health
+			continue
+		}
+		if resp.Tenants == nil {
+			resp.Tenants = make(map[string]AirtableHealth)
+		}
+		resp.Tenants[name] = health
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lam-phuong-api/internal/airtable"
+)
+
+// airtableCallMetrics counts the Airtable requests made while serving each
+// request and records them per route in metrics. Requests that match no
+// route are not recorded.
+func airtableCallMetrics(metrics *airtable.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if metrics == nil {
+			c.Next()
+			return
+		}
+
+		ctx, tally := airtable.TrackCalls(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if route := c.FullPath(); route != "" {
+			metrics.ObserveRoute(c.Request.Method+" "+route, tally)
+		}
+	}
+}
@@ -9,7 +9,9 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
+	"lam-phuong-api/internal/airtable"
 	"lam-phuong-api/internal/location"
+	"lam-phuong-api/internal/tenant"
 	"lam-phuong-api/internal/user"
 )
 
@@ -22,7 +24,10 @@ type VersionInfo struct {
 }
 
 // NewRouter constructs a Gin engine configured with middleware and routes.
-func NewRouter(locationHandler *location.Handler, userHandler *user.Handler, jwtSecret string, version string,
+func NewRouter(locationHandler *location.Handler, userHandler *user.Handler, diagnosticsHandler *DiagnosticsHandler,
+	webhookHandler *WebhookHandler, healthHandler *HealthHandler, airtableMetrics *airtable.Metrics,
+	tenantResolver *tenant.Resolver,
+	jwtSecret string, version string,
 	commitHash string,
 	buildTime string) *gin.Engine {
 	router := gin.Default()
@@ -37,12 +42,14 @@ func NewRouter(locationHandler *location.Handler, userHandler *user.Handler, jwt
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Route requests to the tenant serving their host
+	router.Use(resolveTenant(tenantResolver))
+
+	// Count the Airtable requests made by each route
+	router.Use(airtableCallMetrics(airtableMetrics))
+
 	// ✅ THÊM HEALTH ENDPOINT
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ok",
-		})
-	})
+	router.GET("/health", healthHandler.Health)
 
 	// ✅ THÊM VERSION ENDPOINT
 	router.GET("/version", func(c *gin.Context) {
@@ -67,6 +74,9 @@ func NewRouter(locationHandler *location.Handler, userHandler *user.Handler, jwt
 		// Auth routes (public)
 		userHandler.RegisterRoutes(api)
 
+		// Airtable change notifications (public, authenticated by HMAC signature)
+		api.POST("/webhooks/airtable", webhookHandler.AirtableNotification)
+
 		// Protected routes (require authentication)
 		protected := api.Group("")
 		protected.Use(user.AuthMiddleware(jwtSecret))
@@ -78,6 +88,10 @@ func NewRouter(locationHandler *location.Handler, userHandler *user.Handler, jwt
 				adminRoutes.GET("/users", userHandler.ListUsers)
 				adminRoutes.POST("/users", userHandler.CreateUser)
 				adminRoutes.DELETE("/users/:id", userHandler.DeleteUser)
+				adminRoutes.PUT("/users/:id/avatar", userHandler.SetUserAvatar)
+				adminRoutes.GET("/diagnostics/airtable", diagnosticsHandler.AirtableSchema)
+				adminRoutes.GET("/diagnostics/airtable/metrics", diagnosticsHandler.AirtableMetrics)
+				adminRoutes.GET("/diagnostics/airtable/sync", diagnosticsHandler.AirtableSync)
 			}
 
 			// User update routes (super admin only)
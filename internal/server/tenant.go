@@ -0,0 +1,19 @@
+package server
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"lam-phuong-api/internal/tenant"
+)
+
+// resolveTenant stores the tenant serving the request's host in the request
+// context. Requests to other hosts are served by the tenant of their token,
+// or by the default tenant.
+func resolveTenant(resolver *tenant.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if name, ok := resolver.FromHost(c.Request.Host); ok {
+			c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), name))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/tenant"
+)
+
+const (
+	// maxWebhookBodyBytes caps notification bodies; Airtable sends a few hundred bytes.
+	maxWebhookBodyBytes = 64 << 10
+
+	// webhookSyncTimeout bounds fetching and applying the payloads of one notification.
+	webhookSyncTimeout = time.Minute
+)
+
+// WebhookHandler receives change notifications from Airtable.
+type WebhookHandler struct {
+	listeners map[string]*airtable.WebhookListener // Keyed by tenant
+}
+
+// NewWebhookHandler creates a webhook handler with the listener of each
+// tenant. listeners is empty when webhooks are not configured.
+func NewWebhookHandler(listeners map[string]*airtable.WebhookListener) *WebhookHandler {
+	return &WebhookHandler{
+		listeners: listeners,
+	}
+}
+
+// WebhookURL returns the notification URL registered for a tenant's
+// webhooks. Other tenants than the default one get a tenant query parameter,
+// so tenants sharing a base do not replace each other's webhooks.
+func WebhookURL(baseURL, tenantName string) string {
+	if tenantName == tenant.Default {
+		return baseURL
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	query := u.Query()
+	query.Set("tenant", tenantName)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// AirtableNotification godoc
+// @Summary      Receive an Airtable webhook notification
+// @Description  Called by Airtable when records change. The X-Airtable-Content-MAC signature is verified, then the changes are fetched and applied to the cache in the background.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        tenant                  query     string                        false "Tenant whose webhook sent the notification"
+// @Param        X-Airtable-Content-MAC  header    string                        true  "hmac-sha256=<hex HMAC of the body>"
+// @Param        notification            body      airtable.WebhookNotification  true  "Notification"
+// @Success      200                     {object}  map[string]string
+// @Failure      400                     {object}  map[string]string
+// @Failure      401                     {object}  map[string]string
+// @Failure      404                     {object}  map[string]string
+// @Router       /webhooks/airtable [post]
+func (h *WebhookHandler) AirtableNotification(c *gin.Context) {
+	tenantName := c.DefaultQuery("tenant", tenant.Default)
+	listener, ok := h.listeners[tenantName]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Airtable webhooks are not configured"})
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	webhookID, err := listener.Verify(body, c.GetHeader(airtable.WebhookMACHeader))
+	switch {
+	case errors.Is(err, airtable.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown webhook"})
+		return
+	case err != nil:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid webhook signature"})
+		return
+	}
+
+	// Airtable expects a quick response; the payloads are fetched afterwards
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookSyncTimeout)
+		defer cancel()
+
+		if err := listener.Sync(ctx, webhookID); err != nil {
+			log.Printf("Failed to sync Airtable webhook %s: %v", webhookID, err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, gin.H{"status": "accepted"})
+}
@@ -0,0 +1,29 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+)
+
+// Registry holds one value per tenant, such as the repository serving the
+// tenant's Airtable base, and looks up the value for a request.
+type Registry[T any] struct {
+	values map[string]T // Keyed by tenant name
+}
+
+// NewRegistry creates a registry from values keyed by tenant name.
+func NewRegistry[T any](values map[string]T) *Registry[T] {
+	return &Registry[T]{values: values}
+}
+
+// Get returns the value of the tenant in ctx. It returns ErrUnknown if the
+// tenant is not in the registry.
+func (r *Registry[T]) Get(ctx context.Context) (T, error) {
+	name := FromContext(ctx)
+	value, ok := r.values[name]
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%w: %s", ErrUnknown, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,35 @@
+package tenant_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lam-phuong-api/internal/tenant"
+)
+
+func TestRegistryGet(t *testing.T) {
+	registry := tenant.NewRegistry(map[string]string{
+		tenant.Default: "default base",
+		"hanoi":        "Hanoi base",
+	})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+		err  error
+	}{
+		{"no tenant", context.Background(), "default base", nil},
+		{"known tenant", tenant.NewContext(context.Background(), "hanoi"), "Hanoi base", nil},
+		{"unknown tenant", tenant.NewContext(context.Background(), "hue"), "", tenant.ErrUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := registry.Get(tt.ctx)
+			if got != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("Get = %q, %v; want %q, %v", got, err, tt.want, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package tenant identifies which tenant, and so which Airtable base, a
+// request is served from. The tenant travels in the request context: it is
+// resolved from the Host header before authentication and taken from the
+// token's claim afterwards.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+)
+
+// Default is the tenant configured by the top-level AIRTABLE_* settings. It
+// serves requests whose host matches no other tenant.
+const Default = "default"
+
+// ErrUnknown is returned when a request names a tenant that is not configured.
+var ErrUnknown = errors.New("unknown tenant")
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the tenant name.
+func NewContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// Lookup returns the tenant stored in ctx, if any.
+func Lookup(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(contextKey{}).(string)
+	return name, ok && name != ""
+}
+
+// FromContext returns the tenant stored in ctx, or Default.
+func FromContext(ctx context.Context) string {
+	if name, ok := Lookup(ctx); ok {
+		return name
+	}
+	return Default
+}
+
+// Resolver maps request hosts to tenants.
+type Resolver struct {
+	hosts map[string]string // Lowercase host without port to tenant
+}
+
+// NewResolver creates a resolver from the hosts of each tenant.
+func NewResolver(hostsByTenant map[string][]string) *Resolver {
+	r := &Resolver{hosts: make(map[string]string)}
+	for name, hosts := range hostsByTenant {
+		for _, host := range hosts {
+			r.hosts[normalizeHost(host)] = name
+		}
+	}
+	return r
+}
+
+// FromHost returns the tenant serving host, which may include a port.
+func (r *Resolver) FromHost(host string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	name, ok := r.hosts[normalizeHost(host)]
+	return name, ok
+}
+
+func normalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
@@ -1,11 +1,14 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"lam-phuong-api/internal/tenant"
 )
 
 // AuthMiddleware validates JWT tokens and sets user context
@@ -37,7 +40,21 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// Serve the request from the tenant the token was issued for. A host
+		// that belongs to another tenant must not be used to reach this one
+		tokenTenant := claims.Tenant
+		if tokenTenant == "" {
+			tokenTenant = tenant.Default
+		}
+		if hostTenant, ok := tenant.Lookup(c.Request.Context()); ok && hostTenant != tokenTenant {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token was issued for another tenant"})
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.NewContext(c.Request.Context(), tokenTenant))
+
 		// Set user information in context
+		c.Set("tenant", tokenTenant)
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
@@ -61,11 +78,15 @@ func (h *Handler) Login(c *gin.Context, jwtSecret string, tokenExpiry time.Durat
 	}
 
 	// Get user by email
-	user, ok := h.repo.GetByEmail(req.Email)
-	if !ok {
+	user, err := h.repo.GetByEmail(c.Request.Context(), req.Email)
+	if errors.Is(err, ErrNotFound) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password."})
 		return
 	}
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 
 	// Verify password
 	if !CheckPassword(user.Password, req.Password) {
@@ -74,7 +95,7 @@ func (h *Handler) Login(c *gin.Context, jwtSecret string, tokenExpiry time.Durat
 	}
 
 	// Generate JWT token
-	token, err := GenerateToken(user, jwtSecret, tokenExpiry)
+	token, err := GenerateToken(user, tenant.FromContext(c.Request.Context()), jwtSecret, tokenExpiry)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
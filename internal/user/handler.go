@@ -1,11 +1,17 @@
 package user
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/tenant"
 )
 
 // Handler exposes HTTP handlers for the user resource
@@ -42,7 +48,9 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 // @Success      201         {object}  TokenResponse
 // @Failure      400         {object}  map[string]string
 // @Failure      409         {object}  map[string]string
+// @Failure      422         {object}  map[string]string
 // @Failure      500         {object}  map[string]string
+// @Failure      503         {object}  map[string]string
 // @Router       /auth/register [post]
 func (h *Handler) RegisterHandler(c *gin.Context) {
 	var req RegisterRequest
@@ -52,11 +60,15 @@ func (h *Handler) RegisterHandler(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	_, exists := h.repo.GetByEmail(req.Email)
-	if exists {
+	_, err := h.repo.GetByEmail(c.Request.Context(), req.Email)
+	if err == nil {
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
 		return
 	}
+	if !errors.Is(err, ErrNotFound) {
+		respondError(c, err)
+		return
+	}
 
 	// Hash the password
 	hashedPassword, err := HashPassword(req.Password)
@@ -76,16 +88,16 @@ func (h *Handler) RegisterHandler(c *gin.Context) {
 	created, err := h.repo.Create(c.Request.Context(), user)
 	if err != nil {
 		// Check if it's a duplicate email error (race condition)
-		if strings.Contains(err.Error(), "already exists") {
+		if errors.Is(err, ErrEmailExists) {
 			c.JSON(http.StatusConflict, gin.H{"error": "Email already registered"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
 	// Generate JWT token for immediate use (auto-login)
-	token, err := GenerateToken(created, h.jwtSecret, h.tokenExpiry)
+	token, err := GenerateToken(created, tenant.FromContext(c.Request.Context()), h.jwtSecret, h.tokenExpiry)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
 		return
@@ -113,6 +125,7 @@ func (h *Handler) RegisterHandler(c *gin.Context) {
 // @Success      200         {object}  TokenResponse
 // @Failure      400         {object}  map[string]string
 // @Failure      401         {object}  map[string]string
+// @Failure      503         {object}  map[string]string
 // @Router       /auth/login [post]
 func (h *Handler) LoginHandler(c *gin.Context) {
 	h.Login(c, h.jwtSecret, h.tokenExpiry)
@@ -130,7 +143,7 @@ func (h *Handler) LoginHandler(c *gin.Context) {
 // @Failure      403  {object}  map[string]string
 // @Router       /users [get]
 func (h *Handler) ListUsers(c *gin.Context) {
-	users := h.repo.List()
+	users := h.repo.List(c.Request.Context())
 	// Remove passwords from response
 	for i := range users {
 		users[i].Password = ""
@@ -151,7 +164,9 @@ func (h *Handler) ListUsers(c *gin.Context) {
 // @Failure      401   {object}  map[string]string
 // @Failure      403   {object}  map[string]string
 // @Failure      409   {object}  map[string]string
+// @Failure      422   {object}  map[string]string
 // @Failure      500   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
 // @Router       /users [post]
 func (h *Handler) CreateUser(c *gin.Context) {
 	var payload createUserPayload
@@ -195,12 +210,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 	// Create in repository (repository handles Airtable sync if configured)
 	created, err := h.repo.Create(c.Request.Context(), user)
 	if err != nil {
-		// Check if it's a duplicate email error
-		if strings.Contains(err.Error(), "already exists") {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -221,6 +231,7 @@ func (h *Handler) CreateUser(c *gin.Context) {
 // @Failure      401  {object}  map[string]string
 // @Failure      403  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
 // @Router       /users/{id} [delete]
 func (h *Handler) DeleteUser(c *gin.Context) {
 	id := c.Param("id")
@@ -229,8 +240,8 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if ok := h.repo.Delete(id); !ok {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -251,7 +262,9 @@ func (h *Handler) DeleteUser(c *gin.Context) {
 // @Failure      401   {object}  map[string]string
 // @Failure      403   {object}  map[string]string
 // @Failure      404   {object}  map[string]string
+// @Failure      422   {object}  map[string]string
 // @Failure      500   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
 // @Router       /users/{id} [put]
 func (h *Handler) UpdateUser(c *gin.Context) {
 	id := c.Param("id")
@@ -267,9 +280,9 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 	}
 
 	// Get existing user
-	existingUser, exists := h.repo.Get(id)
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	existingUser, err := h.repo.Get(c.Request.Context(), id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -316,7 +329,7 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 	// Update in repository (repository handles Airtable sync if configured)
 	updated, err := h.repo.Update(c.Request.Context(), id, updatedUser)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -325,6 +338,114 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 	c.JSON(http.StatusOK, updated)
 }
 
+// SetUserAvatar godoc
+// @Summary      Set a user's avatar
+// @Description  Upload an image as multipart form field "file" (at most 5 MB), or send JSON with the URL of an image for Airtable to fetch. Replaces any existing avatar (requires admin role)
+// @Tags         users
+// @Accept       mpfd
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string            true   "User ID"
+// @Param        file    formData  file              false  "Image file"
+// @Param        avatar  body      avatarURLPayload  false  "Image URL"
+// @Success      200     {object}  User
+// @Failure      400     {object}  map[string]string
+// @Failure      401     {object}  map[string]string
+// @Failure      403     {object}  map[string]string
+// @Failure      404     {object}  map[string]string
+// @Failure      413     {object}  map[string]string
+// @Failure      422     {object}  map[string]string
+// @Failure      503     {object}  map[string]string
+// @Router       /users/{id}/avatar [put]
+func (h *Handler) SetUserAvatar(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+
+	avatar, ok := avatarFromRequest(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.repo.SetAvatar(c.Request.Context(), id, avatar)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	// Remove password from response
+	updated.Password = ""
+	c.JSON(http.StatusOK, updated)
+}
+
+type avatarURLPayload struct {
+	URL      string `json:"url" binding:"required,url"` // Public URL Airtable downloads the image from
+	Filename string `json:"filename"`
+}
+
+// avatarFromRequest reads an avatar from a multipart upload or a JSON URL
+// payload. On failure it writes the error response and returns false.
+func avatarFromRequest(c *gin.Context) (airtable.AttachmentSource, bool) {
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		var payload avatarURLPayload
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return airtable.AttachmentSource{}, false
+		}
+		return airtable.AttachmentSource{URL: payload.URL, Filename: payload.Filename}, true
+	}
+
+	// Leave room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, airtable.MaxUploadSize+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is larger than 5 MB"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		}
+		return airtable.AttachmentSource{}, false
+	}
+	defer file.Close()
+
+	avatar, err := airtable.NewAttachmentSource(header.Filename, header.Header.Get("Content-Type"), file)
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is larger than 5 MB"})
+		return airtable.AttachmentSource{}, false
+	}
+	if !strings.HasPrefix(avatar.ContentType, "image/") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file must be an image"})
+		return airtable.AttachmentSource{}, false
+	}
+	return avatar, true
+}
+
+// respondError writes err as a JSON error response with a status code derived
+// from its type: 404 for unknown users, 409 for duplicate emails, and the
+// Airtable mapping (422/503) for failures reported by Airtable.
+func respondError(c *gin.Context, err error) {
+	var status int
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrEmailExists):
+		status = http.StatusConflict
+	case errors.Is(err, tenant.ErrUnknown):
+		status = http.StatusNotFound
+	default:
+		status = airtable.HTTPStatus(err)
+	}
+
+	if retryAfter, ok := airtable.RetryAfter(err); ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
+
 // RegisterRequest represents the registration request payload
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
@@ -1,35 +1,57 @@
 package user
 
-import "time"
+import (
+	"time"
 
-// ToAirtableFieldsForCreate converts a User to Airtable fields format for creation
-func (u *User) ToAirtableFieldsForCreate() map[string]interface{} {
-	now := time.Now().Format(time.RFC3339)
-	fields := map[string]interface{}{
-		FieldEmail:     u.Email,
-		FieldPassword:  u.Password, // Already hashed
-		FieldCreatedAt: now,
-		FieldUpdatedAt: now,
-	}
-	if u.Role != "" {
-		fields[FieldRole] = u.Role
+	"lam-phuong-api/internal/airtable"
+)
+
+// AirtableSchema lists the fields the users table must provide. locationsTable
+// is the table the Locations field links to.
+func AirtableSchema(table, locationsTable string) airtable.TableRequirement {
+	text := []string{airtable.FieldTypeSingleLineText, airtable.FieldTypeMultilineText}
+	date := []string{airtable.FieldTypeDateTime, airtable.FieldTypeDate, airtable.FieldTypeSingleLineText}
+	return airtable.TableRequirement{
+		Table: table,
+		Fields: []airtable.FieldRequirement{
+			{Name: FieldEmail, Types: []string{airtable.FieldTypeEmail, airtable.FieldTypeSingleLineText}},
+			{Name: FieldPublicID, Types: text},
+			{Name: FieldPassword, Types: text},
+			{Name: FieldRole, Types: []string{airtable.FieldTypeSingleSelect, airtable.FieldTypeSingleLineText}, Options: airtable.SelectOptions(ValidRoles...)},
+			{Name: FieldAvatar, Types: []string{airtable.FieldTypeAttachments}, Optional: true},
+			{Name: FieldLocations, Types: []string{airtable.FieldTypeRecordLinks}, Optional: true, LinkedTable: locationsTable},
+			{Name: FieldCreatedAt, Types: date},
+			{Name: FieldUpdatedAt, Types: date},
+		},
 	}
-	return fields
 }
 
-// ToAirtableFieldsForUpdate converts a User to Airtable fields format for update
-func (u *User) ToAirtableFieldsForUpdate() map[string]interface{} {
-	now := time.Now().Format(time.RFC3339)
-	fields := map[string]interface{}{
-		FieldEmail:     u.Email,
-		FieldUpdatedAt: now,
-	}
-	if u.Password != "" {
-		fields[FieldPassword] = u.Password // Already hashed
+// AirtableLinks declares the links from the users table to other tables.
+func AirtableLinks(table, locationsTable string) []airtable.Link {
+	return []airtable.Link{
+		{Table: table, Field: FieldLocations, LinkedTable: locationsTable},
 	}
-	if u.Role != "" {
-		fields[FieldRole] = u.Role
+}
+
+// ToAirtableFieldsForCreate converts a User to Airtable fields format for creation
+func (u *User) ToAirtableFieldsForCreate() (map[string]interface{}, error) {
+	fields, err := airtable.Marshal(u) // Password is already hashed
+	if err != nil {
+		return nil, err
 	}
-	return fields
+	now := time.Now().Format(time.RFC3339)
+	fields[FieldCreatedAt] = now
+	fields[FieldUpdatedAt] = now
+	return fields, nil
 }
 
+// ToAirtableFieldsForUpdate converts a User to Airtable fields format for update.
+// Empty password and role are left unchanged.
+func (u *User) ToAirtableFieldsForUpdate() (map[string]interface{}, error) {
+	fields, err := airtable.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	fields[FieldUpdatedAt] = time.Now().Format(time.RFC3339)
+	return fields, nil
+}
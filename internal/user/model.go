@@ -4,15 +4,22 @@ import (
 	"fmt"
 	"time"
 
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/location"
+
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Airtable field names
+// Airtable field names, as used in code. Each can be mapped to another column
+// name or a field ID with AIRTABLE_USERS_FIELDS
 const (
+	FieldPublicID  = "Public ID"
 	FieldEmail     = "Email"
 	FieldPassword  = "Password"
 	FieldRole      = "Role"
+	FieldAvatar    = "Avatar"
+	FieldLocations = "Locations"
 	FieldCreatedAt = "Created At"
 	FieldUpdatedAt = "Updated At"
 )
@@ -27,57 +34,41 @@ const (
 // ValidRoles contains all valid user roles
 var ValidRoles = []string{RoleSuperAdmin, RoleAdmin, RoleUser}
 
-// Helper functions
-func getStringField(fields map[string]interface{}, key string) string {
-	if val, ok := fields[key]; ok {
-		if str, ok := val.(string); ok {
-			return str
-		}
-	}
-	return ""
-}
-
 // User represents a user in the system
 type User struct {
-	ID       string `json:"id"`
-	Email    string `json:"email"`
-	Password string `json:"-"` // Never serialize password in JSON responses
-	Role     string `json:"role"`
-}
-
-// ToAirtableFields converts a User to Airtable fields format (for creation)
-// Deprecated: Use ToAirtableFieldsForCreate() instead
-func (u *User) ToAirtableFields() map[string]interface{} {
-	return u.ToAirtableFieldsForCreate()
+	ID       string               `json:"id" airtable:"Public ID,omitempty"` // Stable public ID, see package publicid
+	RecordID string               `json:"-" airtable:",id"`                  // Airtable record ID, never exposed
+	Email    string               `json:"email" airtable:"Email"`
+	Password string               `json:"-" airtable:"Password,omitempty"` // Never serialize password in JSON responses
+	Role     string               `json:"role" airtable:"Role,omitempty"`
+	Avatar   *airtable.Attachment `json:"avatar,omitempty" airtable:"Avatar,readonly"` // Managed through SetAvatar
+
+	// Locations the user manages, linked in Airtable
+	LocationIDs       []string            `json:"location_ids,omitempty"`          // Public IDs, filled when the link is expanded
+	LocationRecordIDs []string            `json:"-" airtable:"Locations,readonly"` // Record IDs of the linked locations
+	Locations         []location.Location `json:"locations,omitempty"`             // Filled when the link is expanded
 }
 
 // FromAirtable maps an Airtable record to a User
-func FromAirtable(record map[string]interface{}) (*User, error) {
-	// Safely extract ID
-	id := ""
-	if idVal, ok := record["id"]; ok {
-		if idStr, ok := idVal.(string); ok {
-			id = idStr
-		}
+func FromAirtable(record airtable.Record) (*User, error) {
+	var user User
+	if err := airtable.Unmarshal(record, &user); err != nil {
+		return nil, err
 	}
-
-	// Safely extract fields
-	fields, ok := record["fields"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid record: missing or invalid 'fields'")
+	if user.Role == "" {
+		user.Role = RoleUser // Default role
 	}
-
-	role := getStringField(fields, FieldRole)
-	if role == "" {
-		role = RoleUser // Default role
+	for _, linked := range record.Expanded[FieldLocations] {
+		loc, err := location.FromAirtable(linked)
+		if err != nil {
+			return nil, err
+		}
+		user.Locations = append(user.Locations, *loc)
+		if loc.ID != "" {
+			user.LocationIDs = append(user.LocationIDs, loc.ID)
+		}
 	}
-
-	return &User{
-		ID:       id,
-		Email:    getStringField(fields, FieldEmail),
-		Password: getStringField(fields, FieldPassword),
-		Role:     role,
-	}, nil
+	return &user, nil
 }
 
 // HashPassword hashes a plain text password using bcrypt
@@ -100,6 +91,7 @@ type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	Tenant string `json:"tenant,omitempty"` // Tenant the user belongs to; empty means the default tenant
 	jwt.RegisteredClaims
 }
 
@@ -111,8 +103,8 @@ type TokenResponse struct {
 	User        User   `json:"user"`
 }
 
-// GenerateToken generates a JWT token for the user
-func GenerateToken(user User, secretKey string, expiresIn time.Duration) (string, error) {
+// GenerateToken generates a JWT token for a user of the given tenant
+func GenerateToken(user User, tenantName string, secretKey string, expiresIn time.Duration) (string, error) {
 	expirationTime := time.Now().Add(expiresIn)
 	role := user.Role
 	if role == "" {
@@ -122,6 +114,7 @@ func GenerateToken(user User, secretKey string, expiresIn time.Duration) (string
 		UserID: user.ID,
 		Email:  user.Email,
 		Role:   role,
+		Tenant: tenantName,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -2,52 +2,62 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 
 	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/publicid"
+)
+
+// Repository errors
+var (
+	// ErrNotFound is returned when no user matches the requested ID or email
+	ErrNotFound = errors.New("user not found")
+	// ErrEmailExists is returned when creating a user with an email that is already registered
+	ErrEmailExists = errors.New("user with this email already exists")
 )
 
 // Repository defines behavior for storing and retrieving users
 type Repository interface {
-	List() []User
-	Get(id string) (User, bool)
+	List(ctx context.Context) []User
+	Get(ctx context.Context, id string) (User, error)
 	Create(ctx context.Context, user User) (User, error)
 	Update(ctx context.Context, id string, user User) (User, error)
-	Delete(id string) bool
-	GetByEmail(email string) (User, bool)
+	Delete(ctx context.Context, id string) error
+	GetByEmail(ctx context.Context, email string) (User, error)
+	SetAvatar(ctx context.Context, id string, avatar airtable.AttachmentSource) (User, error)
 }
 
-// InMemoryRepository stores users in memory and is safe for concurrent access
+// InMemoryRepository stores users in memory, keyed by public ID, and is safe
+// for concurrent access
 type InMemoryRepository struct {
-	mu     sync.RWMutex
-	data   map[string]User
-	nextID int
+	mu   sync.RWMutex
+	data map[string]User
 }
 
-// NewInMemoryRepository creates a new in-memory user repository
+// NewInMemoryRepository creates a new in-memory user repository. Seed users
+// without an ID are assigned one
 func NewInMemoryRepository(seed []User) *InMemoryRepository {
 	repo := &InMemoryRepository{
-		data:   make(map[string]User),
-		nextID: 1,
+		data: make(map[string]User),
 	}
 
 	for _, u := range seed {
-		repo.data[u.ID] = u
-		if id, err := strconv.Atoi(u.ID); err == nil && id >= repo.nextID {
-			repo.nextID = id + 1
+		if u.ID == "" {
+			u.ID = publicid.New()
 		}
+		repo.data[u.ID] = u
 	}
 
 	return repo
 }
 
-// List returns all users sorted by ID
-func (r *InMemoryRepository) List() []User {
+// List returns all users sorted by ID, which is creation order
+func (r *InMemoryRepository) List(ctx context.Context) []User {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -63,7 +73,7 @@ func (r *InMemoryRepository) List() []User {
 	return users
 }
 
-// Create adds a new user and automatically assigns an ID
+// Create adds a new user, assigning a public ID unless it already has one
 func (r *InMemoryRepository) Create(ctx context.Context, user User) (User, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -71,51 +81,55 @@ func (r *InMemoryRepository) Create(ctx context.Context, user User) (User, error
 	// Check if email already exists
 	for _, u := range r.data {
 		if u.Email == user.Email {
-			return User{}, fmt.Errorf("user with email %s already exists", user.Email)
+			return User{}, fmt.Errorf("%w: %s", ErrEmailExists, user.Email)
 		}
 	}
 
-	user.ID = strconv.Itoa(r.nextID)
-	r.nextID++
+	if user.ID == "" {
+		user.ID = publicid.New()
+	}
 	r.data[user.ID] = user
 
 	return user, nil
 }
 
 // Delete removes a user by ID
-func (r *InMemoryRepository) Delete(id string) bool {
+func (r *InMemoryRepository) Delete(ctx context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	if _, exists := r.data[id]; !exists {
-		return false
+		return ErrNotFound
 	}
 
 	delete(r.data, id)
-	return true
+	return nil
 }
 
 // Get retrieves a user by ID
-func (r *InMemoryRepository) Get(id string) (User, bool) {
+func (r *InMemoryRepository) Get(ctx context.Context, id string) (User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	user, exists := r.data[id]
-	return user, exists
+	if !exists {
+		return User{}, ErrNotFound
+	}
+	return user, nil
 }
 
 // GetByEmail retrieves a user by email
-func (r *InMemoryRepository) GetByEmail(email string) (User, bool) {
+func (r *InMemoryRepository) GetByEmail(ctx context.Context, email string) (User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for _, user := range r.data {
 		if user.Email == email {
-			return user, true
+			return user, nil
 		}
 	}
 
-	return User{}, false
+	return User{}, ErrNotFound
 }
 
 // Update updates an existing user
@@ -126,11 +140,12 @@ func (r *InMemoryRepository) Update(ctx context.Context, id string, updatedUser
 	// Check if user exists
 	existingUser, exists := r.data[id]
 	if !exists {
-		return User{}, fmt.Errorf("user with id %s not found", id)
+		return User{}, ErrNotFound
 	}
 
-	// Preserve ID and email (email should not be changed via update)
+	// Preserve IDs and email (email should not be changed via update)
 	updatedUser.ID = id
+	updatedUser.RecordID = existingUser.RecordID
 	updatedUser.Email = existingUser.Email
 
 	// If password is empty, keep the existing password
@@ -147,11 +162,73 @@ func (r *InMemoryRepository) Update(ctx context.Context, id string, updatedUser
 	return updatedUser, nil
 }
 
+// SetAvatar replaces a user's avatar. Without Airtable there is nowhere to
+// store uploaded content, so only the avatar's metadata is kept.
+func (r *InMemoryRepository) SetAvatar(ctx context.Context, id string, avatar airtable.AttachmentSource) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.data[id]
+	if !exists {
+		return User{}, ErrNotFound
+	}
+
+	user.Avatar = &airtable.Attachment{
+		URL:      avatar.URL,
+		Filename: avatar.Filename,
+		Size:     int64(len(avatar.Content)),
+		Type:     avatar.ContentType,
+	}
+	r.data[id] = user
+	return user, nil
+}
+
+// ApplyAirtableChanges updates the cache with users created, edited or
+// deleted directly in Airtable. It has the signature of airtable.ChangeHandler.
+// Records without a public ID are skipped until one is assigned
+func (r *InMemoryRepository) ApplyAirtableChanges(ctx context.Context, changes airtable.RecordChanges) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, record := range changes.Upserted {
+		user, err := FromAirtable(record)
+		if err != nil {
+			log.Printf("Skipping Airtable record %s due to mapping error: %v", record.ID, err)
+			continue
+		}
+		if user.ID == "" {
+			log.Printf("Skipping Airtable record %s without a public ID", record.ID)
+			continue
+		}
+
+		// Replace the copy cached under the record's previous public ID, if it was edited
+		r.deleteRecord(record.ID)
+		r.data[user.ID] = *user
+	}
+
+	for _, recordID := range changes.Deleted {
+		r.deleteRecord(recordID)
+	}
+
+	return nil
+}
+
+// deleteRecord removes the user stored in the given Airtable record. The
+// caller must hold r.mu
+func (r *InMemoryRepository) deleteRecord(recordID string) {
+	for id, user := range r.data {
+		if user.RecordID == recordID {
+			delete(r.data, id)
+		}
+	}
+}
+
 // AirtableRepository wraps a Repository and adds Airtable persistence
 type AirtableRepository struct {
 	repo           Repository
 	airtableClient *airtable.Client
 	airtableTable  string
+	queue          *airtable.WriteQueue // Nil rejects writes while Airtable is unavailable
 }
 
 // NewAirtableRepository creates a repository that syncs to Airtable
@@ -163,34 +240,59 @@ func NewAirtableRepository(repo Repository, airtableClient *airtable.Client, air
 	}
 }
 
+// SetWriteQueue makes writes that fail because the Airtable circuit breaker
+// is open succeed locally and be replayed once Airtable recovers, instead of
+// being rejected
+func (r *AirtableRepository) SetWriteQueue(queue *airtable.WriteQueue) {
+	r.queue = queue
+}
+
 // List returns all users from Airtable, falling back to underlying repository
-func (r *AirtableRepository) List() []User {
-	records, err := r.airtableClient.ListRecords(context.Background(), r.airtableTable, nil)
+func (r *AirtableRepository) List(ctx context.Context) []User {
+	params := &airtable.ListParams{Expand: []string{FieldLocations}}
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, params)
 	if err != nil {
 		log.Printf("Failed to list users from Airtable: %v", err)
-		return r.repo.List()
+		return r.repo.List(ctx)
+	}
+
+	// Users added in the Airtable UI have no public ID until first listed
+	if _, err := publicid.Assign(ctx, r.airtableClient, r.airtableTable, FieldPublicID, records); err != nil {
+		log.Printf("Failed to assign public IDs to users: %v", err)
 	}
 
 	users := make([]User, 0, len(records))
 	for _, record := range records {
-		user, err := mapAirtableRecord(record)
+		user, err := FromAirtable(record)
 		if err != nil {
 			log.Printf("Failed to map Airtable record: %v", err)
 			continue
 		}
-		users = append(users, user)
+		if user.ID == "" {
+			continue // Assigning its public ID failed
+		}
+		users = append(users, *user)
 	}
 
 	// If Airtable returns no records, fall back to underlying repository
 	if len(users) == 0 {
-		return r.repo.List()
+		return r.repo.List(ctx)
 	}
 
 	return users
 }
 
-// Create adds a new user to the repository and syncs it to Airtable
+// Create adds a new user to the repository and syncs it to Airtable.
+// If Airtable rejects the user, the local copy is removed again and the error is returned.
 func (r *AirtableRepository) Create(ctx context.Context, user User) (User, error) {
+	if user.ID == "" {
+		user.ID = publicid.New()
+	}
+	airtableFields, err := user.ToAirtableFieldsForCreate()
+	if err != nil {
+		return User{}, err
+	}
+
 	// Create in the underlying repository first
 	created, err := r.repo.Create(ctx, user)
 	if err != nil {
@@ -198,141 +300,326 @@ func (r *AirtableRepository) Create(ctx context.Context, user User) (User, error
 	}
 
 	// Save to Airtable
-	airtableFields := created.ToAirtableFieldsForCreate()
 	log.Printf("Attempting to save user to Airtable table: %s", r.airtableTable)
 	airtableRecord, err := r.airtableClient.CreateRecord(ctx, r.airtableTable, airtableFields)
+	if err != nil && r.queueable(err) {
+		queueErr := r.queue.Enqueue("create user "+created.Email, func(ctx context.Context) error {
+			record, err := r.airtableClient.CreateRecord(ctx, r.airtableTable, airtableFields)
+			if err != nil {
+				return err
+			}
+			r.cacheRecord(ctx, record)
+			return nil
+		})
+		if queueErr == nil {
+			return created, nil
+		}
+		err = queueErr
+	}
 	if err != nil {
-		// Log error but don't fail - user is already created in repo
 		log.Printf("Failed to save user to Airtable: %v", err)
 		log.Printf("Error details - Table: %s, Fields: %+v", r.airtableTable, airtableFields)
-		return created, nil // Return created user even if Airtable save failed
+		if deleteErr := r.repo.Delete(ctx, created.ID); deleteErr != nil {
+			log.Printf("Failed to roll back user %s: %v", created.ID, deleteErr)
+		}
+		return User{}, err
 	}
 
-	// Update the created user with Airtable ID
-	created.ID = airtableRecord.ID
-	log.Printf("User saved to Airtable successfully with ID: %s", airtableRecord.ID)
+	// Remember the Airtable record ID; the public ID stays the same
+	created.RecordID = airtableRecord.ID
+	r.cacheRecord(ctx, airtableRecord)
+	log.Printf("User %s saved to Airtable successfully with record ID: %s", created.ID, airtableRecord.ID)
 	return created, nil
 }
 
-// Delete removes a user from the underlying repository and Airtable
-func (r *AirtableRepository) Delete(id string) bool {
-	// Delete from underlying repository
-	deleted := r.repo.Delete(id)
-	if !deleted {
-		return false
+// Delete removes a user from Airtable and the underlying repository
+func (r *AirtableRepository) Delete(ctx context.Context, id string) error {
+	airtableErr := r.deleteFromAirtable(ctx, id)
+	if airtableErr != nil && r.queueable(airtableErr) {
+		if err := r.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		queueErr := r.queue.Enqueue("delete user "+id, func(ctx context.Context) error {
+			err := r.deleteFromAirtable(ctx, id)
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			return err
+		})
+		if queueErr == nil {
+			return nil
+		}
+		airtableErr = queueErr
+	}
+	if airtableErr != nil && !errors.Is(airtableErr, ErrNotFound) {
+		log.Printf("Failed to delete Airtable record for user %s: %v", id, airtableErr)
+		return airtableErr
 	}
 
-	// Attempt to delete from Airtable
-	if err := r.airtableClient.DeleteRecord(context.Background(), r.airtableTable, id); err != nil {
-		log.Printf("Failed to delete Airtable record for user %s: %v", id, err)
+	// Delete from underlying repository
+	repoErr := r.repo.Delete(ctx, id)
+	if airtableErr != nil && repoErr != nil {
+		// Neither Airtable nor the cache knew this user
+		return ErrNotFound
 	}
 
-	return true
+	return nil
+}
+
+// deleteFromAirtable deletes the Airtable record of the user with the given
+// public ID
+func (r *AirtableRepository) deleteFromAirtable(ctx context.Context, id string) error {
+	recordID, err := r.recordID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return notFoundOr(r.airtableClient.DeleteRecord(ctx, r.airtableTable, recordID))
 }
 
 // Get retrieves a user by ID from Airtable, falling back to underlying repository
-func (r *AirtableRepository) Get(id string) (User, bool) {
-	record, err := r.airtableClient.GetRecord(context.Background(), r.airtableTable, id)
+func (r *AirtableRepository) Get(ctx context.Context, id string) (User, error) {
+	if !publicid.Valid(id) {
+		return User{}, ErrNotFound
+	}
+
+	record, err := r.findRecord(ctx, id, FieldLocations)
 	if err != nil {
-		log.Printf("Failed to get user from Airtable: %v", err)
-		return r.repo.Get(id)
+		if !errors.Is(err, ErrNotFound) {
+			log.Printf("Failed to get user from Airtable: %v", err)
+		}
+		if user, repoErr := r.repo.Get(ctx, id); repoErr == nil {
+			return user, nil
+		}
+		return User{}, err
 	}
 
-	user, err := mapAirtableRecord(record)
+	user, err := FromAirtable(record)
 	if err != nil {
 		log.Printf("Failed to map Airtable record: %v", err)
-		return r.repo.Get(id)
+		return r.repo.Get(ctx, id)
 	}
 
-	return user, true
+	return *user, nil
 }
 
 // GetByEmail retrieves a user by email, preferring Airtable and falling back to repo cache
-func (r *AirtableRepository) GetByEmail(email string) (User, bool) {
+func (r *AirtableRepository) GetByEmail(ctx context.Context, email string) (User, error) {
 	email = strings.TrimSpace(email)
 	if email == "" {
-		return User{}, false
+		return User{}, ErrNotFound
 	}
 
-	filter := fmt.Sprintf(
-		"LOWER({%s}) = '%s'",
-		FieldEmail,
-		escapeAirtableFormulaValue(strings.ToLower(email)),
-	)
+	filter := airtable.Lower(airtable.Field(FieldEmail)).Eq(strings.ToLower(email)).String()
 
 	records, err := r.airtableClient.ListRecords(
-		context.Background(),
+		ctx,
 		r.airtableTable,
 		&airtable.ListParams{
-			PageSize:        1,
+			MaxRecords:      1,
 			FilterByFormula: filter,
 		},
 	)
 	if err != nil {
 		log.Printf("Failed to find user by email in Airtable: %v", err)
-		return r.repo.GetByEmail(email)
+		if user, repoErr := r.repo.GetByEmail(ctx, email); repoErr == nil {
+			return user, nil
+		}
+		return User{}, notFoundOr(err)
 	}
 
 	if len(records) > 0 {
-		user, mapErr := mapAirtableRecord(records[0])
+		if _, err := publicid.Assign(ctx, r.airtableClient, r.airtableTable, FieldPublicID, records); err != nil {
+			log.Printf("Failed to assign a public ID to user %s: %v", email, err)
+		}
+		user, mapErr := FromAirtable(records[0])
 		if mapErr == nil {
-			return user, true
+			return *user, nil
 		}
 		log.Printf("Failed to map Airtable user for email %s: %v", email, mapErr)
 	}
 
-	return r.repo.GetByEmail(email)
+	return r.repo.GetByEmail(ctx, email)
 }
 
-// Update updates an existing user in the repository and syncs it to Airtable
+// Update updates an existing user in the repository and syncs it to Airtable.
+// If Airtable rejects the update, the local copy is restored and the error is returned.
 func (r *AirtableRepository) Update(ctx context.Context, id string, updatedUser User) (User, error) {
 	// Get existing user to preserve email
-	existingUser, exists := r.repo.Get(id)
-	if !exists {
+	existingUser, err := r.repo.Get(ctx, id)
+	cached := err == nil
+	if !cached {
 		// Try to get from Airtable
-		existingUser, exists = r.Get(id)
-		if !exists {
-			return User{}, fmt.Errorf("user with id %s not found", id)
+		existingUser, err = r.Get(ctx, id)
+		if err != nil {
+			return User{}, err
 		}
 	}
 
-	// Preserve email (should not be changed via update)
+	// Preserve IDs and email (email should not be changed via update)
+	updatedUser.ID = id
+	updatedUser.RecordID = existingUser.RecordID
 	updatedUser.Email = existingUser.Email
 
-	// Update in the underlying repository first
-	updated, err := r.repo.Update(ctx, id, updatedUser)
+	airtableFields, err := updatedUser.ToAirtableFieldsForUpdate()
 	if err != nil {
 		return User{}, err
 	}
 
+	// Update in the underlying repository first
+	updated := updatedUser
+	updated.ID = id
+	if cached {
+		updated, err = r.repo.Update(ctx, id, updatedUser)
+		if err != nil {
+			return User{}, err
+		}
+	}
+
 	// Update in Airtable (partial update - only changed fields)
-	airtableFields := updated.ToAirtableFieldsForUpdate()
 	log.Printf("Attempting to update user in Airtable table: %s", r.airtableTable)
-	_, err = r.airtableClient.UpdateRecordPartial(ctx, r.airtableTable, id, airtableFields)
+	err = r.updateInAirtable(ctx, id, existingUser.RecordID, airtableFields)
+	if err != nil && cached && r.queueable(err) {
+		queueErr := r.queue.Enqueue("update user "+id, func(ctx context.Context) error {
+			return r.updateInAirtable(ctx, id, existingUser.RecordID, airtableFields)
+		})
+		if queueErr == nil {
+			return updated, nil
+		}
+		err = queueErr
+	}
 	if err != nil {
-		// Log error but don't fail - user is already updated in repo
 		log.Printf("Failed to update user in Airtable: %v", err)
 		log.Printf("Error details - Table: %s, ID: %s, Fields: %+v", r.airtableTable, id, airtableFields)
-		return updated, nil // Return updated user even if Airtable update failed
+		if cached {
+			if _, restoreErr := r.repo.Update(ctx, id, existingUser); restoreErr != nil {
+				log.Printf("Failed to restore user %s: %v", id, restoreErr)
+			}
+		}
+		return User{}, notFoundOr(err)
 	}
 
 	log.Printf("User updated in Airtable successfully with ID: %s", id)
 	return updated, nil
 }
 
-func mapAirtableRecord(record airtable.Record) (User, error) {
-	role := getStringField(record.Fields, FieldRole)
-	if role == "" {
-		role = RoleUser // Default role
-	}
-	return User{
-		ID:       record.ID,
-		Email:    getStringField(record.Fields, FieldEmail),
-		Password: getStringField(record.Fields, FieldPassword),
-		Role:     role,
-	}, nil
+// updateInAirtable writes fields to the Airtable record of the user with the
+// given public ID. recordID may be empty when the record ID is not known yet,
+// for example for a user whose creation was queued
+func (r *AirtableRepository) updateInAirtable(ctx context.Context, id, recordID string, fields map[string]interface{}) error {
+	if recordID == "" {
+		var err error
+		if recordID, err = r.recordID(ctx, id); err != nil {
+			return err
+		}
+	}
+	_, err := r.airtableClient.UpdateRecordPartial(ctx, r.airtableTable, recordID, fields)
+	return err
+}
+
+// SetAvatar replaces the user's Avatar attachment in Airtable and returns
+// the updated user.
+func (r *AirtableRepository) SetAvatar(ctx context.Context, id string, avatar airtable.AttachmentSource) (User, error) {
+	recordID, err := r.recordID(ctx, id)
+	if err != nil {
+		return User{}, err
+	}
+
+	replacement := airtable.Attachment{URL: avatar.URL, Filename: avatar.Filename}
+	if avatar.Content != nil {
+		// Uploads append to the field, so keep only the new attachment afterwards
+		uploaded, err := r.airtableClient.UploadAttachment(ctx, r.airtableTable, recordID, FieldAvatar, avatar)
+		if err != nil {
+			log.Printf("Failed to upload avatar for user %s: %v", id, err)
+			return User{}, notFoundOr(err)
+		}
+		replacement = airtable.Attachment{ID: uploaded.ID}
+	}
+
+	record, err := r.airtableClient.SetAttachments(ctx, r.airtableTable, recordID, FieldAvatar, replacement)
+	if err != nil {
+		log.Printf("Failed to set avatar for user %s: %v", id, err)
+		return User{}, notFoundOr(err)
+	}
+
+	updated, err := FromAirtable(record)
+	if err != nil {
+		return User{}, err
+	}
+
+	// Keep the cache in step; it only stores the avatar's URL and metadata
+	if updated.Avatar != nil {
+		cached := airtable.AttachmentSource{URL: updated.Avatar.URL, Filename: updated.Avatar.Filename}
+		if _, err := r.repo.SetAvatar(ctx, id, cached); err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("Failed to cache avatar for user %s: %v", id, err)
+		}
+	}
+
+	log.Printf("Avatar updated in Airtable for user %s", id)
+	return *updated, nil
+}
+
+// recordID returns the Airtable record ID of the user with the given public
+// ID, from the cache when possible
+func (r *AirtableRepository) recordID(ctx context.Context, id string) (string, error) {
+	if !publicid.Valid(id) {
+		return "", ErrNotFound
+	}
+	if user, err := r.repo.Get(ctx, id); err == nil && user.RecordID != "" {
+		return user.RecordID, nil
+	}
+
+	record, err := r.findRecord(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return record.ID, nil
+}
+
+// findRecord returns the Airtable record of the user with the given public
+// ID, expanding the given link fields
+func (r *AirtableRepository) findRecord(ctx context.Context, id string, expand ...string) (airtable.Record, error) {
+	records, err := r.airtableClient.ListRecords(ctx, r.airtableTable, &airtable.ListParams{
+		MaxRecords:      1,
+		FilterByFormula: airtable.Field(FieldPublicID).Eq(id).String(),
+		Expand:          expand,
+	})
+	if err != nil {
+		return airtable.Record{}, err
+	}
+	if len(records) == 0 {
+		return airtable.Record{}, ErrNotFound
+	}
+	return records[0], nil
 }
 
-func escapeAirtableFormulaValue(value string) string {
-	return strings.ReplaceAll(value, "'", "''")
+// queueable reports whether a failed write should be queued rather than
+// returned: a write queue is set and the circuit breaker is open
+func (r *AirtableRepository) queueable(err error) bool {
+	return r.queue != nil && errors.Is(err, airtable.ErrCircuitOpen)
+}
+
+// cacheRecord stores a record written to Airtable in the underlying
+// repository, so the cached copy knows its record ID
+func (r *AirtableRepository) cacheRecord(ctx context.Context, record airtable.Record) {
+	cache, ok := r.repo.(interface {
+		ApplyAirtableChanges(ctx context.Context, changes airtable.RecordChanges) error
+	})
+	if !ok {
+		return
+	}
+	if err := cache.ApplyAirtableChanges(ctx, airtable.RecordChanges{
+		Table:    r.airtableTable,
+		Upserted: []airtable.Record{record},
+	}); err != nil {
+		log.Printf("Failed to cache replayed Airtable record %s: %v", record.ID, err)
+	}
+}
+
+// notFoundOr translates Airtable's not-found error into ErrNotFound and
+// returns any other error unchanged.
+func notFoundOr(err error) error {
+	if errors.Is(err, airtable.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
 }
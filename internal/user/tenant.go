@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+
+	"lam-phuong-api/internal/airtable"
+	"lam-phuong-api/internal/tenant"
+)
+
+// TenantRepository passes user lookups and changes on to the repository of
+// the request's tenant.
+type TenantRepository struct {
+	repos *tenant.Registry[Repository]
+}
+
+// NewTenantRepository creates a repository that routes calls by tenant.
+func NewTenantRepository(repos map[string]Repository) *TenantRepository {
+	return &TenantRepository{repos: tenant.NewRegistry(repos)}
+}
+
+// List returns the tenant's users, or none for an unknown tenant.
+func (r *TenantRepository) List(ctx context.Context) []User {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return []User{}
+	}
+	return repo.List(ctx)
+}
+
+// Get retrieves a user from the tenant's repository.
+func (r *TenantRepository) Get(ctx context.Context, id string) (User, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	return repo.Get(ctx, id)
+}
+
+// Create adds a user to the tenant's repository.
+func (r *TenantRepository) Create(ctx context.Context, user User) (User, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	return repo.Create(ctx, user)
+}
+
+// Update updates a user in the tenant's repository.
+func (r *TenantRepository) Update(ctx context.Context, id string, user User) (User, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	return repo.Update(ctx, id, user)
+}
+
+// Delete removes a user from the tenant's repository.
+func (r *TenantRepository) Delete(ctx context.Context, id string) error {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(ctx, id)
+}
+
+// GetByEmail retrieves a user by email from the tenant's repository.
+func (r *TenantRepository) GetByEmail(ctx context.Context, email string) (User, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	return repo.GetByEmail(ctx, email)
+}
+
+// SetAvatar replaces a user's avatar in the tenant's repository.
+func (r *TenantRepository) SetAvatar(ctx context.Context, id string, avatar airtable.AttachmentSource) (User, error) {
+	repo, err := r.repos.Get(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	return repo.SetAvatar(ctx, id, avatar)
+}